@@ -0,0 +1,254 @@
+package main
+
+import (
+	"go/ast"
+	"go/token"
+	"go/types"
+	"path/filepath"
+	"sort"
+)
+
+// typeNode is a package-level named type of the module.
+type typeNode struct {
+	Name         string  `json:"name"` // Qualified by the package name (e.g., cloudmodel.MciReq)
+	Package      string  `json:"package"`
+	File         string  `json:"file"` // Slash-separated path relative to the module root
+	Kind         string  `json:"kind"` // struct, string, int, map, slice, interface, ...
+	Dependencies []*edge `json:"dependencies"`
+	ReferencedBy []*edge `json:"referencedBy"`
+	UsedInCode   bool    `json:"usedInCode"` // Used outside type declarations (e.g., in functions and variables)
+}
+
+// edge is a dependency of a type on another type.
+type edge struct {
+	From     string   `json:"from"`
+	To       string   `json:"to"`
+	FromFile string   `json:"fromFile"`
+	ToFile   string   `json:"toFile"`
+	Fields   []string `json:"fields,omitempty"` // Fields referencing the type (e.g., SubGroups []CreateSubGroupReq)
+	Embedded bool     `json:"embedded,omitempty"`
+}
+
+// fileStats counts the types of a file.
+type fileStats struct {
+	File       string `json:"file"`
+	Structs    int    `json:"structs"`
+	OtherTypes int    `json:"otherTypes"`
+}
+
+// analysis is the result of the dependency analysis.
+type analysis struct {
+	Files        []fileStats `json:"files,omitempty"`
+	Types        []*typeNode `json:"types,omitempty"`
+	CrossFile    []*edge     `json:"crossFile,omitempty"`
+	Unreferenced []string    `json:"unreferenced,omitempty"` // Types not referenced by any other type
+	Unused       []string    `json:"unused,omitempty"`       // Unreferenced types not used in code either
+}
+
+func analyze(root string, fset *token.FileSet, pkgs []*modulePackage) *analysis {
+	nodes := make(map[*types.TypeName]*typeNode)
+	var order []*types.TypeName
+
+	// Collect the package-level named types.
+	for _, pkg := range pkgs {
+		scope := pkg.types.Scope()
+		for _, name := range scope.Names() {
+			tn, ok := scope.Lookup(name).(*types.TypeName)
+			if !ok || tn.IsAlias() {
+				continue
+			}
+			nodes[tn] = &typeNode{
+				Name:    pkg.types.Name() + "." + tn.Name(),
+				Package: pkg.path,
+				File:    relFile(fset, root, tn.Pos()),
+				Kind:    kindOf(tn.Type().Underlying()),
+			}
+			order = append(order, tn)
+		}
+	}
+
+	// Dependencies through fields, elements and embedded structs
+	for _, tn := range order {
+		node := nodes[tn]
+		deps := make(map[*types.TypeName]*edge)
+		var depOrder []*types.TypeName
+		walkType(tn.Type().Underlying(), "", false, types.RelativeTo(tn.Pkg()), make(map[types.Type]bool), func(dep *types.TypeName, field string, embedded bool) {
+			target, ok := nodes[dep]
+			if !ok || dep == tn {
+				return
+			}
+			e, ok := deps[dep]
+			if !ok {
+				e = &edge{From: node.Name, To: target.Name, FromFile: node.File, ToFile: target.File}
+				deps[dep] = e
+				depOrder = append(depOrder, dep)
+			}
+			if field != "" {
+				e.Fields = append(e.Fields, field)
+			}
+			e.Embedded = e.Embedded || embedded
+		})
+		for _, dep := range depOrder {
+			e := deps[dep]
+			node.Dependencies = append(node.Dependencies, e)
+			nodes[dep].ReferencedBy = append(nodes[dep].ReferencedBy, e)
+		}
+	}
+
+	// Uses outside type declarations and method receivers
+	for _, pkg := range pkgs {
+		declRanges := declarationRanges(pkg.files)
+		for ident, obj := range pkg.info.Uses {
+			tn, ok := obj.(*types.TypeName)
+			if !ok || nodes[tn] == nil || inRanges(declRanges, ident.Pos()) {
+				continue
+			}
+			nodes[tn].UsedInCode = true
+		}
+	}
+
+	a := &analysis{}
+	stats := make(map[string]*fileStats)
+	for _, tn := range order {
+		node := nodes[tn]
+		a.Types = append(a.Types, node)
+		s, ok := stats[node.File]
+		if !ok {
+			s = &fileStats{File: node.File}
+			stats[node.File] = s
+		}
+		if node.Kind == "struct" {
+			s.Structs++
+		} else {
+			s.OtherTypes++
+		}
+		for _, e := range node.Dependencies {
+			if e.FromFile != e.ToFile {
+				a.CrossFile = append(a.CrossFile, e)
+			}
+		}
+		if len(node.ReferencedBy) == 0 {
+			a.Unreferenced = append(a.Unreferenced, node.Name)
+			if !node.UsedInCode {
+				a.Unused = append(a.Unused, node.Name)
+			}
+		}
+	}
+	for _, s := range stats {
+		a.Files = append(a.Files, *s)
+	}
+	sort.Slice(a.Files, func(i, j int) bool { return a.Files[i].File < a.Files[j].File })
+	sort.SliceStable(a.Types, func(i, j int) bool { return a.Types[i].Name < a.Types[j].Name })
+	return a
+}
+
+// walkType calls visit for every named type reachable from t without passing through another named type.
+func walkType(t types.Type, field string, embedded bool, qf types.Qualifier, seen map[types.Type]bool, visit func(*types.TypeName, string, bool)) {
+	if seen[t] {
+		return
+	}
+	seen[t] = true
+	defer delete(seen, t)
+
+	switch t := types.Unalias(t).(type) {
+	case *types.Named:
+		visit(t.Obj(), field, embedded)
+		for i := 0; i < t.TypeArgs().Len(); i++ {
+			walkType(t.TypeArgs().At(i), field, false, qf, seen, visit)
+		}
+	case *types.Pointer:
+		walkType(t.Elem(), field, embedded, qf, seen, visit)
+	case *types.Slice:
+		walkType(t.Elem(), field, false, qf, seen, visit)
+	case *types.Array:
+		walkType(t.Elem(), field, false, qf, seen, visit)
+	case *types.Map:
+		walkType(t.Key(), field, false, qf, seen, visit)
+		walkType(t.Elem(), field, false, qf, seen, visit)
+	case *types.Chan:
+		walkType(t.Elem(), field, false, qf, seen, visit)
+	case *types.Struct:
+		for i := 0; i < t.NumFields(); i++ {
+			f := t.Field(i)
+			name := f.Name() + " " + types.TypeString(f.Type(), qf)
+			if f.Embedded() {
+				name = types.TypeString(f.Type(), qf) + " (embedded)"
+			}
+			if field != "" {
+				name = field + "." + name
+			}
+			walkType(f.Type(), name, f.Embedded(), qf, seen, visit)
+		}
+	case *types.Signature:
+		for _, tuple := range []*types.Tuple{t.Params(), t.Results()} {
+			for i := 0; i < tuple.Len(); i++ {
+				walkType(tuple.At(i).Type(), field, false, qf, seen, visit)
+			}
+		}
+	case *types.Interface:
+		for i := 0; i < t.NumExplicitMethods(); i++ {
+			walkType(t.ExplicitMethod(i).Type(), field, false, qf, seen, visit)
+		}
+		for i := 0; i < t.NumEmbeddeds(); i++ {
+			walkType(t.EmbeddedType(i), field, true, qf, seen, visit)
+		}
+	}
+}
+
+func kindOf(t types.Type) string {
+	switch t := t.(type) {
+	case *types.Struct:
+		return "struct"
+	case *types.Basic:
+		return t.Name()
+	case *types.Slice, *types.Array:
+		return "slice"
+	case *types.Map:
+		return "map"
+	case *types.Interface:
+		return "interface"
+	case *types.Signature:
+		return "func"
+	case *types.Pointer:
+		return "pointer"
+	default:
+		return "other"
+	}
+}
+
+// declarationRanges returns the ranges of the type declarations and method receivers of files.
+func declarationRanges(files []*ast.File) [][2]token.Pos {
+	var ranges [][2]token.Pos
+	for _, file := range files {
+		for _, decl := range file.Decls {
+			switch decl := decl.(type) {
+			case *ast.GenDecl:
+				if decl.Tok == token.TYPE {
+					ranges = append(ranges, [2]token.Pos{decl.Pos(), decl.End()})
+				}
+			case *ast.FuncDecl:
+				if decl.Recv != nil {
+					ranges = append(ranges, [2]token.Pos{decl.Recv.Pos(), decl.Recv.End()})
+				}
+			}
+		}
+	}
+	return ranges
+}
+
+func inRanges(ranges [][2]token.Pos, pos token.Pos) bool {
+	for _, r := range ranges {
+		if r[0] <= pos && pos < r[1] {
+			return true
+		}
+	}
+	return false
+}
+
+func relFile(fset *token.FileSet, root string, pos token.Pos) string {
+	rel, err := filepath.Rel(root, fset.Position(pos).Filename)
+	if err != nil {
+		return fset.Position(pos).Filename
+	}
+	return filepath.ToSlash(rel)
+}
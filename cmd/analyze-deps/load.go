@@ -0,0 +1,153 @@
+package main
+
+import (
+	"fmt"
+	"go/ast"
+	"go/importer"
+	"go/parser"
+	"go/token"
+	"go/types"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// modulePackage is a type-checked package of the module.
+type modulePackage struct {
+	path  string // Import path
+	dir   string // Slash-separated directory relative to the module root
+	files []*ast.File
+	types *types.Package
+	info  *types.Info
+}
+
+// loader type-checks the packages of the module from source, importing the others with the source importer.
+type loader struct {
+	root     string
+	module   string
+	fset     *token.FileSet
+	parsed   map[string][]*ast.File // Import path -> files
+	dirs     map[string]string      // Import path -> relative directory
+	checked  map[string]*modulePackage
+	checking map[string]bool
+	fallback types.Importer
+}
+
+var modulePattern = regexp.MustCompile(`(?m)^module\s+(\S+)`)
+
+// loadModule parses and type-checks all non-main packages of the module (commands are tools, not models).
+func loadModule(root string, fset *token.FileSet) ([]*modulePackage, error) {
+	gomod, err := os.ReadFile(filepath.Join(root, "go.mod"))
+	if err != nil {
+		return nil, err
+	}
+	m := modulePattern.FindSubmatch(gomod)
+	if m == nil {
+		return nil, fmt.Errorf("no module path in %s", filepath.Join(root, "go.mod"))
+	}
+
+	l := &loader{
+		root:     root,
+		module:   string(m[1]),
+		fset:     fset,
+		parsed:   make(map[string][]*ast.File),
+		dirs:     make(map[string]string),
+		checked:  make(map[string]*modulePackage),
+		checking: make(map[string]bool),
+		fallback: importer.ForCompiler(fset, "source", nil),
+	}
+	if err := l.parse(); err != nil {
+		return nil, err
+	}
+
+	paths := make([]string, 0, len(l.parsed))
+	for path := range l.parsed {
+		paths = append(paths, path)
+	}
+	sort.Strings(paths)
+
+	var pkgs []*modulePackage
+	for _, path := range paths {
+		if l.parsed[path][0].Name.Name == "main" {
+			continue
+		}
+		pkg, err := l.check(path)
+		if err != nil {
+			return nil, err
+		}
+		pkgs = append(pkgs, pkg)
+	}
+	return pkgs, nil
+}
+
+// parse parses the non-test Go files of every directory of the module.
+func (l *loader) parse() error {
+	return filepath.WalkDir(l.root, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if d.IsDir() {
+			name := d.Name()
+			if path != l.root && (strings.HasPrefix(name, ".") || strings.HasPrefix(name, "_") || name == "testdata" || name == "vendor") {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if !strings.HasSuffix(path, ".go") || strings.HasSuffix(path, "_test.go") {
+			return nil
+		}
+
+		file, err := parser.ParseFile(l.fset, path, nil, parser.ParseComments|parser.SkipObjectResolution)
+		if err != nil {
+			return err
+		}
+		rel, _ := filepath.Rel(l.root, filepath.Dir(path))
+		rel = filepath.ToSlash(rel)
+		importPath := l.module
+		if rel != "." {
+			importPath += "/" + rel
+		}
+		l.parsed[importPath] = append(l.parsed[importPath], file)
+		l.dirs[importPath] = rel
+		return nil
+	})
+}
+
+// Import implements types.Importer.
+func (l *loader) Import(path string) (*types.Package, error) {
+	if _, ok := l.parsed[path]; ok {
+		pkg, err := l.check(path)
+		if err != nil {
+			return nil, err
+		}
+		return pkg.types, nil
+	}
+	return l.fallback.Import(path)
+}
+
+func (l *loader) check(path string) (*modulePackage, error) {
+	if pkg, ok := l.checked[path]; ok {
+		return pkg, nil
+	}
+	if l.checking[path] {
+		return nil, fmt.Errorf("import cycle through %s", path)
+	}
+	l.checking[path] = true
+	defer delete(l.checking, path)
+
+	info := &types.Info{
+		Defs: make(map[*ast.Ident]types.Object),
+		Uses: make(map[*ast.Ident]types.Object),
+	}
+	conf := types.Config{Importer: l}
+	tpkg, err := conf.Check(path, l.fset, l.parsed[path], info)
+	if err != nil {
+		return nil, fmt.Errorf("failed to type-check %s: %w", path, err)
+	}
+	pkg := &modulePackage{path: path, dir: l.dirs[path], files: l.parsed[path], types: tpkg, info: info}
+	l.checked[path] = pkg
+	return pkg, nil
+}
@@ -0,0 +1,82 @@
+// Command analyze-deps analyzes the dependencies between the types of all packages in the module.
+// It is the go/types-based successor of scripts/analyze_dependencies.py: it follows pointer, slice, array and map
+// element types and embedded structs (e.g., VmInfraInfo{MciInfo}) across packages and files.
+//
+// Usage:
+//
+//	go run ./cmd/analyze-deps [--verbose] [--unused-only] [--cross-file-only] [--json]
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"go/token"
+	"log"
+	"os"
+	"path/filepath"
+)
+
+func main() {
+	var verbose, unusedOnly, crossFileOnly, jsonOutput bool
+	flag.BoolVar(&verbose, "verbose", false, "show detailed dependency information including fields")
+	flag.BoolVar(&verbose, "v", false, "shorthand for --verbose")
+	flag.BoolVar(&unusedOnly, "unused-only", false, "show only types not referenced by other types")
+	flag.BoolVar(&unusedOnly, "u", false, "shorthand for --unused-only")
+	flag.BoolVar(&crossFileOnly, "cross-file-only", false, "show only dependencies between types in different files")
+	flag.BoolVar(&crossFileOnly, "c", false, "shorthand for --cross-file-only")
+	flag.BoolVar(&jsonOutput, "json", false, "print the analysis in JSON")
+	root := flag.String("root", "", "module root directory (default: the nearest directory with go.mod)")
+	flag.Parse()
+
+	if *root == "" {
+		dir, err := findModuleRoot()
+		if err != nil {
+			log.Fatal(err)
+		}
+		*root = dir
+	}
+
+	fset := token.NewFileSet()
+	pkgs, err := loadModule(*root, fset)
+	if err != nil {
+		log.Fatal(err)
+	}
+	a := analyze(*root, fset, pkgs)
+
+	if jsonOutput {
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "  ")
+		if err := enc.Encode(a.filter(unusedOnly, crossFileOnly)); err != nil {
+			log.Fatal(err)
+		}
+		return
+	}
+
+	switch {
+	case unusedOnly:
+		a.printUnreferenced(os.Stdout, verbose)
+	case crossFileOnly:
+		a.printCrossFile(os.Stdout, verbose)
+	default:
+		a.print(os.Stdout, verbose)
+	}
+}
+
+// findModuleRoot returns the nearest directory with go.mod from the working directory.
+func findModuleRoot() (string, error) {
+	dir, err := os.Getwd()
+	if err != nil {
+		return "", err
+	}
+	for {
+		if _, err := os.Stat(filepath.Join(dir, "go.mod")); err == nil {
+			return dir, nil
+		}
+		parent := filepath.Dir(dir)
+		if parent == dir {
+			return "", fmt.Errorf("go.mod is not found")
+		}
+		dir = parent
+	}
+}
@@ -0,0 +1,134 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"strings"
+)
+
+// filter returns the part of the analysis selected by the modes, for the JSON output.
+func (a *analysis) filter(unusedOnly, crossFileOnly bool) *analysis {
+	switch {
+	case unusedOnly:
+		return &analysis{Unreferenced: a.Unreferenced, Unused: a.Unused}
+	case crossFileOnly:
+		return &analysis{CrossFile: a.CrossFile}
+	default:
+		return a
+	}
+}
+
+func (a *analysis) node(name string) *typeNode {
+	for _, node := range a.Types {
+		if node.Name == name {
+			return node
+		}
+	}
+	return nil
+}
+
+func (a *analysis) print(w io.Writer, verbose bool) {
+	fmt.Fprintln(w, "🔍 Module Dependency Analysis (all packages)")
+	fmt.Fprintln(w, strings.Repeat("=", 65))
+
+	fmt.Fprintln(w, "\n📊 Statistics:")
+	fmt.Fprintf(w, "   Total files analyzed: %d\n", len(a.Files))
+	structs, others := 0, 0
+	for _, s := range a.Files {
+		fmt.Fprintf(w, "   %s: %d structs, %d other types\n", s.File, s.Structs, s.OtherTypes)
+		structs += s.Structs
+		others += s.OtherTypes
+	}
+	fmt.Fprintf(w, "   Module total: %d structs, %d other types\n", structs, others)
+
+	var independent, dependent []*typeNode
+	for _, node := range a.Types {
+		if len(node.Dependencies) == 0 {
+			independent = append(independent, node)
+		} else {
+			dependent = append(dependent, node)
+		}
+	}
+
+	fmt.Fprintf(w, "\n✅ INDEPENDENT TYPES (no dependencies on module types) [%d]:\n", len(independent))
+	for _, node := range independent {
+		fmt.Fprintf(w, "   • %s (defined in %s)\n", node.Name, node.File)
+	}
+
+	fmt.Fprintf(w, "\n🔗 TYPES WITH DEPENDENCIES [%d]:\n", len(dependent))
+	for _, node := range dependent {
+		targets := make([]string, 0, len(node.Dependencies))
+		for _, e := range node.Dependencies {
+			target := e.To
+			if e.Embedded {
+				target += " (embedded)"
+			}
+			targets = append(targets, target)
+		}
+		fmt.Fprintf(w, "   • %s (%s) → %s\n", node.Name, node.File, strings.Join(targets, ", "))
+		if verbose {
+			printFields(w, node.Dependencies)
+		}
+	}
+
+	a.printCrossFile(w, false)
+	a.printUnreferenced(w, verbose)
+}
+
+func (a *analysis) printCrossFile(w io.Writer, verbose bool) {
+	fmt.Fprintf(w, "\n🔄 CROSS-FILE DEPENDENCIES [%d]:\n", len(a.CrossFile))
+	var from string
+	var targets []string
+	var edges []*edge
+	flush := func() {
+		if from == "" {
+			return
+		}
+		fmt.Fprintf(w, "   • %s → %s\n", from, strings.Join(targets, ", "))
+		if verbose {
+			printFields(w, edges)
+		}
+	}
+	for _, e := range a.CrossFile {
+		source := fmt.Sprintf("%s (%s)", e.From, e.FromFile)
+		if source != from {
+			flush()
+			from, targets, edges = source, nil, nil
+		}
+		targets = append(targets, fmt.Sprintf("%s (%s)", e.To, e.ToFile))
+		edges = append(edges, e)
+	}
+	flush()
+}
+
+func (a *analysis) printUnreferenced(w io.Writer, verbose bool) {
+	fmt.Fprintf(w, "\n🏝️  UNREFERENCED TYPES (not used by other types) [%d]:\n", len(a.Unreferenced))
+	for _, name := range a.Unreferenced {
+		node := a.node(name)
+		note := ""
+		if node.UsedInCode {
+			note = " [used in code]"
+		}
+		fmt.Fprintf(w, "   • %s (defined in %s)%s\n", name, node.File, note)
+	}
+
+	fmt.Fprintf(w, "\n⚠️  COMPLETELY UNUSED TYPES (not referenced by types nor used in code) [%d]:\n", len(a.Unused))
+	for _, name := range a.Unused {
+		fmt.Fprintf(w, "   • %s (defined in %s)\n", name, a.node(name).File)
+	}
+
+	if verbose {
+		fmt.Fprintln(w, "\n💡 Analysis method:")
+		fmt.Fprintln(w, "   - References: fields (including pointer, slice, array and map elements and embedded structs) of every type in the module")
+		fmt.Fprintln(w, "   - Used in code: any use outside type declarations and method receivers (e.g., functions, variables)")
+		fmt.Fprintln(w, "   - Top-level models are unreferenced by design; they are used by other cm-* subsystems")
+	}
+}
+
+func printFields(w io.Writer, edges []*edge) {
+	for _, e := range edges {
+		for _, field := range e.Fields {
+			fmt.Fprintf(w, "      - %s → %s\n", field, e.To)
+		}
+	}
+}
@@ -0,0 +1,313 @@
+// Command gen-jsonschema generates JSON Schema documents of the top-level models
+// from the Go structs and their `json`, `validate`, `example`, `default` and `enums` tags.
+//
+// Usage:
+//
+//	go run ./cmd/gen-jsonschema [-out data/schema]
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strconv"
+	"strings"
+
+	cloudmodel "github.com/cloud-barista/cm-model/infra/cloud-model"
+	onpremisemodel "github.com/cloud-barista/cm-model/infra/on-premise-model"
+	softwaremodel "github.com/cloud-barista/cm-model/sw"
+)
+
+const draft = "https://json-schema.org/draft/2020-12/schema"
+
+var models = []struct {
+	name  string
+	model any
+}{
+	{"onpremise-infra-model", onpremisemodel.OnpremiseInfraModel{}},
+	{"migrated-vm-infra-model", cloudmodel.MigratedVmInfraModel{}},
+	{"recommended-vm-infra-model", cloudmodel.RecommendedVmInfraModel{}},
+	{"source-software-model", softwaremodel.SourceSoftwareModel{}},
+	{"target-software-model", softwaremodel.TargetSoftwareModel{}},
+}
+
+func main() {
+	out := flag.String("out", filepath.Join("data", "schema"), "output directory of the schema documents")
+	flag.Parse()
+
+	paths, err := writeSchemas(*out)
+	if err != nil {
+		log.Fatal(err)
+	}
+	for _, path := range paths {
+		fmt.Println(path)
+	}
+}
+
+// writeSchemas writes the schema documents of the models into the directory and returns their paths.
+func writeSchemas(out string) ([]string, error) {
+	if err := os.MkdirAll(out, 0o755); err != nil {
+		return nil, err
+	}
+	var paths []string
+	for _, m := range models {
+		data, err := marshal(generate(reflect.TypeOf(m.model)))
+		if err != nil {
+			return nil, fmt.Errorf("failed to generate the schema of %s: %w", m.name, err)
+		}
+		path := filepath.Join(out, m.name+".schema.json")
+		if err := os.WriteFile(path, data, 0o644); err != nil {
+			return nil, err
+		}
+		paths = append(paths, path)
+	}
+	return paths, nil
+}
+
+// schema is a JSON Schema (a subset of the 2020-12 draft).
+type schema struct {
+	Schema               string             `json:"$schema,omitempty"`
+	Id                   string             `json:"$id,omitempty"`
+	Title                string             `json:"title,omitempty"`
+	Ref                  string             `json:"$ref,omitempty"`
+	Type                 string             `json:"type,omitempty"`
+	Format               string             `json:"format,omitempty"`
+	Properties           *orderedProperties `json:"properties,omitempty"`
+	Required             []string           `json:"required,omitempty"`
+	Items                *schema            `json:"items,omitempty"`
+	AdditionalProperties *schema            `json:"additionalProperties,omitempty"`
+	Enum                 []any              `json:"enum,omitempty"`
+	Default              any                `json:"default,omitempty"`
+	Examples             []any              `json:"examples,omitempty"`
+	Defs                 *orderedProperties `json:"$defs,omitempty"`
+}
+
+// orderedProperties keeps the order of the struct fields (or the definitions) in the output.
+type orderedProperties struct {
+	keys   []string
+	values map[string]*schema
+}
+
+func (p *orderedProperties) set(key string, s *schema) {
+	if p.values == nil {
+		p.values = make(map[string]*schema)
+	}
+	if _, ok := p.values[key]; !ok {
+		p.keys = append(p.keys, key)
+	}
+	p.values[key] = s
+}
+
+func (p *orderedProperties) MarshalJSON() ([]byte, error) {
+	var buf bytes.Buffer
+	buf.WriteByte('{')
+	for i, key := range p.keys {
+		if i > 0 {
+			buf.WriteByte(',')
+		}
+		k, _ := json.Marshal(key)
+		v, err := json.Marshal(p.values[key])
+		if err != nil {
+			return nil, err
+		}
+		buf.Write(k)
+		buf.WriteByte(':')
+		buf.Write(v)
+	}
+	buf.WriteByte('}')
+	return buf.Bytes(), nil
+}
+
+// generator collects the definitions of the named struct types reachable from a root type.
+type generator struct {
+	defs  orderedProperties
+	names map[reflect.Type]string
+	used  map[string]reflect.Type
+}
+
+func generate(root reflect.Type) *schema {
+	g := &generator{names: make(map[reflect.Type]string), used: make(map[string]reflect.Type)}
+	s := g.structSchema(root)
+	s.Schema = draft
+	s.Id = root.PkgPath() + "/" + root.Name()
+	s.Title = root.Name()
+	if len(g.defs.keys) > 0 {
+		s.Defs = &g.defs
+	}
+	return s
+}
+
+var errorType = reflect.TypeOf((*error)(nil)).Elem()
+
+func (g *generator) typeSchema(t reflect.Type) *schema {
+	if t == errorType {
+		return &schema{} // Any value, as an error is encoded differently by its concrete type
+	}
+	switch t.Kind() {
+	case reflect.Pointer:
+		return g.typeSchema(t.Elem())
+	case reflect.Bool:
+		return &schema{Type: "boolean"}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return &schema{Type: "integer"}
+	case reflect.Float32, reflect.Float64:
+		return &schema{Type: "number"}
+	case reflect.String:
+		return &schema{Type: "string"}
+	case reflect.Slice, reflect.Array:
+		if t.Elem().Kind() == reflect.Uint8 {
+			return &schema{Type: "string", Format: "byte"}
+		}
+		return &schema{Type: "array", Items: g.typeSchema(t.Elem())}
+	case reflect.Map:
+		return &schema{Type: "object", AdditionalProperties: g.typeSchema(t.Elem())}
+	case reflect.Struct:
+		if t.Name() == "" {
+			return g.structSchema(t)
+		}
+		return &schema{Ref: "#/$defs/" + g.define(t)}
+	default: // Interfaces
+		return &schema{}
+	}
+}
+
+// define adds the definition of a named struct type and returns its name.
+// The name is qualified with the package name if it collides with another type.
+func (g *generator) define(t reflect.Type) string {
+	if name, ok := g.names[t]; ok {
+		return name
+	}
+	name := t.Name()
+	if other, ok := g.used[name]; ok && other != t {
+		name = filepath.Base(t.PkgPath()) + "." + name
+	}
+	g.names[t] = name
+	g.used[name] = t
+	g.defs.set(name, nil) // Reserve the position for recursive types
+	g.defs.set(name, g.structSchema(t))
+	return name
+}
+
+func (g *generator) structSchema(t reflect.Type) *schema {
+	s := &schema{Type: "object", Properties: &orderedProperties{}}
+	g.addFields(s, t)
+	return s
+}
+
+func (g *generator) addFields(s *schema, t reflect.Type) {
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		name, skip := jsonField(f)
+		if skip {
+			continue
+		}
+		if f.Anonymous && f.Tag.Get("json") == "" {
+			ft := f.Type
+			if ft.Kind() == reflect.Pointer {
+				ft = ft.Elem()
+			}
+			if ft.Kind() == reflect.Struct {
+				g.addFields(s, ft) // Fields of an embedded struct are promoted
+				continue
+			}
+		}
+		if !f.IsExported() {
+			continue
+		}
+
+		prop := g.typeSchema(f.Type)
+		if prop.Ref == "" { // Annotations of a field referencing a struct are not applicable
+			annotate(prop, f)
+		}
+		s.Properties.set(name, prop)
+
+		if contains(strings.Split(f.Tag.Get("validate"), ","), "required") {
+			s.Required = append(s.Required, name)
+		}
+	}
+}
+
+// annotate translates the `enums`, `default` and `example` tags of a field.
+func annotate(s *schema, f reflect.StructField) {
+	if enums := f.Tag.Get("enums"); enums != "" {
+		for _, e := range strings.Split(enums, ",") {
+			if v, ok := tagValue(s, strings.TrimSpace(e)); ok {
+				s.Enum = append(s.Enum, v)
+			}
+		}
+	}
+	if def, ok := f.Tag.Lookup("default"); ok && def != "" {
+		if v, ok := tagValue(s, def); ok {
+			s.Default = v
+		}
+	}
+	if example := f.Tag.Get("example"); example != "" {
+		if v, ok := tagValue(s, example); ok {
+			s.Examples = []any{v}
+		}
+	}
+}
+
+// tagValue converts a tag value into a value of the schema type, reporting false if it cannot.
+func tagValue(s *schema, value string) (any, bool) {
+	switch s.Type {
+	case "string":
+		return value, true
+	case "integer":
+		n, err := strconv.ParseInt(value, 10, 64)
+		return n, err == nil
+	case "number":
+		n, err := strconv.ParseFloat(value, 64)
+		return n, err == nil
+	case "boolean":
+		b, err := strconv.ParseBool(value)
+		return b, err == nil
+	default: // Arrays, objects and any values are given in JSON
+		var v any
+		if err := json.Unmarshal([]byte(value), &v); err != nil {
+			return nil, false
+		}
+		return v, true
+	}
+}
+
+// jsonField returns the JSON name of a field and whether it is skipped.
+func jsonField(f reflect.StructField) (string, bool) {
+	tag := f.Tag.Get("json")
+	if tag == "-" {
+		return "", true
+	}
+	name, _, _ := strings.Cut(tag, ",")
+	if name == "" {
+		name = f.Name
+	}
+	return name, false
+}
+
+func contains(list []string, s string) bool {
+	for _, item := range list {
+		if strings.TrimSpace(item) == s {
+			return true
+		}
+	}
+	return false
+}
+
+func marshal(s *schema) ([]byte, error) {
+	data, err := json.Marshal(s)
+	if err != nil {
+		return nil, err
+	}
+	var buf bytes.Buffer
+	if err := json.Indent(&buf, data, "", "  "); err != nil {
+		return nil, err
+	}
+	buf.WriteByte('\n')
+	return buf.Bytes(), nil
+}
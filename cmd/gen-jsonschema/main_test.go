@@ -0,0 +1,47 @@
+package main
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// TestSchemasUpToDate regenerates the schema documents and compares them with the committed ones,
+// so that a model change without `go run ./cmd/gen-jsonschema` fails.
+func TestSchemasUpToDate(t *testing.T) {
+	committed := filepath.Join("..", "..", "data", "schema")
+	paths, err := writeSchemas(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	generated := make(map[string]bool)
+	for _, path := range paths {
+		name := filepath.Base(path)
+		generated[name] = true
+		got, err := os.ReadFile(path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		want, err := os.ReadFile(filepath.Join(committed, name))
+		if err != nil {
+			t.Errorf("%s: %v; run `go run ./cmd/gen-jsonschema`", name, err)
+			continue
+		}
+		if !bytes.Equal(got, want) {
+			t.Errorf("%s is outdated; run `go run ./cmd/gen-jsonschema`", name)
+		}
+	}
+
+	// A schema of a removed model is not left behind
+	files, err := filepath.Glob(filepath.Join(committed, "*.schema.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, file := range files {
+		if !generated[filepath.Base(file)] {
+			t.Errorf("%s is not generated from any model", file)
+		}
+	}
+}
@@ -0,0 +1,105 @@
+// Command sync-tb synchronizes the CB-Tumblebug structs in copied-tb-model.go
+// with a local checkout of CB-Tumblebug, replacing the manual copy process.
+//
+// It re-extracts every type and constant declaration in copied-tb-model.go (and new types they depend on,
+// with their constants) from the CB-Tumblebug sources by AST, including the types of grouped declarations,
+// rewrites the file while preserving the cm-model comments, updates the version header
+// and the `// * Path:` comments, and prints a breaking-change report.
+//
+// Usage:
+//
+//	git -C ../cb-tumblebug checkout v0.12.6
+//	go run ./cmd/sync-tb -tb ../cb-tumblebug [-version v0.12.6] [-note "..."] [-paths] [-dry-run] [-fail-on-breaking]
+package main
+
+import (
+	"flag"
+	"fmt"
+	"go/format"
+	"log"
+	"os"
+	"os/exec"
+	"strings"
+	"time"
+)
+
+func main() {
+	tbDir := flag.String("tb", "", "path to a local checkout of CB-Tumblebug at the target version (required)")
+	file := flag.String("file", "infra/cloud-model/copied-tb-model.go", "path to copied-tb-model.go")
+	version := flag.String("version", "", "CB-Tumblebug version of the checkout (default: git describe --tags)")
+	note := flag.String("note", "", "summary of the changes written in the Synchronized header (default: generated from the report)")
+	date := flag.String("date", time.Now().Format("2006-01-02"), "date written in the Synchronized header")
+	paths := flag.Bool("paths", false, "add `// * Path:` comments to every type (existing ones are always updated)")
+	dryRun := flag.Bool("dry-run", false, "print the report without rewriting the file")
+	failOnBreaking := flag.Bool("fail-on-breaking", false, "exit with status 2 if there is a breaking change")
+	flag.Parse()
+
+	if *tbDir == "" {
+		flag.Usage()
+		os.Exit(1)
+	}
+
+	local, err := parseLocalFile(*file)
+	if err != nil {
+		log.Fatal(err)
+	}
+	tb, err := loadTbTypes(*tbDir)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	if *version == "" {
+		*version = git(*tbDir, "describe", "--tags")
+		if *version == "" {
+			log.Fatal("cannot detect the CB-Tumblebug version; use -version")
+		}
+	}
+	commit := git(*tbDir, "rev-parse", "HEAD")
+
+	result := synchronize(local, tb, *paths)
+	result.report.from = local.version
+	result.report.to = *version
+	result.report.print(os.Stdout)
+
+	if !*dryRun {
+		summary := *note
+		if summary == "" {
+			summary = result.report.summary()
+		}
+		src := local.render(result, header(*version, commit, *date, summary))
+		formatted, err := format.Source(src)
+		if err != nil {
+			log.Fatalf("failed to format the synchronized file: %v", err)
+		}
+		if err := os.WriteFile(*file, formatted, 0o644); err != nil {
+			log.Fatal(err)
+		}
+		fmt.Printf("\n%s is synchronized with CB-Tumblebug %s\n", *file, *version)
+	}
+
+	if *failOnBreaking && result.report.hasBreaking() {
+		os.Exit(2)
+	}
+}
+
+// header returns the version header lines of copied-tb-model.go.
+func header(version, commit, date, summary string) [2]string {
+	versionLine := "// * Version: CB-Tumblebug " + version
+	if commit != "" {
+		versionLine += " (commit: " + commit + ")"
+	}
+	synchronizedLine := "// * Synchronized: " + date
+	if summary != "" {
+		synchronizedLine += " (" + summary + ")"
+	}
+	return [2]string{versionLine, synchronizedLine}
+}
+
+// git runs a git command in a directory and returns its trimmed output, or "" on failure.
+func git(dir string, args ...string) string {
+	out, err := exec.Command("git", append([]string{"-C", dir}, args...)...).Output()
+	if err != nil {
+		return ""
+	}
+	return strings.TrimSpace(string(out))
+}
@@ -0,0 +1,80 @@
+package main
+
+import (
+	"fmt"
+	"io"
+)
+
+// change is a difference of a synchronized type from copied-tb-model.go.
+type change struct {
+	typeName string
+	field    string
+	breaking bool
+	message  string
+}
+
+// report is the breaking-change report of a synchronization.
+type report struct {
+	from, to string
+	changes  []change
+}
+
+func (r *report) add(typeName, field string, breaking bool, message string) {
+	r.changes = append(r.changes, change{typeName: typeName, field: field, breaking: breaking, message: message})
+}
+
+func (r *report) hasBreaking() bool {
+	for _, c := range r.changes {
+		if c.breaking {
+			return true
+		}
+	}
+	return false
+}
+
+// summary returns a one-line summary for the Synchronized header.
+func (r *report) summary() string {
+	breaking, other := r.count()
+	if breaking+other == 0 {
+		return "no struct change"
+	}
+	return fmt.Sprintf("%d breaking change(s), %d other change(s); see the sync-tb report", breaking, other)
+}
+
+func (r *report) count() (int, int) {
+	breaking := 0
+	for _, c := range r.changes {
+		if c.breaking {
+			breaking++
+		}
+	}
+	return breaking, len(r.changes) - breaking
+}
+
+func (r *report) print(w io.Writer) {
+	fmt.Fprintf(w, "CB-Tumblebug sync report: %s -> %s\n", r.from, r.to)
+	breaking, other := r.count()
+	if breaking+other == 0 {
+		fmt.Fprintln(w, "\nNo change")
+		return
+	}
+	r.printSection(w, "Breaking changes", breaking, true)
+	r.printSection(w, "Other changes", other, false)
+}
+
+func (r *report) printSection(w io.Writer, title string, n int, breaking bool) {
+	if n == 0 {
+		return
+	}
+	fmt.Fprintf(w, "\n%s (%d):\n", title, n)
+	for _, c := range r.changes {
+		if c.breaking != breaking {
+			continue
+		}
+		name := c.typeName
+		if c.field != "" {
+			name += "." + c.field
+		}
+		fmt.Fprintf(w, "  - %s: %s\n", name, c.message)
+	}
+}
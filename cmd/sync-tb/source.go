@@ -0,0 +1,324 @@
+package main
+
+import (
+	"fmt"
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// localFile is the parsed copied-tb-model.go.
+type localFile struct {
+	path    string
+	src     []byte
+	fset    *token.FileSet
+	file    *ast.File
+	version string // CB-Tumblebug version in the header
+	types   []*localType
+	consts  []*localConst
+}
+
+// localType is a type declaration of copied-tb-model.go.
+type localType struct {
+	name       string
+	decl       *ast.GenDecl
+	spec       *ast.TypeSpec
+	grouped    bool // Whether the type is declared in a grouped declaration, type (...)
+	start, end int  // Byte offsets of the declaration (the spec if grouped) including its doc comment
+}
+
+// doc returns the doc comment of the type.
+func (lt *localType) doc() *ast.CommentGroup {
+	if lt.grouped {
+		return lt.spec.Doc
+	}
+	return lt.decl.Doc
+}
+
+// localConst is a constant declaration of copied-tb-model.go (e.g., the values of OSArchitecture).
+type localConst struct {
+	names      []string
+	decl       *ast.GenDecl
+	start, end int // Byte offsets of the declaration including its doc comment
+}
+
+var versionPattern = regexp.MustCompile(`// \* Version: CB-Tumblebug (\S+)`)
+
+func parseLocalFile(path string) (*localFile, error) {
+	src, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	fset := token.NewFileSet()
+	file, err := parser.ParseFile(fset, path, src, parser.ParseComments)
+	if err != nil {
+		return nil, err
+	}
+
+	local := &localFile{path: path, src: src, fset: fset, file: file}
+	if m := versionPattern.FindSubmatch(src); m != nil {
+		local.version = string(m[1])
+	}
+	for _, decl := range file.Decls {
+		gen, ok := decl.(*ast.GenDecl)
+		if !ok {
+			continue
+		}
+		start := gen.Pos()
+		if gen.Doc != nil {
+			start = gen.Doc.Pos()
+		}
+
+		switch gen.Tok {
+		case token.TYPE:
+			if len(gen.Specs) == 1 && !gen.Lparen.IsValid() {
+				local.types = append(local.types, &localType{
+					name:  gen.Specs[0].(*ast.TypeSpec).Name.Name,
+					decl:  gen,
+					spec:  gen.Specs[0].(*ast.TypeSpec),
+					start: fset.Position(start).Offset,
+					end:   fset.Position(gen.End()).Offset,
+				})
+				continue
+			}
+			// Each type of a grouped declaration is synchronized in place
+			for _, spec := range gen.Specs {
+				ts := spec.(*ast.TypeSpec)
+				specStart := ts.Pos()
+				if ts.Doc != nil {
+					specStart = ts.Doc.Pos()
+				}
+				local.types = append(local.types, &localType{
+					name:    ts.Name.Name,
+					decl:    gen,
+					spec:    ts,
+					grouped: true,
+					start:   fset.Position(specStart).Offset,
+					end:     fset.Position(ts.End()).Offset,
+				})
+			}
+		case token.CONST:
+			local.consts = append(local.consts, &localConst{
+				names: constNames(gen),
+				decl:  gen,
+				start: fset.Position(start).Offset,
+				end:   fset.Position(gen.End()).Offset,
+			})
+		}
+	}
+	return local, nil
+}
+
+// constNames returns the names of the constants of a declaration.
+func constNames(decl *ast.GenDecl) []string {
+	var names []string
+	for _, spec := range decl.Specs {
+		for _, name := range spec.(*ast.ValueSpec).Names {
+			if name.Name != "_" {
+				names = append(names, name.Name)
+			}
+		}
+	}
+	return names
+}
+
+// constType returns the name of the type of a constant declaration (e.g., OSArchitecture),
+// given by its first typed constant, or "" if the constants are untyped.
+func constType(decl *ast.GenDecl, internal map[string]bool) string {
+	for _, spec := range decl.Specs {
+		switch t := spec.(*ast.ValueSpec).Type.(type) {
+		case *ast.Ident:
+			return t.Name
+		case *ast.SelectorExpr:
+			if pkg, ok := t.X.(*ast.Ident); ok && internal[pkg.Name] {
+				return t.Sel.Name
+			}
+		}
+	}
+	return ""
+}
+
+// tbSource is a parsed Go file of CB-Tumblebug.
+type tbSource struct {
+	path     string // Slash-separated path relative to the checkout (e.g., src/core/model/mci.go)
+	src      []byte
+	fset     *token.FileSet
+	file     *ast.File
+	internal map[string]bool // Names of the CB-Tumblebug packages imported by the file
+}
+
+// tbType is a type declaration found in the CB-Tumblebug sources.
+type tbType struct {
+	*tbSource
+	name string
+	decl *ast.GenDecl
+	spec *ast.TypeSpec
+}
+
+// tbConst is a constant declaration found in the CB-Tumblebug sources.
+type tbConst struct {
+	*tbSource
+	names    []string
+	typeName string // Type of the constants (see constType)
+	decl     *ast.GenDecl
+}
+
+// lines returns the line range of the declaration (without the doc comment).
+func (c *tbConst) lines() (int, int) {
+	return c.fset.Position(c.decl.Pos()).Line, c.fset.Position(c.decl.End()).Line
+}
+
+// doc returns the doc comment of the type.
+func (t *tbType) doc() *ast.CommentGroup {
+	if t.spec.Doc != nil {
+		return t.spec.Doc
+	}
+	if len(t.decl.Specs) == 1 {
+		return t.decl.Doc
+	}
+	return nil
+}
+
+// lines returns the line range of the declaration (without the doc comment).
+func (t *tbType) lines() (int, int) {
+	start := t.spec.Pos()
+	if len(t.decl.Specs) == 1 {
+		start = t.decl.Pos()
+	}
+	return t.fset.Position(start).Line, t.fset.Position(t.spec.End()).Line
+}
+
+// tbIndex indexes the CB-Tumblebug types and constant declarations.
+type tbIndex struct {
+	types       map[string][]*tbType  // By type name
+	consts      map[string][]*tbConst // By constant name
+	typedConsts map[string][]*tbConst // By type name of the constants
+}
+
+// lookup returns the CB-Tumblebug type of a name, preferring the model package if there are several.
+// It also tries the former Tb-prefixed name (e.g., TbMciReq for MciReq) and reports which name it found.
+func (idx tbIndex) lookup(name string) (*tbType, bool) {
+	for _, candidate := range []string{name, "Tb" + name, strings.TrimPrefix(name, "Tb")} {
+		if t, ok := preferModel(idx.types[candidate]); ok {
+			return t, true
+		}
+	}
+	return nil, false
+}
+
+// lookupConst returns the CB-Tumblebug declaration of a constant, preferring the model package if there are several.
+func (idx tbIndex) lookupConst(name string) (*tbConst, bool) {
+	return preferModel(idx.consts[name])
+}
+
+// preferModel returns the declaration in the model package, or else the first one.
+func preferModel[T interface{ modelPath() string }](decls []T) (T, bool) {
+	var zero T
+	if len(decls) == 0 {
+		return zero, false
+	}
+	for _, d := range decls {
+		if strings.Contains(d.modelPath(), "/model/") {
+			return d, true
+		}
+	}
+	return decls[0], true
+}
+
+func (s *tbSource) modelPath() string { return s.path }
+
+var skippedDirs = map[string]bool{".git": true, "vendor": true, "testdata": true, "node_modules": true}
+
+// loadTbTypes parses every Go file of a CB-Tumblebug checkout and indexes its type and constant declarations.
+func loadTbTypes(root string) (tbIndex, error) {
+	idx := tbIndex{
+		types:       make(map[string][]*tbType),
+		consts:      make(map[string][]*tbConst),
+		typedConsts: make(map[string][]*tbConst),
+	}
+	err := filepath.WalkDir(root, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if d.IsDir() {
+			if skippedDirs[d.Name()] {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if !strings.HasSuffix(path, ".go") || strings.HasSuffix(path, "_test.go") {
+			return nil
+		}
+
+		src, err := os.ReadFile(path)
+		if err != nil {
+			return err
+		}
+		fset := token.NewFileSet()
+		file, err := parser.ParseFile(fset, path, src, parser.ParseComments)
+		if err != nil {
+			return nil // Files which do not parse (e.g., templates) have no model
+		}
+		rel, _ := filepath.Rel(root, path)
+
+		internal := make(map[string]bool)
+		for _, imp := range file.Imports {
+			importPath, _ := strconv.Unquote(imp.Path.Value)
+			if !strings.Contains(importPath, "cb-tumblebug") {
+				continue
+			}
+			name := importPath[strings.LastIndex(importPath, "/")+1:]
+			if imp.Name != nil {
+				name = imp.Name.Name
+			}
+			internal[name] = true
+		}
+
+		source := &tbSource{path: filepath.ToSlash(rel), src: src, fset: fset, file: file, internal: internal}
+		for _, decl := range file.Decls {
+			gen, ok := decl.(*ast.GenDecl)
+			if !ok {
+				continue
+			}
+			switch gen.Tok {
+			case token.TYPE:
+				for _, spec := range gen.Specs {
+					ts := spec.(*ast.TypeSpec)
+					idx.types[ts.Name.Name] = append(idx.types[ts.Name.Name], &tbType{tbSource: source, name: ts.Name.Name, decl: gen, spec: ts})
+				}
+			case token.CONST:
+				c := &tbConst{tbSource: source, names: constNames(gen), typeName: constType(gen, internal), decl: gen}
+				for _, name := range c.names {
+					idx.consts[name] = append(idx.consts[name], c)
+				}
+				if c.typeName != "" {
+					idx.typedConsts[c.typeName] = append(idx.typedConsts[c.typeName], c)
+				}
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return tbIndex{}, err
+	}
+	if len(idx.types) == 0 {
+		return tbIndex{}, fmt.Errorf("no Go type found in %s", root)
+	}
+	for _, types := range idx.types {
+		sort.Slice(types, func(i, j int) bool { return types[i].path < types[j].path })
+	}
+	for _, consts := range idx.consts {
+		sort.Slice(consts, func(i, j int) bool { return consts[i].path < consts[j].path })
+	}
+	for _, consts := range idx.typedConsts {
+		sort.Slice(consts, func(i, j int) bool { return consts[i].path < consts[j].path })
+	}
+	return idx, nil
+}
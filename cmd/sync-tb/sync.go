@@ -0,0 +1,588 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"go/ast"
+	"go/token"
+	"go/types"
+	"reflect"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+const pathPrefix = "// * Path:"
+
+// syncResult is the synchronized declarations of copied-tb-model.go.
+type syncResult struct {
+	texts      map[string]string      // Local type name -> synchronized declaration
+	constTexts map[*localConst]string // Local constant declaration -> synchronized declaration
+	added      []string               // Declarations of new types the synchronized types depend on, and of their constants
+	report     *report
+}
+
+// synchronize re-extracts the types and constants of the local file from CB-Tumblebug, following new dependencies.
+// The constants of the synchronized types missing in the local file (e.g., a new enum type) are added.
+func synchronize(local *localFile, idx tbIndex, paths bool) syncResult {
+	result := syncResult{texts: make(map[string]string), constTexts: make(map[*localConst]string), report: &report{}}
+	synced := make(map[*tbConst]bool)
+	for _, lc := range local.consts {
+		decls := constDecls(idx, lc)
+		if len(decls) == 0 {
+			result.report.add(constLabel(lc.decl, nil), "", false, "constants not found in CB-Tumblebug (kept as is)")
+			continue
+		}
+		result.constTexts[lc] = renderConsts(lc, decls, paths)
+		compareConsts(result.report, local, lc, decls)
+		for _, c := range decls {
+			synced[c] = true
+		}
+	}
+	var syncedTypes []string // CB-Tumblebug names of the synchronized types
+
+	known := make(map[string]bool)
+	var queue []string
+	for _, lt := range local.types {
+		known[lt.name] = true
+		queue = append(queue, lt.name)
+	}
+	localTypes := make(map[string]*localType)
+	for _, lt := range local.types {
+		localTypes[lt.name] = lt
+	}
+
+	for i := 0; i < len(queue); i++ {
+		name := queue[i]
+		lt := localTypes[name]
+		t, ok := idx.lookup(name)
+		if !ok {
+			result.report.add(name, "", false, "not found in CB-Tumblebug (kept as is)")
+			continue
+		}
+		if t.name != name {
+			result.report.add(name, "", false, fmt.Sprintf("named %s in CB-Tumblebug (cm-model name kept)", t.name))
+		}
+
+		syncedTypes = append(syncedTypes, t.name)
+		text, deps := renderType(local, lt, t, name, paths)
+		if lt != nil {
+			result.texts[name] = text
+			compareType(result.report, local, lt, t)
+		} else {
+			result.added = append(result.added, text)
+		}
+
+		for _, dep := range deps {
+			if known[dep.name] {
+				continue
+			}
+			known[dep.name] = true
+			if _, ok := idx.lookup(dep.name); !ok {
+				result.report.add(name, dep.field, true, fmt.Sprintf("depends on %s which is not found in CB-Tumblebug", dep.name))
+				continue
+			}
+			queue = append(queue, dep.name)
+			result.report.add(dep.name, "", false, "added as a dependency of "+name)
+		}
+	}
+
+	for _, typeName := range syncedTypes {
+		for _, c := range idx.typedConsts[typeName] {
+			if synced[c] {
+				continue
+			}
+			synced[c] = true
+			result.added = append(result.added, renderConsts(nil, []*tbConst{c}, paths))
+			result.report.add(typeName, "", false, "constants added: "+strings.Join(c.names, ", "))
+		}
+	}
+	return result
+}
+
+// constDecls returns the CB-Tumblebug declarations of the constants of a local declaration, in order.
+func constDecls(idx tbIndex, lc *localConst) []*tbConst {
+	var decls []*tbConst
+	seen := make(map[*tbConst]bool)
+	for _, name := range lc.names {
+		c, ok := idx.lookupConst(name)
+		if ok && !seen[c] {
+			seen[c] = true
+			decls = append(decls, c)
+		}
+	}
+	return decls
+}
+
+// constLabel returns the type of a constant declaration for the report, or its first constant if untyped.
+func constLabel(decl *ast.GenDecl, internal map[string]bool) string {
+	if name := constType(decl, internal); name != "" {
+		return name
+	}
+	if names := constNames(decl); len(names) > 0 {
+		return names[0]
+	}
+	return "const"
+}
+
+// renderConsts renders the CB-Tumblebug declarations of constants with the doc comment of the local declaration merged.
+// Comments inside the declarations are the ones of CB-Tumblebug.
+func renderConsts(lc *localConst, decls []*tbConst, paths bool) string {
+	var buf strings.Builder
+	for i, c := range decls {
+		if i > 0 {
+			buf.WriteString("\n\n")
+		}
+		var localDoc []string
+		if lc != nil && i == 0 {
+			localDoc = commentLines(lc.decl.Doc)
+		}
+		hasPath := false
+		var doc []string
+		for _, line := range localDoc {
+			if strings.HasPrefix(line, pathPrefix) {
+				hasPath = true
+				continue
+			}
+			doc = append(doc, line)
+		}
+		doc = mergeLines(doc, commentLines(c.decl.Doc))
+		if paths || hasPath {
+			start, end := c.lines()
+			doc = append(doc, fmt.Sprintf("%s %s (L%d-L%d)", pathPrefix, c.path, start, end))
+		}
+		for _, line := range doc {
+			buf.WriteString(line + "\n")
+		}
+		text, _ := rewriteType(c.tbSource, c.decl, "")
+		buf.WriteString(text)
+	}
+	return buf.String()
+}
+
+// compareConsts reports the differences of the local constants from the CB-Tumblebug constants.
+// Values are compared when both are explicit (i.e., not implied by iota).
+func compareConsts(r *report, local *localFile, lc *localConst, decls []*tbConst) {
+	label := constLabel(lc.decl, nil)
+	localValues := constValues(local.src, local.fset, lc.decl, nil)
+	tbValues := make(map[string]string)
+	var tbNames []string
+	for _, c := range decls {
+		for name, value := range constValues(c.src, c.fset, c.decl, c.tbSource) {
+			tbValues[name] = value
+		}
+		tbNames = append(tbNames, c.names...)
+	}
+
+	for _, name := range lc.names {
+		after, ok := tbValues[name]
+		switch {
+		case !ok:
+			r.add(label, name, true, "removed")
+		case localValues[name] != "" && after != "" && localValues[name] != after:
+			r.add(label, name, true, fmt.Sprintf("value changed: %s -> %s", localValues[name], after))
+		}
+	}
+	for _, name := range tbNames {
+		if _, ok := localValues[name]; !ok {
+			r.add(label, name, false, "added")
+		}
+	}
+}
+
+// constValues returns the explicit values of the constants of a declaration ("" if implied),
+// with the qualifiers of CB-Tumblebug packages removed if source is given.
+func constValues(src []byte, fset *token.FileSet, decl *ast.GenDecl, source *tbSource) map[string]string {
+	values := make(map[string]string)
+	for _, spec := range decl.Specs {
+		vs := spec.(*ast.ValueSpec)
+		for i, name := range vs.Names {
+			value := ""
+			if i < len(vs.Values) {
+				if source != nil {
+					value, _ = rewriteType(source, vs.Values[i], "")
+				} else {
+					value = string(src[fset.Position(vs.Values[i].Pos()).Offset:fset.Position(vs.Values[i].End()).Offset])
+				}
+				value = normalize(value)
+			}
+			values[name.Name] = value
+		}
+	}
+	return values
+}
+
+// dependency is a type referenced by a field.
+type dependency struct {
+	name  string
+	field string
+}
+
+// renderType renders the declaration of a CB-Tumblebug type with the cm-model comments of the local type merged.
+// Doc comment lines of the local type and fields missing in CB-Tumblebug are kept.
+func renderType(local *localFile, lt *localType, t *tbType, name string, paths bool) (string, []dependency) {
+	var buf strings.Builder
+
+	var localDoc []string
+	if lt != nil {
+		localDoc = commentLines(lt.doc())
+	}
+	hasPath := false
+	var doc []string
+	for _, line := range localDoc {
+		if strings.HasPrefix(line, pathPrefix) {
+			hasPath = true
+			continue
+		}
+		doc = append(doc, line)
+	}
+	doc = mergeLines(doc, commentLines(t.doc()))
+	if paths || hasPath {
+		start, end := t.lines()
+		doc = append(doc, fmt.Sprintf("%s %s (L%d-L%d)", pathPrefix, t.path, start, end))
+	}
+	for _, line := range doc {
+		buf.WriteString(line + "\n")
+	}
+
+	keyword := "type "
+	if lt != nil && lt.grouped {
+		keyword = "" // In place of a spec of the grouped declaration
+	}
+
+	st, ok := t.spec.Type.(*ast.StructType)
+	if !ok {
+		typeText, deps := rewriteType(t.tbSource, t.spec.Type, "")
+		assign := ""
+		if t.spec.Assign.IsValid() {
+			assign = "= "
+		}
+		fmt.Fprintf(&buf, "%s%s %s%s", keyword, name, assign, typeText)
+		return buf.String(), deps
+	}
+
+	var localStruct *ast.StructType
+	if lt != nil {
+		localStruct, _ = lt.spec.Type.(*ast.StructType)
+	}
+	localFields := make(map[string]*ast.Field)
+	if localStruct != nil {
+		for _, f := range localStruct.Fields.List {
+			localFields[fieldKey(local.src, local.fset, f)] = f
+		}
+	}
+
+	fmt.Fprintf(&buf, "%s%s struct {", keyword, name)
+	opening, trailing := freeComments(t.fset, st, t.file)
+	if localStruct != nil {
+		lo, ltr := freeComments(local.fset, localStruct, local.file)
+		opening = mergeLines(lo, opening)
+		trailing = mergeLines(trailing, ltr)
+	}
+	if len(opening) > 0 {
+		buf.WriteString(" " + strings.Join(opening, " "))
+	}
+	buf.WriteString("\n")
+
+	var deps []dependency
+	prevEnd := t.fset.Position(st.Fields.Opening).Line
+	for _, f := range st.Fields.List {
+		key := fieldKey(t.src, t.fset, f)
+		lf := localFields[key]
+
+		start := f.Pos()
+		if f.Doc != nil {
+			start = f.Doc.Pos()
+		}
+		if t.fset.Position(start).Line > prevEnd+1 { // Keep the blank lines between fields
+			buf.WriteString("\n")
+		}
+		prevEnd = t.fset.Position(f.End()).Line
+
+		fieldDoc := commentLines(f.Doc)
+		if lf != nil {
+			fieldDoc = mergeLines(fieldDoc, commentLines(lf.Doc))
+		}
+		for _, line := range fieldDoc {
+			buf.WriteString("\t" + line + "\n")
+		}
+
+		typeText, fieldDeps := rewriteType(t.tbSource, f.Type, key)
+		deps = append(deps, fieldDeps...)
+		buf.WriteString("\t")
+		if len(f.Names) > 0 {
+			buf.WriteString(key + " ")
+		}
+		buf.WriteString(typeText)
+		if f.Tag != nil {
+			buf.WriteString(" " + f.Tag.Value)
+		}
+		comment := commentLines(f.Comment)
+		if len(comment) == 0 && lf != nil {
+			comment = commentLines(lf.Comment)
+		}
+		if len(comment) > 0 {
+			buf.WriteString(" " + strings.Join(comment, " "))
+		}
+		buf.WriteString("\n")
+	}
+	for _, line := range trailing {
+		buf.WriteString("\t" + line + "\n")
+	}
+	buf.WriteString("}")
+	return buf.String(), deps
+}
+
+// freeComments returns the comments in a struct which are not attached to any field,
+// split into the one on the line of the opening brace and the others.
+func freeComments(fset *token.FileSet, st *ast.StructType, file *ast.File) ([]string, []string) {
+	attached := make(map[*ast.CommentGroup]bool)
+	for _, f := range st.Fields.List {
+		attached[f.Doc] = true
+		attached[f.Comment] = true
+	}
+	var opening, others []string
+	openLine := fset.Position(st.Fields.Opening).Line
+	for _, group := range file.Comments {
+		if group.Pos() < st.Fields.Opening || group.End() > st.Fields.Closing || attached[group] {
+			continue
+		}
+		if fset.Position(group.Pos()).Line == openLine {
+			opening = append(opening, commentLines(group)...)
+		} else {
+			others = append(others, commentLines(group)...)
+		}
+	}
+	return opening, others
+}
+
+// rewriteType returns the source of a type expression (or a declaration), with the qualifiers of CB-Tumblebug
+// packages removed (e.g., model.KeyValue -> KeyValue), and the types it references.
+func rewriteType(t *tbSource, expr ast.Node, field string) (string, []dependency) {
+	type replacement struct{ start, end int }
+	var replacements []replacement
+	var deps []dependency
+
+	var inspect func(n ast.Node) bool
+	inspect = func(n ast.Node) bool {
+		switch n := n.(type) {
+		case *ast.SelectorExpr:
+			pkg, ok := n.X.(*ast.Ident)
+			if ok && t.internal[pkg.Name] {
+				replacements = append(replacements, replacement{
+					start: t.fset.Position(n.Pos()).Offset,
+					end:   t.fset.Position(n.Sel.Pos()).Offset,
+				})
+				deps = append(deps, dependency{name: n.Sel.Name, field: field})
+			}
+			return false // Types of other packages (e.g., time.Time) are kept as they are
+		case *ast.Ident:
+			if types.Universe.Lookup(n.Name) == nil {
+				deps = append(deps, dependency{name: n.Name, field: field})
+			}
+		case *ast.Field: // Fields of an inline struct: only their types are references
+			ast.Inspect(n.Type, inspect)
+			return false
+		}
+		return true
+	}
+	ast.Inspect(expr, inspect)
+
+	start := t.fset.Position(expr.Pos()).Offset
+	end := t.fset.Position(expr.End()).Offset
+	sort.Slice(replacements, func(i, j int) bool { return replacements[i].start < replacements[j].start })
+	var buf bytes.Buffer
+	pos := start
+	for _, r := range replacements {
+		buf.Write(t.src[pos:r.start])
+		pos = r.end
+	}
+	buf.Write(t.src[pos:end])
+	return buf.String(), deps
+}
+
+// fieldKey returns the names of a field, or the type of an embedded field.
+func fieldKey(src []byte, fset *token.FileSet, f *ast.Field) string {
+	if len(f.Names) == 0 {
+		return string(src[fset.Position(f.Type.Pos()).Offset:fset.Position(f.Type.End()).Offset])
+	}
+	names := make([]string, 0, len(f.Names))
+	for _, name := range f.Names {
+		names = append(names, name.Name)
+	}
+	return strings.Join(names, ", ")
+}
+
+// commentLines returns the lines of a comment group as written (e.g., "// text").
+func commentLines(group *ast.CommentGroup) []string {
+	if group == nil {
+		return nil
+	}
+	var lines []string
+	for _, c := range group.List {
+		lines = append(lines, strings.Split(c.Text, "\n")...)
+	}
+	return lines
+}
+
+// mergeLines returns the lines of a followed by the lines of b not in a.
+func mergeLines(a, b []string) []string {
+	seen := make(map[string]bool)
+	merged := make([]string, 0, len(a)+len(b))
+	for _, line := range a {
+		seen[strings.TrimSpace(line)] = true
+		merged = append(merged, line)
+	}
+	for _, line := range b {
+		if !seen[strings.TrimSpace(line)] {
+			merged = append(merged, line)
+		}
+	}
+	return merged
+}
+
+// compareType reports the differences of the local type from the CB-Tumblebug type.
+func compareType(r *report, local *localFile, lt *localType, t *tbType) {
+	st, tbIsStruct := t.spec.Type.(*ast.StructType)
+	localStruct, localIsStruct := lt.spec.Type.(*ast.StructType)
+	if !tbIsStruct || !localIsStruct {
+		before := normalize(string(local.src[local.fset.Position(lt.spec.Type.Pos()).Offset:local.fset.Position(lt.spec.Type.End()).Offset]))
+		after, _ := rewriteType(t.tbSource, t.spec.Type, "")
+		if after = normalize(after); before != after {
+			r.add(lt.name, "", true, fmt.Sprintf("type changed: %s -> %s", before, after))
+		}
+		return
+	}
+
+	tbFields := make(map[string]*ast.Field)
+	for _, f := range st.Fields.List {
+		tbFields[fieldKey(t.src, t.fset, f)] = f
+	}
+	localFields := make(map[string]bool)
+	for _, lf := range localStruct.Fields.List {
+		key := fieldKey(local.src, local.fset, lf)
+		localFields[key] = true
+		f, ok := tbFields[key]
+		if !ok {
+			r.add(lt.name, key, true, "removed")
+			continue
+		}
+
+		before := normalize(string(local.src[local.fset.Position(lf.Type.Pos()).Offset:local.fset.Position(lf.Type.End()).Offset]))
+		after, _ := rewriteType(t.tbSource, f.Type, key)
+		if after = normalize(after); before != after {
+			r.add(lt.name, key, true, fmt.Sprintf("type changed: %s -> %s", before, after))
+		}
+		compareTags(r, lt.name, key, tagOf(lf), tagOf(f))
+	}
+	for _, f := range st.Fields.List {
+		key := fieldKey(t.src, t.fset, f)
+		if localFields[key] {
+			continue
+		}
+		if hasRequired(tagOf(f)) {
+			r.add(lt.name, key, true, "added as a required field")
+		} else {
+			r.add(lt.name, key, false, "added")
+		}
+	}
+}
+
+// compareTags reports the changes of the struct tags of a field.
+func compareTags(r *report, typeName, field string, before, after reflect.StructTag) {
+	beforeName, beforeOpts, _ := strings.Cut(before.Get("json"), ",")
+	afterName, afterOpts, _ := strings.Cut(after.Get("json"), ",")
+	if beforeName != afterName {
+		r.add(typeName, field, true, fmt.Sprintf("JSON name changed: %q -> %q", beforeName, afterName))
+	}
+	if beforeOpts != afterOpts {
+		r.add(typeName, field, false, fmt.Sprintf("JSON options changed: %q -> %q", beforeOpts, afterOpts))
+	}
+
+	switch {
+	case !hasRequired(before) && hasRequired(after):
+		r.add(typeName, field, true, "became required")
+	case hasRequired(before) && !hasRequired(after):
+		r.add(typeName, field, false, "became optional")
+	case before.Get("validate") != after.Get("validate"):
+		r.add(typeName, field, false, fmt.Sprintf("validate tag changed: %q -> %q", before.Get("validate"), after.Get("validate")))
+	}
+
+	for _, key := range []string{"example", "default", "enums"} {
+		if before.Get(key) != after.Get(key) {
+			r.add(typeName, field, false, fmt.Sprintf("%s tag changed: %q -> %q", key, before.Get(key), after.Get(key)))
+		}
+	}
+}
+
+func tagOf(f *ast.Field) reflect.StructTag {
+	if f.Tag == nil {
+		return ""
+	}
+	return reflect.StructTag(strings.Trim(f.Tag.Value, "`"))
+}
+
+func hasRequired(tag reflect.StructTag) bool {
+	for _, rule := range strings.Split(tag.Get("validate"), ",") {
+		if rule == "required" {
+			return true
+		}
+	}
+	return false
+}
+
+var spaces = regexp.MustCompile(`\s+`)
+
+func normalize(s string) string {
+	return spaces.ReplaceAllString(strings.TrimSpace(s), " ")
+}
+
+var (
+	versionLinePattern      = regexp.MustCompile(`(?m)^// \* Version:.*$`)
+	synchronizedLinePattern = regexp.MustCompile(`(?m)^// \* Synchronized:.*$`)
+)
+
+// render returns the synchronized copied-tb-model.go with the version header lines replaced.
+func (l *localFile) render(result syncResult, header [2]string) []byte {
+	type span struct {
+		start, end int
+		text       string
+	}
+	var spans []span
+	for _, lt := range l.types {
+		if text, ok := result.texts[lt.name]; ok {
+			spans = append(spans, span{lt.start, lt.end, text})
+		}
+	}
+	for _, lc := range l.consts {
+		if text, ok := result.constTexts[lc]; ok {
+			spans = append(spans, span{lc.start, lc.end, text})
+		}
+	}
+	sort.Slice(spans, func(i, j int) bool { return spans[i].start < spans[j].start })
+
+	var buf bytes.Buffer
+	pos := 0
+	for _, s := range spans {
+		buf.Write(l.src[pos:s.start])
+		buf.WriteString(s.text)
+		pos = s.end
+	}
+	buf.Write(l.src[pos:])
+	for _, text := range result.added {
+		buf.WriteString("\n" + text + "\n")
+	}
+
+	out := buf.Bytes()
+	if versionLinePattern.Match(out) {
+		out = versionLinePattern.ReplaceAllLiteral(out, []byte(header[0]))
+	} else {
+		out = bytes.Replace(out, []byte("\n\n"), []byte("\n\n"+header[0]+"\n"), 1)
+	}
+	if synchronizedLinePattern.Match(out) {
+		out = synchronizedLinePattern.ReplaceAllLiteral(out, []byte(header[1]))
+	} else {
+		out = bytes.Replace(out, []byte(header[0]+"\n"), []byte(header[0]+"\n"+header[1]+"\n"), 1)
+	}
+	return out
+}
@@ -0,0 +1,66 @@
+package main
+
+import (
+	"bytes"
+	"flag"
+	"go/format"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+var update = flag.Bool("update", false, "update the golden file of testdata")
+
+// TestSynchronize synchronizes testdata/local/copied-tb-model.go with the checkout in testdata/tb
+// and compares the result with testdata/synced.golden.
+func TestSynchronize(t *testing.T) {
+	local, err := parseLocalFile(filepath.Join("testdata", "local", "copied-tb-model.go"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	idx, err := loadTbTypes(filepath.Join("testdata", "tb"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	result := synchronize(local, idx, false)
+	src := local.render(result, header("v0.12.6", "", "2026-10-17", "test"))
+	got, err := format.Source(src)
+	if err != nil {
+		t.Fatalf("synchronized file does not compile: %v\n%s", err, src)
+	}
+
+	golden := filepath.Join("testdata", "synced.golden")
+	if *update {
+		if err := os.WriteFile(golden, got, 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	want, err := os.ReadFile(golden)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(got, want) {
+		t.Errorf("synchronized file differs from %s:\n%s", golden, got)
+	}
+
+	var out strings.Builder
+	result.report.print(&out)
+	for _, want := range []string{
+		"OSArchitecture.X86_32: removed",
+		"ImageStatus.ImageDeprecated: value changed: \"Deprecated\" -> \"Obsolete\"",
+		"ImageInfo.Kind: added",
+		"SpecInfo.VCPU: added",
+		"OSArchitecture.RISCV64: added",
+		"ImageKind: added as a dependency of ImageInfo",
+		"ImageKind: constants added: ImageKindBasic, ImageKindGPU",
+		"LocalOnly: constants not found in CB-Tumblebug (kept as is)",
+	} {
+		if !strings.Contains(out.String(), want) {
+			t.Errorf("report does not contain %q:\n%s", want, out.String())
+		}
+	}
+	if !result.report.hasBreaking() {
+		t.Error("report has no breaking change")
+	}
+}
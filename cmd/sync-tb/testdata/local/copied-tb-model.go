@@ -0,0 +1,39 @@
+package cloudmodel
+
+// * Version: CB-Tumblebug v0.12.5
+// * Synchronized: 2026-04-03
+
+type (
+	// SpecInfo is the information of a VM spec.
+	SpecInfo struct {
+		Id           string `json:"id"`
+		Architecture string `json:"architecture"`
+	}
+
+	// ImageInfo is the information of an OS image.
+	// cm-model: used by the image matching
+	ImageInfo struct {
+		Id             string         `json:"id"`
+		OSArchitecture OSArchitecture `json:"osArchitecture"`
+		ImageStatus    ImageStatus    `json:"imageStatus"`
+	}
+)
+
+type OSArchitecture string
+
+const (
+	ARM64  OSArchitecture = "arm64"
+	X86_32 OSArchitecture = "x86_32"
+	X86_64 OSArchitecture = "x86_64"
+)
+
+type ImageStatus string
+
+// Statuses of the images
+const (
+	ImageAvailable  ImageStatus = "Available"
+	ImageDeprecated ImageStatus = "Deprecated"
+)
+
+// LocalOnly is not in CB-Tumblebug.
+const LocalOnly = "local"
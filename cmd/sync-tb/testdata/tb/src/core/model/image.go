@@ -0,0 +1,42 @@
+package model
+
+// OSArchitecture is the architecture of an OS image.
+type OSArchitecture string
+
+const (
+	ARM64   OSArchitecture = "arm64"
+	X86_64  OSArchitecture = "x86_64"
+	RISCV64 OSArchitecture = "riscv64"
+)
+
+type ImageStatus string
+
+const (
+	ImageAvailable  ImageStatus = "Available"
+	ImageDeprecated ImageStatus = "Obsolete"
+)
+
+// ImageKind is the kind of an image.
+type ImageKind string
+
+const (
+	ImageKindBasic ImageKind = "basic"
+	ImageKindGPU   ImageKind = "gpu"
+)
+
+type (
+	// SpecInfo is the information of a VM spec.
+	SpecInfo struct {
+		Id           string `json:"id"`
+		Architecture string `json:"architecture"`
+		VCPU         uint16 `json:"vCPU"`
+	}
+
+	// ImageInfo is the information of an OS image.
+	ImageInfo struct {
+		Id             string         `json:"id"`
+		OSArchitecture OSArchitecture `json:"osArchitecture"`
+		ImageStatus    ImageStatus    `json:"imageStatus"`
+		Kind           ImageKind      `json:"kind"`
+	}
+)
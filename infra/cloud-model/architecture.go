@@ -0,0 +1,57 @@
+package cloudmodel
+
+import "strings"
+
+// architectureAliases maps the architecture names used by OSes, CPUs and container registries to OSArchitecture.
+var architectureAliases = map[string]OSArchitecture{
+	"x86_64": X86_64, "amd64": X86_64, "x64": X86_64, "x86-64": X86_64,
+	"x86_32": X86_32, "x86": X86_32, "i386": X86_32, "i686": X86_32, "386": X86_32,
+	"arm64": ARM64, "aarch64": ARM64, "arm64v8": ARM64, "armv8": ARM64,
+	"arm32": ARM32, "arm": ARM32, "armhf": ARM32, "armv7": ARM32, "armv7l": ARM32, "armv6": ARM32, "armv6l": ARM32, "armv5": ARM32,
+	"s390x":     S390X,
+	"arm64_mac": ARM64_MAC, "x86_32_mac": X86_32_MAC, "x86_64_mac": X86_64_MAC,
+	"na": ArchitectureNA,
+}
+
+// NormalizeArchitecture converts an architecture name (e.g., aarch64 from `lscpu`, amd64 from a container registry)
+// into OSArchitecture. An unknown name is returned in lower case as is.
+func NormalizeArchitecture(arch string) OSArchitecture {
+	name := strings.ToLower(strings.TrimSpace(arch))
+	if name == "" {
+		return ArchitectureUnknown
+	}
+	if normalized, ok := architectureAliases[name]; ok {
+		return normalized
+	}
+	return OSArchitecture(name)
+}
+
+// macArchitectures maps the architectures of the Mac instances of CB-Tumblebug to their instruction set.
+var macArchitectures = map[OSArchitecture]OSArchitecture{
+	ARM64_MAC:  ARM64,
+	X86_32_MAC: X86_32,
+	X86_64_MAC: X86_64,
+}
+
+// IsCompatibleArchitecture returns true if software built for the given architecture runs on the target architecture.
+// This is the compatibility rule of the whole module (e.g., softwaremodel.IsArchitectureCompatible):
+//   - the architectures are normalized (see NormalizeArchitecture) and the _mac variants are their instruction set
+//     (e.g., arm64_mac is arm64)
+//   - an unknown architecture (empty or NA) on either side is regarded as compatible
+//   - 32-bit x86 software runs on x86_64
+func IsCompatibleArchitecture(arch, target OSArchitecture) bool {
+	arch, target = instructionSet(arch), instructionSet(target)
+	if arch == ArchitectureUnknown || arch == ArchitectureNA || target == ArchitectureUnknown || target == ArchitectureNA {
+		return true
+	}
+	return arch == target || (arch == X86_32 && target == X86_64)
+}
+
+// instructionSet normalizes an architecture and converts a _mac variant into its instruction set.
+func instructionSet(arch OSArchitecture) OSArchitecture {
+	normalized := NormalizeArchitecture(string(arch))
+	if base, ok := macArchitectures[normalized]; ok {
+		return base
+	}
+	return normalized
+}
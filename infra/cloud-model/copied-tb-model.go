@@ -465,7 +465,7 @@ type SshCmdResult struct { // Tumblebug
 	Command map[int]string `json:"command"`
 	Stdout  map[int]string `json:"stdout"`
 	Stderr  map[int]string `json:"stderr"`
-	Err     error          `json:"err"`
+	Err     error          `json:"err"` // Encoded as an SshCmdError (see ssh-cmd-result.go)
 }
 
 // SshHostKeyInfo is struct for SSH host key information (TOFU verification)
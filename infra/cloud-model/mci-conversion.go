@@ -0,0 +1,472 @@
+package cloudmodel
+
+import (
+	"fmt"
+	"strings"
+)
+
+// MciResources represents the resources implied by the default resource option of MciDynamicReq.
+type MciResources struct {
+	VNets          []VNetReq          `json:"vNets"`
+	SecurityGroups []SecurityGroupReq `json:"securityGroups"`
+	SshKeys        []SshKeyReq        `json:"sshKeys"`
+}
+
+// MciConversionOptions represents the options of ExpandMciDynamicReq and CollapseMciReq.
+type MciConversionOptions struct {
+	NsId           string                      `json:"nsId" default:"default"` // Namespace of the shared resources
+	VNetTemplates  map[string]VNetReq          `json:"vNetTemplates"`          // vNet templates by ID
+	SgTemplates    map[string]SecurityGroupReq `json:"sgTemplates"`            // Security group templates by ID
+	DefaultVNet    string                      `json:"defaultVNet" default:"10.0.0.0/16"`
+	DefaultSubnets []string                    `json:"defaultSubnets" default:"10.0.1.0/24,...,10.0.255.0/24"`
+}
+
+// ExpandMciDynamicReq expands a dynamic request into a static request and the shared resources it implies,
+// as CB-Tumblebug does with the default resource option.
+// The shared vNet, security group and SSH key of a connection are named {nsId}-shared-{connectionName};
+// a resource made from a template is suffixed with the template ID (e.g., default-shared-aws-ap-northeast-2-web-sg).
+// The connection of a subgroup is ConnectionName, or is derived from SpecId ({csp}+{region}+{spec name}).
+// A subgroup template ID overrides the MCI-level one.
+// A subgroup with a zone gets its own subnet ({vNet name}-{zone}) in the zone.
+func ExpandMciDynamicReq(req MciDynamicReq, opts MciConversionOptions) (MciReq, MciResources, error) {
+	opts = opts.withDefaults()
+
+	mci := MciReq{
+		Name:                   req.Name,
+		InstallMonAgent:        req.InstallMonAgent,
+		Label:                  req.Label,
+		SystemLabel:            req.SystemLabel,
+		Description:            req.Description,
+		PostCommand:            req.PostCommand,
+		PolicyOnPartialFailure: req.PolicyOnPartialFailure,
+	}
+	var resources MciResources
+	vnets := make(map[string]int) // vNet name -> index in resources.VNets
+	sgs := make(map[string]bool)
+	keys := make(map[string]bool)
+
+	for i, subGroup := range req.SubGroups {
+		connectionName, err := subGroupConnection(subGroup)
+		if err != nil {
+			return MciReq{}, MciResources{}, fmt.Errorf("subGroup[%d] (%s): %w", i, subGroup.Name, err)
+		}
+
+		vnetTemplateId := subGroup.VNetTemplateId
+		if vnetTemplateId == "" {
+			vnetTemplateId = req.VNetTemplateId
+		}
+		sgTemplateId := subGroup.SgTemplateId
+		if sgTemplateId == "" {
+			sgTemplateId = req.SgTemplateId
+		}
+		sharedName := sharedResourceName(opts.NsId, connectionName)
+
+		// vNet and subnet
+		vnetName := templatedName(sharedName, vnetTemplateId)
+		idx, ok := vnets[vnetName]
+		if !ok {
+			vnet, err := opts.newVNet(vnetName, connectionName, vnetTemplateId)
+			if err != nil {
+				return MciReq{}, MciResources{}, fmt.Errorf("subGroup[%d] (%s): %w", i, subGroup.Name, err)
+			}
+			idx = len(resources.VNets)
+			vnets[vnetName] = idx
+			resources.VNets = append(resources.VNets, vnet)
+		}
+		subnetName, err := opts.subnetFor(&resources.VNets[idx], subGroup.Zone, vnetTemplateId != "")
+		if err != nil {
+			return MciReq{}, MciResources{}, fmt.Errorf("subGroup[%d] (%s): %w", i, subGroup.Name, err)
+		}
+
+		// Security group
+		sgName := templatedName(sharedName, sgTemplateId)
+		if !sgs[sgName] {
+			sg, err := opts.newSecurityGroup(sgName, connectionName, vnetName, sgTemplateId)
+			if err != nil {
+				return MciReq{}, MciResources{}, fmt.Errorf("subGroup[%d] (%s): %w", i, subGroup.Name, err)
+			}
+			sgs[sgName] = true
+			resources.SecurityGroups = append(resources.SecurityGroups, sg)
+		}
+
+		// SSH key
+		if !keys[sharedName] {
+			keys[sharedName] = true
+			resources.SshKeys = append(resources.SshKeys, SshKeyReq{
+				Name:           sharedName,
+				ConnectionName: connectionName,
+				Description:    "Shared SSH key of " + connectionName,
+			})
+		}
+
+		subGroupSize := subGroup.SubGroupSize
+		if subGroupSize <= 0 {
+			subGroupSize = 1
+		}
+		mci.SubGroups = append(mci.SubGroups, CreateSubGroupReq{
+			Name:             subGroup.Name,
+			SubGroupSize:     subGroupSize,
+			Label:            subGroup.Label,
+			Description:      subGroup.Description,
+			ConnectionName:   connectionName,
+			SpecId:           subGroup.SpecId,
+			ImageId:          subGroup.ImageId,
+			VNetId:           vnetName,
+			SubnetId:         subnetName,
+			SecurityGroupIds: []string{sgName},
+			SshKeyId:         sharedName,
+			VmUserPassword:   subGroup.VmUserPassword,
+			RootDiskType:     subGroup.RootDiskType,
+			RootDiskSize:     subGroup.RootDiskSize,
+			DataDiskIds:      []string{},
+		})
+	}
+
+	return mci, resources, nil
+}
+
+// CollapseMciReq collapses a static request back to the dynamic form.
+// It succeeds only if every subgroup uses the shared resources as ExpandMciDynamicReq names and makes them;
+// the template IDs are recovered from the resource names and the zone from the subnet in the resources.
+// The vNets and security groups must be in the resources with the content of the default or of the template
+// (CIDR blocks, subnets, firewall rules; descriptions aside), since the dynamic form cannot express any other.
+// Any subgroup using its own or modified resources or static-only fields (e.g., data disks, CSP resource IDs)
+// is reported as an error.
+func CollapseMciReq(req MciReq, resources MciResources, opts MciConversionOptions) (MciDynamicReq, error) {
+	opts = opts.withDefaults()
+
+	dynamic := MciDynamicReq{
+		Name:                   req.Name,
+		PolicyOnPartialFailure: req.PolicyOnPartialFailure,
+		InstallMonAgent:        req.InstallMonAgent,
+		PostCommand:            req.PostCommand,
+		SystemLabel:            req.SystemLabel,
+		Description:            req.Description,
+		Label:                  req.Label,
+	}
+	vnets := make(map[string]VNetReq)
+	for _, vnet := range resources.VNets {
+		vnets[vnet.Name] = vnet
+	}
+	sgs := make(map[string]SecurityGroupReq)
+	for _, sg := range resources.SecurityGroups {
+		sgs[sg.Name] = sg
+	}
+
+	var errs []string
+	for i, subGroup := range req.SubGroups {
+		fail := func(format string, args ...any) {
+			errs = append(errs, fmt.Sprintf("subGroup[%d] (%s): ", i, subGroup.Name)+fmt.Sprintf(format, args...))
+		}
+
+		if subGroup.CspResourceId != "" || subGroup.VmUserName != "" || len(subGroup.DataDiskIds) > 0 {
+			fail("static-only fields (cspResourceId, vmUserName, dataDiskIds) cannot be expressed in the dynamic form")
+			continue
+		}
+		sharedName := sharedResourceName(opts.NsId, subGroup.ConnectionName)
+		if subGroup.SshKeyId != sharedName {
+			fail("SSH key %q is not the shared SSH key %q", subGroup.SshKeyId, sharedName)
+			continue
+		}
+		vnetTemplateId, ok := templateOf(subGroup.VNetId, sharedName, opts.VNetTemplates)
+		if !ok {
+			fail("vNet %q is not a shared vNet of %q", subGroup.VNetId, subGroup.ConnectionName)
+			continue
+		}
+		if len(subGroup.SecurityGroupIds) != 1 {
+			fail("%d security groups cannot be expressed in the dynamic form", len(subGroup.SecurityGroupIds))
+			continue
+		}
+		sgTemplateId, ok := templateOf(subGroup.SecurityGroupIds[0], sharedName, opts.SgTemplates)
+		if !ok {
+			fail("security group %q is not a shared security group of %q", subGroup.SecurityGroupIds[0], subGroup.ConnectionName)
+			continue
+		}
+		vnet, ok := vnets[subGroup.VNetId]
+		if !ok {
+			fail("vNet %q is not in the resources", subGroup.VNetId)
+			continue
+		}
+		if problem := opts.vnetMismatch(vnet, subGroup.ConnectionName, vnetTemplateId); problem != "" {
+			fail("vNet %q differs from the shared vNet: %s", subGroup.VNetId, problem)
+			continue
+		}
+		sg, ok := sgs[subGroup.SecurityGroupIds[0]]
+		if !ok {
+			fail("security group %q is not in the resources", subGroup.SecurityGroupIds[0])
+			continue
+		}
+		if problem := opts.securityGroupMismatch(sg, subGroup.ConnectionName, subGroup.VNetId, sgTemplateId); problem != "" {
+			fail("security group %q differs from the shared security group: %s", sg.Name, problem)
+			continue
+		}
+		zone, ok := zoneOf(vnet, subGroup.SubnetId)
+		if !ok {
+			fail("subnet %q is not in the shared vNet %q", subGroup.SubnetId, subGroup.VNetId)
+			continue
+		}
+
+		dynamic.SubGroups = append(dynamic.SubGroups, CreateSubGroupDynamicReq{
+			Name:           subGroup.Name,
+			SubGroupSize:   subGroup.SubGroupSize,
+			Label:          subGroup.Label,
+			Description:    subGroup.Description,
+			SpecId:         subGroup.SpecId,
+			ImageId:        subGroup.ImageId,
+			RootDiskType:   subGroup.RootDiskType,
+			RootDiskSize:   subGroup.RootDiskSize,
+			VmUserPassword: subGroup.VmUserPassword,
+			ConnectionName: subGroup.ConnectionName,
+			Zone:           zone,
+			VNetTemplateId: vnetTemplateId,
+			SgTemplateId:   sgTemplateId,
+		})
+	}
+	if len(errs) > 0 {
+		return MciDynamicReq{}, fmt.Errorf("cannot collapse MCI %q: %s", req.Name, strings.Join(errs, "; "))
+	}
+
+	// Hoist the template IDs shared by all subgroups to the MCI level.
+	dynamic.VNetTemplateId = commonTemplate(dynamic.SubGroups, func(s *CreateSubGroupDynamicReq) *string { return &s.VNetTemplateId })
+	dynamic.SgTemplateId = commonTemplate(dynamic.SubGroups, func(s *CreateSubGroupDynamicReq) *string { return &s.SgTemplateId })
+
+	return dynamic, nil
+}
+
+// ConnectionFromSpecId derives the connection name from a spec ID in the form of {csp}+{region}+{spec name}.
+func ConnectionFromSpecId(specId string) (string, error) {
+	parts := strings.SplitN(specId, "+", 3)
+	if len(parts) != 3 || parts[0] == "" || parts[1] == "" {
+		return "", fmt.Errorf("spec ID %q is not in the form of {csp}+{region}+{spec name}", specId)
+	}
+	return strings.ToLower(parts[0] + "-" + parts[1]), nil
+}
+
+func subGroupConnection(subGroup CreateSubGroupDynamicReq) (string, error) {
+	if subGroup.ConnectionName != "" {
+		return subGroup.ConnectionName, nil
+	}
+	return ConnectionFromSpecId(subGroup.SpecId)
+}
+
+func sharedResourceName(nsId, connectionName string) string {
+	return nsId + "-shared-" + connectionName
+}
+
+func templatedName(sharedName, templateId string) string {
+	if templateId == "" {
+		return sharedName
+	}
+	return sharedName + "-" + templateId
+}
+
+// templateOf returns the template ID encoded in a shared resource name ("" for the default resource).
+func templateOf[T any](name, sharedName string, templates map[string]T) (string, bool) {
+	if name == sharedName {
+		return "", true
+	}
+	templateId, ok := strings.CutPrefix(name, sharedName+"-")
+	if !ok {
+		return "", false
+	}
+	_, ok = templates[templateId]
+	return templateId, ok
+}
+
+func zoneOf(vnet VNetReq, subnetName string) (string, bool) {
+	for _, subnet := range vnet.SubnetInfoList {
+		if subnet.Name == subnetName {
+			return subnet.Zone, true
+		}
+	}
+	return "", false
+}
+
+func commonTemplate(subGroups []CreateSubGroupDynamicReq, field func(*CreateSubGroupDynamicReq) *string) string {
+	if len(subGroups) == 0 {
+		return ""
+	}
+	common := *field(&subGroups[0])
+	for i := range subGroups {
+		if *field(&subGroups[i]) != common {
+			return ""
+		}
+	}
+	for i := range subGroups {
+		*field(&subGroups[i]) = ""
+	}
+	return common
+}
+
+func (opts MciConversionOptions) withDefaults() MciConversionOptions {
+	if opts.NsId == "" {
+		opts.NsId = "default"
+	}
+	if opts.DefaultVNet == "" {
+		opts.DefaultVNet = "10.0.0.0/16"
+	}
+	if len(opts.DefaultSubnets) == 0 {
+		for i := 1; i < 256; i++ {
+			opts.DefaultSubnets = append(opts.DefaultSubnets, fmt.Sprintf("10.0.%d.0/24", i))
+		}
+	}
+	return opts
+}
+
+func (opts MciConversionOptions) newVNet(name, connectionName, templateId string) (VNetReq, error) {
+	if templateId == "" {
+		return VNetReq{
+			Name:           name,
+			ConnectionName: connectionName,
+			CidrBlock:      opts.DefaultVNet,
+			SubnetInfoList: []SubnetReq{},
+			Description:    "Shared vNet of " + connectionName,
+		}, nil
+	}
+
+	template, ok := opts.VNetTemplates[templateId]
+	if !ok {
+		return VNetReq{}, fmt.Errorf("vNet template %q is not found", templateId)
+	}
+	vnet := VNetReq{
+		Name:           name,
+		ConnectionName: connectionName,
+		CidrBlock:      template.CidrBlock,
+		SubnetInfoList: make([]SubnetReq, 0, len(template.SubnetInfoList)),
+		Description:    fmt.Sprintf("Shared vNet of %s (template: %s)", connectionName, templateId),
+	}
+	for _, subnet := range template.SubnetInfoList {
+		subnet.Name = name + "-" + subnet.Name
+		vnet.SubnetInfoList = append(vnet.SubnetInfoList, subnet)
+	}
+	return vnet, nil
+}
+
+// subnetFor returns the subnet of the vNet in the zone, adding one to a default vNet if there is none.
+// A templated vNet only provides the subnets of its template, the first one if no zone is given.
+func (opts MciConversionOptions) subnetFor(vnet *VNetReq, zone string, templated bool) (string, error) {
+	for _, subnet := range vnet.SubnetInfoList {
+		if subnet.Zone == zone || (templated && zone == "") {
+			return subnet.Name, nil
+		}
+	}
+	if templated {
+		return "", fmt.Errorf("vNet %q has no subnet in the zone %q", vnet.Name, zone)
+	}
+
+	n := len(vnet.SubnetInfoList)
+	if n >= len(opts.DefaultSubnets) {
+		return "", fmt.Errorf("vNet %q has no more default subnet", vnet.Name)
+	}
+	name := vnet.Name
+	if zone != "" {
+		name += "-" + zone
+	}
+	vnet.SubnetInfoList = append(vnet.SubnetInfoList, SubnetReq{
+		Name:      name,
+		IPv4_CIDR: opts.DefaultSubnets[n],
+		Zone:      zone,
+	})
+	return name, nil
+}
+
+func (opts MciConversionOptions) newSecurityGroup(name, connectionName, vnetName, templateId string) (SecurityGroupReq, error) {
+	sg := SecurityGroupReq{
+		Name:           name,
+		ConnectionName: connectionName,
+		VNetId:         vnetName,
+	}
+	if templateId == "" {
+		rules := []FirewallRuleReq{
+			{Protocol: "TCP", Direction: "inbound", Ports: "1-65535", CIDR: "0.0.0.0/0"},
+			{Protocol: "UDP", Direction: "inbound", Ports: "1-65535", CIDR: "0.0.0.0/0"},
+			{Protocol: "ICMP", Direction: "inbound", CIDR: "0.0.0.0/0"},
+		}
+		sg.FirewallRules = &rules
+		sg.Description = "Shared security group of " + connectionName + " (all open)"
+		return sg, nil
+	}
+
+	template, ok := opts.SgTemplates[templateId]
+	if !ok {
+		return SecurityGroupReq{}, fmt.Errorf("security group template %q is not found", templateId)
+	}
+	if template.FirewallRules != nil {
+		rules := append([]FirewallRuleReq(nil), *template.FirewallRules...)
+		sg.FirewallRules = &rules
+	}
+	sg.Description = fmt.Sprintf("Shared security group of %s (template: %s)", connectionName, templateId)
+	return sg, nil
+}
+
+// vnetMismatch describes how the vNet differs from the one ExpandMciDynamicReq makes, or returns "" if it does not.
+// A default vNet may have any number of the default subnets, added in order, one per zone.
+func (opts MciConversionOptions) vnetMismatch(vnet VNetReq, connectionName, templateId string) string {
+	want, err := opts.newVNet(vnet.Name, connectionName, templateId)
+	if err != nil {
+		return err.Error()
+	}
+	if vnet.ConnectionName != want.ConnectionName {
+		return fmt.Sprintf("connection %q is not %q", vnet.ConnectionName, want.ConnectionName)
+	}
+	if vnet.CidrBlock != want.CidrBlock {
+		return fmt.Sprintf("CIDR block %s is not %s", vnet.CidrBlock, want.CidrBlock)
+	}
+
+	if templateId == "" {
+		for j, subnet := range vnet.SubnetInfoList {
+			name := vnet.Name
+			if subnet.Zone != "" {
+				name += "-" + subnet.Zone
+			}
+			if j >= len(opts.DefaultSubnets) || subnet.Name != name || subnet.IPv4_CIDR != opts.DefaultSubnets[j] {
+				return fmt.Sprintf("subnet %q (%s) is not a default subnet", subnet.Name, subnet.IPv4_CIDR)
+			}
+		}
+		return ""
+	}
+	if len(vnet.SubnetInfoList) != len(want.SubnetInfoList) {
+		return fmt.Sprintf("%d subnets, not the %d subnets of the template", len(vnet.SubnetInfoList), len(want.SubnetInfoList))
+	}
+	for j, subnet := range vnet.SubnetInfoList {
+		w := want.SubnetInfoList[j]
+		if subnet.Name != w.Name || subnet.IPv4_CIDR != w.IPv4_CIDR || subnet.Zone != w.Zone {
+			return fmt.Sprintf("subnet %q (%s) is not the subnet %q (%s) of the template", subnet.Name, subnet.IPv4_CIDR, w.Name, w.IPv4_CIDR)
+		}
+	}
+	return ""
+}
+
+// securityGroupMismatch describes how the security group differs from the one ExpandMciDynamicReq makes,
+// or returns "" if it does not.
+func (opts MciConversionOptions) securityGroupMismatch(sg SecurityGroupReq, connectionName, vnetName, templateId string) string {
+	want, err := opts.newSecurityGroup(sg.Name, connectionName, vnetName, templateId)
+	if err != nil {
+		return err.Error()
+	}
+	switch {
+	case sg.ConnectionName != want.ConnectionName:
+		return fmt.Sprintf("connection %q is not %q", sg.ConnectionName, want.ConnectionName)
+	case sg.VNetId != want.VNetId:
+		return fmt.Sprintf("vNet %q is not %q", sg.VNetId, want.VNetId)
+	case sg.CspResourceId != "":
+		return "a registered CSP resource"
+	}
+
+	var rules, wantRules []FirewallRuleReq
+	if sg.FirewallRules != nil {
+		rules = *sg.FirewallRules
+	}
+	if want.FirewallRules != nil {
+		wantRules = *want.FirewallRules
+	}
+	if len(rules) != len(wantRules) {
+		return fmt.Sprintf("%d firewall rules, not %d", len(rules), len(wantRules))
+	}
+	for j := range rules {
+		if rules[j] != wantRules[j] {
+			return fmt.Sprintf("firewall rule %+v is not %+v", rules[j], wantRules[j])
+		}
+	}
+	return ""
+}
@@ -0,0 +1,185 @@
+package cloudmodel
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// conversionOptions has a vNet template and a security group template, both with the ID "web".
+func conversionOptions() MciConversionOptions {
+	rules := []FirewallRuleReq{{Protocol: "TCP", Direction: "inbound", Ports: "80,443", CIDR: "0.0.0.0/0"}}
+	return MciConversionOptions{
+		VNetTemplates: map[string]VNetReq{"web": {
+			CidrBlock: "192.168.0.0/16",
+			SubnetInfoList: []SubnetReq{
+				{Name: "a", IPv4_CIDR: "192.168.1.0/24", Zone: "asia-northeast3-a"},
+				{Name: "b", IPv4_CIDR: "192.168.2.0/24", Zone: "asia-northeast3-b"},
+			},
+		}},
+		SgTemplates: map[string]SecurityGroupReq{"web": {FirewallRules: &rules}},
+	}
+}
+
+// dynamicMci has subgroups on the default resources, in zones, and on the templated resources.
+func dynamicMci() MciDynamicReq {
+	return MciDynamicReq{
+		Name:            "mci01",
+		InstallMonAgent: "no",
+		Label:           map[string]string{"env": "test"},
+		Description:     "Made in CB-TB",
+		SubGroups: []CreateSubGroupDynamicReq{
+			{Name: "g1", SubGroupSize: 2, SpecId: "aws+ap-northeast-2+t3.nano", ImageId: "ubuntu22.04", ConnectionName: "aws-ap-northeast-2"},
+			{Name: "g2", SubGroupSize: 1, SpecId: "aws+ap-northeast-2+t3.small", ImageId: "ubuntu22.04", ConnectionName: "aws-ap-northeast-2", Zone: "ap-northeast-2c"},
+			{
+				Name: "g3", SubGroupSize: 1, SpecId: "gcp+asia-northeast3+e2-small", ImageId: "ubuntu22.04", ConnectionName: "gcp-asia-northeast3",
+				Zone: "asia-northeast3-b", VNetTemplateId: "web", SgTemplateId: "web", RootDiskSize: 50,
+			},
+		},
+	}
+}
+
+func TestExpandCollapseMciReq(t *testing.T) {
+	opts := conversionOptions()
+	mci, resources, err := ExpandMciDynamicReq(dynamicMci(), opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var subnets []string
+	for _, subGroup := range mci.SubGroups {
+		subnets = append(subnets, subGroup.SubnetId)
+	}
+	want := []string{
+		"default-shared-aws-ap-northeast-2",
+		"default-shared-aws-ap-northeast-2-ap-northeast-2c",
+		"default-shared-gcp-asia-northeast3-web-b",
+	}
+	if !reflect.DeepEqual(subnets, want) {
+		t.Errorf("subnets = %v, want %v", subnets, want)
+	}
+	if len(resources.VNets) != 2 || len(resources.SecurityGroups) != 2 || len(resources.SshKeys) != 2 {
+		t.Errorf("%d vNets, %d security groups, %d SSH keys, want 2 each", len(resources.VNets), len(resources.SecurityGroups), len(resources.SshKeys))
+	}
+
+	dynamic, err := CollapseMciReq(mci, resources, opts)
+	if err != nil {
+		t.Fatalf("CollapseMciReq() = %v", err)
+	}
+	if !reflect.DeepEqual(dynamic, dynamicMci()) {
+		t.Errorf("CollapseMciReq(ExpandMciDynamicReq()) = %+v\nwant %+v", dynamic, dynamicMci())
+	}
+}
+
+func TestCollapseMciReqHoistsCommonTemplates(t *testing.T) {
+	opts := conversionOptions()
+	req := dynamicMci()
+	req.SubGroups = req.SubGroups[2:]
+	req.SubGroups[0].VNetTemplateId, req.SubGroups[0].SgTemplateId = "", ""
+	req.VNetTemplateId, req.SgTemplateId = "web", "web"
+
+	mci, resources, err := ExpandMciDynamicReq(req, opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	dynamic, err := CollapseMciReq(mci, resources, opts)
+	if err != nil {
+		t.Fatalf("CollapseMciReq() = %v", err)
+	}
+	if !reflect.DeepEqual(dynamic, req) {
+		t.Errorf("CollapseMciReq() = %+v\nwant %+v", dynamic, req)
+	}
+}
+
+func TestCollapseMciReqRejects(t *testing.T) {
+	const defaultVNet, defaultSg = 0, 0 // Indexes of the resources of aws-ap-northeast-2
+	const webVNet, webSg = 1, 1         // Indexes of the templated resources of gcp-asia-northeast3
+	tests := []struct {
+		name    string
+		modify  func(mci *MciReq, resources *MciResources)
+		wantErr string
+	}{
+		{
+			name:    "custom CIDR block of the default vNet",
+			modify:  func(mci *MciReq, r *MciResources) { r.VNets[defaultVNet].CidrBlock = "172.16.0.0/16" },
+			wantErr: "CIDR block 172.16.0.0/16 is not 10.0.0.0/16",
+		},
+		{
+			name:    "custom subnet of the default vNet",
+			modify:  func(mci *MciReq, r *MciResources) { r.VNets[defaultVNet].SubnetInfoList[1].IPv4_CIDR = "10.0.100.0/24" },
+			wantErr: `subnet "default-shared-aws-ap-northeast-2-ap-northeast-2c" (10.0.100.0/24) is not a default subnet`,
+		},
+		{
+			name: "additional subnet of the default vNet",
+			modify: func(mci *MciReq, r *MciResources) {
+				r.VNets[defaultVNet].SubnetInfoList = append(r.VNets[defaultVNet].SubnetInfoList, SubnetReq{Name: "db", IPv4_CIDR: "10.0.3.0/24"})
+			},
+			wantErr: `subnet "db" (10.0.3.0/24) is not a default subnet`,
+		},
+		{
+			name:    "custom CIDR block of the templated vNet",
+			modify:  func(mci *MciReq, r *MciResources) { r.VNets[webVNet].CidrBlock = "192.168.0.0/20" },
+			wantErr: "CIDR block 192.168.0.0/20 is not 192.168.0.0/16",
+		},
+		{
+			name:    "custom subnet of the templated vNet",
+			modify:  func(mci *MciReq, r *MciResources) { r.VNets[webVNet].SubnetInfoList[0].Zone = "asia-northeast3-c" },
+			wantErr: "is not the subnet",
+		},
+		{
+			name: "custom rules of the default security group",
+			modify: func(mci *MciReq, r *MciResources) {
+				rules := []FirewallRuleReq{{Protocol: "TCP", Direction: "inbound", Ports: "22", CIDR: "10.0.0.0/16"}}
+				r.SecurityGroups[defaultSg].FirewallRules = &rules
+			},
+			wantErr: "1 firewall rules, not 3",
+		},
+		{
+			name: "custom rule of the templated security group",
+			modify: func(mci *MciReq, r *MciResources) {
+				(*r.SecurityGroups[webSg].FirewallRules)[0].CIDR = "10.0.0.0/8"
+			},
+			wantErr: `security group "default-shared-gcp-asia-northeast3-web" differs from the shared security group: firewall rule`,
+		},
+		{
+			name:    "registered security group",
+			modify:  func(mci *MciReq, r *MciResources) { r.SecurityGroups[defaultSg].CspResourceId = "sg-0123" },
+			wantErr: "a registered CSP resource",
+		},
+		{
+			name:    "vNet missing in the resources",
+			modify:  func(mci *MciReq, r *MciResources) { r.VNets = r.VNets[1:] },
+			wantErr: `vNet "default-shared-aws-ap-northeast-2" is not in the resources`,
+		},
+		{
+			name:    "security group missing in the resources",
+			modify:  func(mci *MciReq, r *MciResources) { r.SecurityGroups = nil },
+			wantErr: `security group "default-shared-aws-ap-northeast-2" is not in the resources`,
+		},
+		{
+			name:    "unshared security group",
+			modify:  func(mci *MciReq, r *MciResources) { mci.SubGroups[0].SecurityGroupIds = []string{"sg-01"} },
+			wantErr: `security group "sg-01" is not a shared security group`,
+		},
+		{
+			name:    "data disks",
+			modify:  func(mci *MciReq, r *MciResources) { mci.SubGroups[2].DataDiskIds = []string{"disk-01"} },
+			wantErr: "subGroup[2] (g3): static-only fields",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			opts := conversionOptions()
+			mci, resources, err := ExpandMciDynamicReq(dynamicMci(), opts)
+			if err != nil {
+				t.Fatal(err)
+			}
+			tt.modify(&mci, &resources)
+
+			if _, err := CollapseMciReq(mci, resources, opts); err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("CollapseMciReq() = %v, want %q", err, tt.wantErr)
+			}
+		})
+	}
+}
@@ -0,0 +1,206 @@
+package cloudmodel
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+
+	onpremisemodel "github.com/cloud-barista/cm-model/infra/on-premise-model"
+)
+
+// Status values of the migrated model.
+const (
+	StatusMigrated          = "migrated"           // Every source server has a VM
+	StatusPartiallyMigrated = "partially-migrated" // Some source servers have no VM
+	StatusNotMigrated       = "not-migrated"       // No source server has a VM
+)
+
+// How a VM is matched to its source server.
+const (
+	MatchedByMachineId = "machineId" // The VM has the LabelSourceMachineId label of the server
+	MatchedByHostname  = "hostname"  // The VM has the LabelSourceHostname label of the server
+	MatchedByName      = "name"      // The subgroup of the VM is named as RecommendVmInfra names the subgroup of the server
+)
+
+// MigratedVmInfraModel represents the result of a migration linking the source servers to the created VMs.
+type MigratedVmInfraModel struct {
+	MigratedVmInfraModel MigratedVmInfra `json:"migratedVmInfraModel" validate:"required"`
+}
+
+// MigratedVmInfra represents the migrated virtual machine infrastructure information.
+type MigratedVmInfra struct {
+	NameSeed         string           `json:"nameSeed"`
+	MciId            string           `json:"mciId"`
+	Status           string           `json:"status"`
+	Description      string           `json:"description"`
+	Servers          []MigratedServer `json:"servers"`
+	UnmatchedServers []string         `json:"unmatchedServers"` // Machine IDs (or hostnames) of the source servers without a VM
+	UnmatchedVms     []string         `json:"unmatchedVms"`     // IDs of the VMs without a source server
+}
+
+// MigratedServer links a source server to its resulting VM.
+type MigratedServer struct {
+	SourceMachineId string        `json:"sourceMachineId"`
+	SourceHostname  string        `json:"sourceHostname"`
+	MatchedBy       string        `json:"matchedBy" enums:"machineId,hostname,name"`
+	Vm              VmInfo        `json:"vm"`
+	Delta           ResourceDelta `json:"delta"`
+}
+
+// ResourceDelta is the difference of the resources between a source server and its VM (target - source).
+// A positive value means the VM has more resources than the server.
+type ResourceDelta struct {
+	SourceVCPU         int     `json:"sourceVCPU"` // Sockets × threads per socket
+	TargetVCPU         int     `json:"targetVCPU"`
+	VCPU               int     `json:"vCPU"`
+	SourceMemoryGiB    float64 `json:"sourceMemoryGiB"`
+	TargetMemoryGiB    float64 `json:"targetMemoryGiB"`
+	MemoryGiB          float64 `json:"memoryGiB"`
+	SourceRootDiskSize int     `json:"sourceRootDiskSize"` // Unit GiB
+	TargetRootDiskSize int     `json:"targetRootDiskSize"` // Unit GB as in VmInfo.RootDiskSize; 0 if unknown
+	RootDiskSize       int     `json:"rootDiskSize"`       // Unit GB, with the source size converted to GB (rounded up); 0 if the target size is unknown
+}
+
+// BuildMigratedVmInfra links the servers of the source model to the VMs of the MCI created for them.
+// A VM is matched to a server by the LabelSourceMachineId label, then by the LabelSourceHostname label,
+// and finally by the ID of its subgroup, which must be the name RecommendVmInfra gives to the subgroup of the server
+// ({nameSeed}-{hostname}, suffixed with -2, -3, ... for a repeated hostname). Without a subgroup ID,
+// it is taken from the name of the VM ({subgroup}-{index}).
+// The name seed defaults to the name of the MCI. A server is linked to one VM; the other VMs of the same server
+// (e.g., of a subgroup of size 2) are reported as unmatched.
+func BuildMigratedVmInfra(source onpremisemodel.OnpremiseInfraModel, mci MciInfo, nameSeed string) MigratedVmInfraModel {
+	if nameSeed == "" {
+		nameSeed = mci.Name
+	}
+	seed := toResourceName(nameSeed)
+	servers := source.OnpremiseInfraModel.Servers
+
+	vmOf := make([]int, len(servers)) // Server index -> VM index
+	matchedBy := make([]string, len(servers))
+	for i := range vmOf {
+		vmOf[i] = -1
+	}
+	vmUsed := make([]bool, len(mci.Vm))
+
+	match := func(by string, matches func(server onpremisemodel.ServerProperty, vm VmInfo) bool) {
+		for i, server := range servers {
+			if vmOf[i] >= 0 {
+				continue
+			}
+			for j, vm := range mci.Vm {
+				if !vmUsed[j] && matches(server, vm) {
+					vmOf[i], matchedBy[i], vmUsed[j] = j, by, true
+					break
+				}
+			}
+		}
+	}
+	match(MatchedByMachineId, func(server onpremisemodel.ServerProperty, vm VmInfo) bool {
+		return server.MachineId != "" && vm.Label[LabelSourceMachineId] == server.MachineId
+	})
+	match(MatchedByHostname, func(server onpremisemodel.ServerProperty, vm VmInfo) bool {
+		id, ok := vm.Label[LabelSourceMachineId]
+		return server.Hostname != "" && vm.Label[LabelSourceHostname] == server.Hostname && (!ok || id == "")
+	})
+	subGroupNames := subGroupNames(seed, servers)
+	for i := range servers {
+		if vmOf[i] >= 0 {
+			continue
+		}
+		for j, vm := range mci.Vm {
+			if !vmUsed[j] && subGroupOf(vm) == subGroupNames[i] {
+				vmOf[i], matchedBy[i], vmUsed[j] = j, MatchedByName, true
+				break
+			}
+		}
+	}
+
+	migrated := MigratedVmInfra{
+		NameSeed:         seed,
+		MciId:            mci.Id,
+		Servers:          []MigratedServer{},
+		UnmatchedServers: []string{},
+		UnmatchedVms:     []string{},
+	}
+	for i, server := range servers {
+		if vmOf[i] < 0 {
+			id := server.MachineId
+			if id == "" {
+				id = server.Hostname
+			}
+			migrated.UnmatchedServers = append(migrated.UnmatchedServers, id)
+			continue
+		}
+		vm := mci.Vm[vmOf[i]]
+		migrated.Servers = append(migrated.Servers, MigratedServer{
+			SourceMachineId: server.MachineId,
+			SourceHostname:  server.Hostname,
+			MatchedBy:       matchedBy[i],
+			Vm:              vm,
+			Delta:           computeResourceDelta(server, vm),
+		})
+	}
+	for j, vm := range mci.Vm {
+		if !vmUsed[j] {
+			migrated.UnmatchedVms = append(migrated.UnmatchedVms, vm.Id)
+		}
+	}
+	sort.Strings(migrated.UnmatchedVms)
+
+	switch {
+	case len(migrated.Servers) == 0:
+		migrated.Status = StatusNotMigrated
+	case len(migrated.UnmatchedServers) > 0:
+		migrated.Status = StatusPartiallyMigrated
+	default:
+		migrated.Status = StatusMigrated
+	}
+	migrated.Description = fmt.Sprintf("%d of %d server(s) migrated to %d VM(s) of MCI %s",
+		len(migrated.Servers), len(servers), len(mci.Vm), mci.Id)
+	if len(migrated.UnmatchedVms) > 0 {
+		migrated.Description += fmt.Sprintf(" (%d VM(s) without a source server)", len(migrated.UnmatchedVms))
+	}
+
+	return MigratedVmInfraModel{MigratedVmInfraModel: migrated}
+}
+
+// subGroupOf returns the subgroup ID of the VM, or else the name of the VM without its index
+// (e.g., mig-web01 of mig-web01-1), since CB-Tumblebug names the VMs of a subgroup {subgroup}-{index}.
+func subGroupOf(vm VmInfo) string {
+	if vm.SubGroupId != "" {
+		return vm.SubGroupId
+	}
+	i := strings.LastIndex(vm.Name, "-")
+	if i <= 0 {
+		return ""
+	}
+	if _, err := strconv.Atoi(vm.Name[i+1:]); err != nil {
+		return ""
+	}
+	return vm.Name[:i]
+}
+
+// gibToGB converts a size in GiB to GB, rounded up.
+func gibToGB(gib int) int {
+	return int(math.Ceil(float64(gib) * (1 << 30) / 1e9))
+}
+
+// computeResourceDelta compares the vCPUs, memory and root disk of a server with its VM.
+func computeResourceDelta(server onpremisemodel.ServerProperty, vm VmInfo) ResourceDelta {
+	d := ResourceDelta{
+		SourceVCPU:         int(requiredVCPUs(server.CPU)),
+		TargetVCPU:         int(vm.Spec.VCPU),
+		SourceMemoryGiB:    float64(server.Memory.TotalSize),
+		TargetMemoryGiB:    float64(vm.Spec.MemoryGiB),
+		SourceRootDiskSize: int(server.RootDisk.TotalSize),
+		TargetRootDiskSize: vm.RootDiskSize,
+	}
+	d.VCPU = d.TargetVCPU - d.SourceVCPU
+	d.MemoryGiB = d.TargetMemoryGiB - d.SourceMemoryGiB
+	if d.TargetRootDiskSize > 0 {
+		d.RootDiskSize = d.TargetRootDiskSize - gibToGB(d.SourceRootDiskSize)
+	}
+	return d
+}
@@ -0,0 +1,172 @@
+package cloudmodel
+
+import (
+	"strings"
+	"testing"
+
+	onpremisemodel "github.com/cloud-barista/cm-model/infra/on-premise-model"
+)
+
+func TestBuildMigratedVmInfra(t *testing.T) {
+	labeled := func(id, machineId, hostname string) VmInfo {
+		label := map[string]string{}
+		if machineId != "" {
+			label[LabelSourceMachineId] = machineId
+		}
+		if hostname != "" {
+			label[LabelSourceHostname] = hostname
+		}
+		return VmInfo{Id: id, Name: id, Label: label}
+	}
+	named := func(id, subGroupId string) VmInfo {
+		return VmInfo{Id: id, Name: id, SubGroupId: subGroupId}
+	}
+
+	tests := []struct {
+		name      string
+		servers   []onpremisemodel.ServerProperty
+		vms       []VmInfo
+		want      map[string]string // VM ID of each matched server (by machine ID, or hostname)
+		wantBy    map[string]string
+		unmatched []string
+		status    string
+	}{
+		{
+			name: "machine ID labels of duplicate hostnames",
+			servers: []onpremisemodel.ServerProperty{
+				recommendedServer("m-1", "localhost", "10.0.0.11"),
+				recommendedServer("m-2", "localhost", "10.0.0.12"),
+			},
+			vms:    []VmInfo{labeled("vm-b", "m-2", "localhost"), labeled("vm-a", "m-1", "localhost")},
+			want:   map[string]string{"m-1": "vm-a", "m-2": "vm-b"},
+			wantBy: map[string]string{"m-1": MatchedByMachineId, "m-2": MatchedByMachineId},
+			status: StatusMigrated,
+		},
+		{
+			name: "hostname labels without machine IDs",
+			servers: []onpremisemodel.ServerProperty{
+				recommendedServer("", "web01", "10.0.0.11"),
+				recommendedServer("", "db01", "10.0.0.12"),
+			},
+			vms:    []VmInfo{labeled("vm-db", "", "db01"), labeled("vm-web", "", "web01")},
+			want:   map[string]string{"web01": "vm-web", "db01": "vm-db"},
+			wantBy: map[string]string{"web01": MatchedByHostname, "db01": MatchedByHostname},
+			status: StatusMigrated,
+		},
+		{
+			name: "subgroups of duplicate hostnames without labels",
+			servers: []onpremisemodel.ServerProperty{
+				recommendedServer("m-1", "localhost", "10.0.0.11"),
+				recommendedServer("m-2", "localhost", "10.0.0.12"),
+			},
+			// mig-localhost-2-1 is the VM of mig-localhost-2, not of mig-localhost
+			vms:    []VmInfo{named("mig-localhost-2-1", "mig-localhost-2"), named("mig-localhost-1", "mig-localhost")},
+			want:   map[string]string{"m-1": "mig-localhost-1", "m-2": "mig-localhost-2-1"},
+			wantBy: map[string]string{"m-1": MatchedByName, "m-2": MatchedByName},
+			status: StatusMigrated,
+		},
+		{
+			name: "VM names of duplicate hostnames without subgroup IDs",
+			servers: []onpremisemodel.ServerProperty{
+				recommendedServer("m-1", "localhost", "10.0.0.11"),
+				recommendedServer("m-2", "localhost", "10.0.0.12"),
+			},
+			vms:    []VmInfo{named("mig-localhost-2-1", ""), named("mig-localhost-1", "")},
+			want:   map[string]string{"m-1": "mig-localhost-1", "m-2": "mig-localhost-2-1"},
+			wantBy: map[string]string{"m-1": MatchedByName, "m-2": MatchedByName},
+			status: StatusMigrated,
+		},
+		{
+			name: "VM of another subgroup only",
+			servers: []onpremisemodel.ServerProperty{
+				recommendedServer("m-1", "localhost", "10.0.0.11"),
+			},
+			vms:       []VmInfo{named("mig-localhost-2-1", "")},
+			want:      map[string]string{},
+			unmatched: []string{"mig-localhost-2-1"},
+			status:    StatusNotMigrated,
+		},
+		{
+			name: "hostname label of a VM with a machine ID label",
+			servers: []onpremisemodel.ServerProperty{
+				recommendedServer("m-1", "web01", "10.0.0.11"),
+			},
+			vms:       []VmInfo{labeled("vm-a", "m-9", "web01")},
+			want:      map[string]string{},
+			unmatched: []string{"vm-a"},
+			status:    StatusNotMigrated,
+		},
+		{
+			name: "server without a VM",
+			servers: []onpremisemodel.ServerProperty{
+				recommendedServer("m-1", "web01", "10.0.0.11"),
+				recommendedServer("m-2", "db01", "10.0.0.12"),
+			},
+			vms:    []VmInfo{named("mig-web01-1", "mig-web01")},
+			want:   map[string]string{"m-1": "mig-web01-1"},
+			wantBy: map[string]string{"m-1": MatchedByName},
+			status: StatusPartiallyMigrated,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			source := onpremisemodel.OnpremiseInfraModel{OnpremiseInfraModel: onpremisemodel.OnpremInfra{Servers: tt.servers}}
+			mci := MciInfo{Id: "mci01", Name: "mig", Vm: tt.vms}
+
+			got := BuildMigratedVmInfra(source, mci, "").MigratedVmInfraModel
+			matched := map[string]string{}
+			for _, server := range got.Servers {
+				id := server.SourceMachineId
+				if id == "" {
+					id = server.SourceHostname
+				}
+				matched[id] = server.Vm.Id
+				if server.MatchedBy != tt.wantBy[id] {
+					t.Errorf("server %s matched by %s, want %s", id, server.MatchedBy, tt.wantBy[id])
+				}
+			}
+			if len(matched) != len(tt.want) {
+				t.Errorf("matched %v, want %v", matched, tt.want)
+			}
+			for id, vm := range tt.want {
+				if matched[id] != vm {
+					t.Errorf("server %s matched to %q, want %q", id, matched[id], vm)
+				}
+			}
+			if strings.Join(got.UnmatchedVms, ",") != strings.Join(tt.unmatched, ",") {
+				t.Errorf("unmatched VMs %v, want %v", got.UnmatchedVms, tt.unmatched)
+			}
+			if got.Status != tt.status {
+				t.Errorf("status %s, want %s", got.Status, tt.status)
+			}
+		})
+	}
+}
+
+func TestComputeResourceDelta(t *testing.T) {
+	server := recommendedServer("m-1", "web01", "10.0.0.11") // 4 vCPUs, 8 GiB of memory, 50 GiB of root disk
+	tests := []struct {
+		name         string
+		rootDiskSize int // GB
+		want         int
+	}{
+		{name: "same size", rootDiskSize: 54, want: 0}, // 50 GiB = 53.7 GB
+		{name: "size in GiB taken as GB", rootDiskSize: 50, want: -4},
+		{name: "larger", rootDiskSize: 100, want: 46},
+		{name: "unknown", rootDiskSize: 0, want: 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			vm := VmInfo{RootDiskSize: tt.rootDiskSize, Spec: SpecSummary{VCPU: 8, MemoryGiB: 16}}
+			d := computeResourceDelta(server, vm)
+			if d.RootDiskSize != tt.want {
+				t.Errorf("root disk delta %d GB, want %d", d.RootDiskSize, tt.want)
+			}
+			if d.VCPU != 4 || d.MemoryGiB != 8 {
+				t.Errorf("vCPU delta %d, memory delta %g GiB, want 4, 8", d.VCPU, d.MemoryGiB)
+			}
+		})
+	}
+}
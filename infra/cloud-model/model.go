@@ -1,5 +1,12 @@
 package cloudmodel
 
+// Status values of the recommended models.
+const (
+	StatusRecommended          = "recommended"           // Everything in the source is reflected in the recommendation
+	StatusPartiallyRecommended = "partially-recommended" // Some parts of the source could not be reflected (see the description or warnings)
+	StatusNotRecommended       = "not-recommended"       // Nothing could be recommended
+)
+
 // RecommendedVmInfraModel represents the recommended virtual machine infrastructure model.
 type RecommendedVmInfraModel struct {
 	RecommendedVmInfraModel RecommendedVmInfra `json:"recommendedVmInfraModel" validate:"required"`
@@ -59,6 +66,7 @@ type RecommendedSecurityGroup struct {
 	Status              string           `json:"status"`
 	SourceServers       []string         `json:"sourceServers"`
 	Description         string           `json:"description"`
+	Warnings            []string         `json:"warnings,omitempty"` // Source firewall rules which cannot be expressed in the security group
 	TargetSecurityGroup SecurityGroupReq `json:"targetSecurityGroup"`
 }
 
@@ -0,0 +1,257 @@
+package cloudmodel
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
+
+	onpremisemodel "github.com/cloud-barista/cm-model/infra/on-premise-model"
+)
+
+// Labels of CreateSubGroupReq linking a subgroup to its source server.
+const (
+	LabelSourceMachineId = "sourceMachineId"
+	LabelSourceHostname  = "sourceHostname"
+)
+
+// VmInfraRecommendOptions represents the options of RecommendVmInfra.
+type VmInfraRecommendOptions struct {
+	NameSeed string           `json:"nameSeed" default:"mig"` // Prefix of the names of all target resources
+	VNet     VNetPlanOptions  `json:"vNet"`                   // Name and ConnectionName are overwritten
+	Spec     SpecMatchOptions `json:"spec"`
+}
+
+var invalidNameChars = regexp.MustCompile(`[^a-z0-9-]+`)
+
+// RecommendVmInfra assembles the recommended VM infrastructure for the source servers on the target cloud.
+// It plans the vNet (PlanVNet), security groups (RecommendSecurityGroups), an SSH key, and the best spec
+// (MatchVmSpecs) and OS image (MatchVmOsImages) of each server from the catalogs, and then creates a subgroup
+// per server referencing them. All resources are named with NameSeed (e.g., mig-vnet-01, mig-sg-01, mig-web01,
+// and mig-web01-2 for a repeated hostname), and subgroups are labeled with LabelSourceMachineId and LabelSourceHostname.
+// Servers without a matching spec or image are left out and described in the description.
+func RecommendVmInfra(source onpremisemodel.OnpremiseInfraModel, cloud CloudProperty, specs []SpecInfo, images []ImageInfo, opts VmInfraRecommendOptions) (RecommendedVmInfraModel, error) {
+	infra := source.OnpremiseInfraModel
+	if len(infra.Servers) == 0 {
+		return RecommendedVmInfraModel{}, errors.New("no source server")
+	}
+	if cloud.Csp == "" || cloud.Region == "" {
+		return RecommendedVmInfraModel{}, errors.New("csp and region of the target cloud are required")
+	}
+
+	seed := toResourceName(opts.NameSeed)
+	if seed == "" {
+		seed = "mig"
+	}
+	connectionName := cloudConnectionName(cloud)
+	status := StatusRecommended
+	var notes []string
+	degrade := func(s string) {
+		if s == StatusNotRecommended || s == StatusPartiallyRecommended {
+			status = StatusPartiallyRecommended
+		}
+	}
+
+	// vNet and subnets
+	network := onpremisemodel.DeriveNetworkProperty(infra.Network, infra.Servers)
+	vnetOpts := opts.VNet
+	vnetOpts.Name = seed + "-vnet-01"
+	vnetOpts.ConnectionName = connectionName
+	vnetPlan, err := PlanVNet(network, infra.Servers, vnetOpts)
+	if err != nil {
+		return RecommendedVmInfraModel{}, fmt.Errorf("failed to plan the vNet: %w", err)
+	}
+	for i := range vnetPlan.SubnetMappings {
+		vnetPlan.SubnetMappings[i].SubnetName = seed + "-" + vnetPlan.SubnetMappings[i].SubnetName
+		vnetPlan.RecommendedVNet.TargetVNet.SubnetInfoList[i].Name = vnetPlan.SubnetMappings[i].SubnetName
+	}
+	vnet := vnetPlan.RecommendedVNet.TargetVNet
+	degrade(vnetPlan.RecommendedVNet.Status)
+	if vnetPlan.RecommendedVNet.Status != StatusRecommended {
+		notes = append(notes, "vNet: "+vnetPlan.RecommendedVNet.Description)
+	}
+
+	// Security groups
+	sgList, groupOf := securityGroupsOf(infra) // Index of the server -> index of the security group
+	securityGroups := make([]SecurityGroupReq, 0, len(sgList.TargetSecurityGroupList))
+	for _, group := range sgList.TargetSecurityGroupList {
+		sg := group.TargetSecurityGroup
+		sg.Name = seed + "-" + sg.Name
+		sg.ConnectionName = connectionName
+		sg.VNetId = vnet.Name
+		securityGroups = append(securityGroups, sg)
+		if len(group.Warnings) > 0 {
+			notes = append(notes, fmt.Sprintf("%s: %s", sg.Name, strings.Join(group.Warnings, "; ")))
+		}
+	}
+	degrade(sgList.Status)
+
+	sshKey := SshKeyReq{
+		Name:           seed + "-sshkey-01",
+		ConnectionName: connectionName,
+		Description:    "SSH key for the migrated VMs of " + seed,
+	}
+
+	// Specs, images and subgroups
+	specs = filterSpecs(specs, connectionName)
+	images = filterImages(images, connectionName, cloud.Region)
+	var specList []SpecInfo
+	var imageList []ImageInfo
+	specIds, imageIds := make(map[string]bool), make(map[string]bool)
+	subGroupNames := subGroupNames(seed, infra.Servers)
+	mci := MciReq{
+		Name:            seed,
+		InstallMonAgent: "no",
+		Label:           map[string]string{},
+		Description:     "Recommended VM infrastructure for the migration of " + seed,
+	}
+
+	for i, server := range infra.Servers {
+		serverId := server.MachineId
+		if serverId == "" {
+			serverId = server.Hostname
+		}
+
+		rankedSpecs := MatchVmSpecs(server, specs, opts.Spec)
+		if len(rankedSpecs) == 0 {
+			notes = append(notes, fmt.Sprintf("server %s: no matching spec", serverId))
+			status = StatusPartiallyRecommended
+			continue
+		}
+		imageMatches := MatchVmOsImages(server, images)
+		if len(imageMatches) == 0 || !imageMatches[0].Chosen {
+			notes = append(notes, fmt.Sprintf("server %s: no matching OS image", serverId))
+			status = StatusPartiallyRecommended
+			continue
+		}
+		spec, image := rankedSpecs[0], imageMatches[0].Image
+
+		if !specIds[spec.Id] {
+			specIds[spec.Id] = true
+			specList = append(specList, spec)
+		}
+		if !imageIds[image.Id] {
+			imageIds[image.Id] = true
+			imageList = append(imageList, image)
+		}
+
+		subnetName, ok := vnetPlan.SubnetFor(server)
+		if !ok {
+			subnetName = vnet.SubnetInfoList[0].Name
+		}
+
+		subGroup := CreateSubGroupReq{
+			Name:             subGroupNames[i],
+			SubGroupSize:     1,
+			Label:            map[string]string{LabelSourceMachineId: server.MachineId, LabelSourceHostname: server.Hostname},
+			Description:      fmt.Sprintf("Migrated from %s (%s)", server.Hostname, server.OS.PrettyName),
+			ConnectionName:   connectionName,
+			SpecId:           spec.Id,
+			ImageId:          image.Id,
+			VNetId:           vnet.Name,
+			SubnetId:         subnetName,
+			SecurityGroupIds: []string{securityGroups[groupOf[i]].Name},
+			SshKeyId:         sshKey.Name,
+			DataDiskIds:      []string{},
+		}
+		// The source size is in GiB, but CB-Tumblebug takes the root disk size in GB
+		if size := gibToGB(int(server.RootDisk.TotalSize)); size > 0 && float64(size) >= image.OSDiskSizeGB {
+			subGroup.RootDiskSize = size
+		}
+		mci.SubGroups = append(mci.SubGroups, subGroup)
+	}
+
+	if len(mci.SubGroups) == 0 {
+		status = StatusNotRecommended
+	}
+	description := fmt.Sprintf("%d of %d server(s) recommended on %s", len(mci.SubGroups), len(infra.Servers), connectionName)
+	if len(notes) > 0 {
+		description += " (" + strings.Join(notes, " / ") + ")"
+	}
+
+	return RecommendedVmInfraModel{
+		RecommendedVmInfraModel: RecommendedVmInfra{
+			NameSeed:                seed,
+			Status:                  status,
+			Description:             description,
+			TargetCloud:             cloud,
+			TargetVmInfra:           mci,
+			TargetVNet:              vnet,
+			TargetSshKey:            sshKey,
+			TargetVmSpecList:        specList,
+			TargetVmOsImageList:     imageList,
+			TargetSecurityGroupList: securityGroups,
+		},
+	}, nil
+}
+
+// filterSpecs returns the specs available on the connection (specs without a connection name are kept).
+// Connection names are compared exactly, as in CheckReferences and CB-Tumblebug.
+func filterSpecs(specs []SpecInfo, connectionName string) []SpecInfo {
+	var filtered []SpecInfo
+	for _, spec := range specs {
+		if spec.ConnectionName == "" || spec.ConnectionName == connectionName {
+			filtered = append(filtered, spec)
+		}
+	}
+	return filtered
+}
+
+// filterImages returns the images available on the connection or in the region
+// (images without a connection name and regions are kept).
+func filterImages(images []ImageInfo, connectionName, region string) []ImageInfo {
+	var filtered []ImageInfo
+	for _, image := range images {
+		if (image.ConnectionName == "" && len(image.RegionList) == 0) ||
+			image.ConnectionName == connectionName ||
+			containsFold(image.RegionList, region) {
+			filtered = append(filtered, image)
+		}
+	}
+	return filtered
+}
+
+// subGroupNames returns the names of the subgroups of the servers: {seed}-{hostname}, or {seed}-server-{nn}
+// without a hostname. Servers may share a hostname (e.g., localhost), but subgroup names must be unique,
+// so a repeated name is suffixed with -2, -3, and so on. BuildMigratedVmInfra relies on the same names.
+func subGroupNames(seed string, servers []onpremisemodel.ServerProperty) []string {
+	names := make([]string, len(servers))
+	used := make(map[string]bool)
+	for i, server := range servers {
+		name := toResourceName(server.Hostname)
+		if name == "" {
+			name = fmt.Sprintf("server-%02d", i+1)
+		}
+		base := seed + "-" + name
+		name = base
+		for n := 2; used[name]; n++ {
+			name = fmt.Sprintf("%s-%d", base, n)
+		}
+		used[name] = true
+		names[i] = name
+	}
+	return names
+}
+
+// cloudConnectionName returns the name of the CB-Tumblebug connection of the cloud (e.g., aws-ap-northeast-2),
+// or an empty string if the CSP or region is unknown.
+func cloudConnectionName(cloud CloudProperty) string {
+	if cloud.Csp == "" || cloud.Region == "" {
+		return ""
+	}
+	return strings.ToLower(cloud.Csp + "-" + cloud.Region)
+}
+
+func containsFold(list []string, s string) bool {
+	for _, item := range list {
+		if strings.EqualFold(item, s) {
+			return true
+		}
+	}
+	return false
+}
+
+// toResourceName converts a string into a name allowed for CB-Tumblebug resources (lower case alphanumerics and hyphens).
+func toResourceName(s string) string {
+	return strings.Trim(invalidNameChars.ReplaceAllString(strings.ToLower(s), "-"), "-")
+}
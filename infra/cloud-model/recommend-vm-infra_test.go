@@ -0,0 +1,104 @@
+package cloudmodel
+
+import (
+	"testing"
+
+	onpremisemodel "github.com/cloud-barista/cm-model/infra/on-premise-model"
+)
+
+// recommendedServer returns an Ubuntu 22.04 server with 4 vCPUs and 8 GiB of memory.
+func recommendedServer(machineId, hostname, ip string) onpremisemodel.ServerProperty {
+	return onpremisemodel.ServerProperty{
+		MachineId: machineId,
+		Hostname:  hostname,
+		CPU:       onpremisemodel.CpuProperty{Architecture: "x86_64", Cpus: 1, Cores: 2, Threads: 4},
+		Memory:    onpremisemodel.MemoryProperty{Type: "DDR4", TotalSize: 8},
+		RootDisk:  onpremisemodel.DiskProperty{Label: "/", TotalSize: 50},
+		Interfaces: []onpremisemodel.NetworkInterfaceProperty{
+			{Name: "eth0", IPv4CidrBlocks: []string{ip + "/24"}, State: "UP"},
+		},
+		OS: onpremisemodel.OsProperty{PrettyName: "Ubuntu 22.04.3 LTS", ID: "ubuntu", IDLike: "debian", VersionID: "22.04"},
+	}
+}
+
+var (
+	recommendedCloud = CloudProperty{Csp: "aws", Region: "ap-northeast-2"}
+	recommendedSpecs = []SpecInfo{
+		// A connection name of another case is not the connection of the target
+		{Id: "upper-case", ConnectionName: "AWS-ap-northeast-2", InfraType: "vm", Architecture: "x86_64", VCPU: 4, MemoryGiB: 8},
+		{Id: "aws+ap-northeast-2+t3.xlarge", ConnectionName: "aws-ap-northeast-2", InfraType: "vm", Architecture: "x86_64", VCPU: 4, MemoryGiB: 16},
+	}
+	recommendedImages = []ImageInfo{{
+		Id: "aws+ap-northeast-2+ubuntu22.04", ConnectionName: "aws-ap-northeast-2", OSType: "Ubuntu 22.04",
+		OSArchitecture: X86_64, ImageStatus: ImageAvailable, InfraType: "vm",
+	}}
+)
+
+func TestRecommendVmInfra(t *testing.T) {
+	source := onpremisemodel.OnpremiseInfraModel{OnpremiseInfraModel: onpremisemodel.OnpremInfra{
+		Servers: []onpremisemodel.ServerProperty{
+			recommendedServer("m-1", "localhost", "10.0.0.11"),
+			recommendedServer("m-2", "localhost", "10.0.0.12"),
+			recommendedServer("m-3", "localhost-2", "10.0.0.13"),
+		},
+	}}
+
+	model, err := RecommendVmInfra(source, recommendedCloud, recommendedSpecs, recommendedImages, VmInfraRecommendOptions{NameSeed: "mig"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	infra := model.RecommendedVmInfraModel
+	var names []string
+	for _, subGroup := range infra.TargetVmInfra.SubGroups {
+		names = append(names, subGroup.Name)
+		if subGroup.SpecId != "aws+ap-northeast-2+t3.xlarge" {
+			t.Errorf("subgroup %s: spec %s of another connection", subGroup.Name, subGroup.SpecId)
+		}
+		if subGroup.RootDiskSize != 54 { // 50 GiB
+			t.Errorf("subgroup %s: root disk %d GB, want 54", subGroup.Name, subGroup.RootDiskSize)
+		}
+	}
+	want := []string{"mig-localhost", "mig-localhost-2", "mig-localhost-2-2"}
+	if len(names) != len(want) {
+		t.Fatalf("subgroups = %v, want %v", names, want)
+	}
+	for i := range want {
+		if names[i] != want[i] {
+			t.Errorf("subgroups = %v, want %v", names, want)
+			break
+		}
+	}
+	if err := infra.CheckReferences(); err != nil {
+		t.Errorf("CheckReferences() = %v", err)
+	}
+}
+
+func TestRecommendVmInfraSecurityGroupsOfDuplicateHostnames(t *testing.T) {
+	// Servers without machine IDs sharing a hostname, with different firewall rules
+	web := recommendedServer("", "localhost", "10.0.0.11")
+	web.FirewallTable = []onpremisemodel.FirewallRuleProperty{
+		{SrcCIDR: "0.0.0.0/0", DstPorts: "80,443", Protocol: "tcp", Direction: "inbound", Action: "allow"},
+	}
+	db := recommendedServer("", "localhost", "10.0.0.12")
+	db.FirewallTable = []onpremisemodel.FirewallRuleProperty{
+		{SrcCIDR: "10.0.0.0/24", DstPorts: "5432", Protocol: "tcp", Direction: "inbound", Action: "allow"},
+	}
+	source := onpremisemodel.OnpremiseInfraModel{OnpremiseInfraModel: onpremisemodel.OnpremInfra{
+		Servers: []onpremisemodel.ServerProperty{web, db},
+	}}
+
+	model, err := RecommendVmInfra(source, recommendedCloud, recommendedSpecs, recommendedImages, VmInfraRecommendOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	infra := model.RecommendedVmInfraModel
+	if len(infra.TargetSecurityGroupList) != 2 || len(infra.TargetVmInfra.SubGroups) != 2 {
+		t.Fatalf("%d security groups, %d subgroups, want 2, 2", len(infra.TargetSecurityGroupList), len(infra.TargetVmInfra.SubGroups))
+	}
+	for i, subGroup := range infra.TargetVmInfra.SubGroups {
+		want := infra.TargetSecurityGroupList[i].Name
+		if len(subGroup.SecurityGroupIds) != 1 || subGroup.SecurityGroupIds[0] != want {
+			t.Errorf("subgroup %s: security groups %v, want [%s]", subGroup.Name, subGroup.SecurityGroupIds, want)
+		}
+	}
+}
@@ -0,0 +1,134 @@
+package cloudmodel
+
+import (
+	"strconv"
+	"strings"
+
+	"github.com/cloud-barista/cm-model/validation"
+)
+
+// Rules reported by CheckReferences.
+const (
+	RuleReference  = "reference"  // The referenced resource does not exist in the model
+	RuleConnection = "connection" // The referenced resource belongs to another connection
+	RuleZone       = "zone"       // The zone does not belong to the target region
+)
+
+// CheckReferences checks that every subgroup of TargetVmInfra references the spec, image, vNet, subnet,
+// security groups and SSH key defined in the model, and that they share the subgroup's connection,
+// which must be the connection of the target vNet and of TargetCloud (e.g., aws-ap-northeast-2).
+// It also checks that the zones of the subnets belong to the target region.
+// It returns validation.Errors listing every dangling or mismatched reference with its JSON path, or nil.
+func (r RecommendedVmInfra) CheckReferences() error {
+	const root = "recommendedVmInfraModel"
+	var errs validation.Errors
+
+	specs := make(map[string]SpecInfo)
+	for _, spec := range r.TargetVmSpecList {
+		specs[spec.Id] = spec
+	}
+	images := make(map[string]ImageInfo)
+	for _, image := range r.TargetVmOsImageList {
+		images[image.Id] = image
+	}
+	subnets := make(map[string]bool)
+	for _, subnet := range r.TargetVNet.SubnetInfoList {
+		subnets[subnet.Name] = true
+	}
+	securityGroups := make(map[string]SecurityGroupReq)
+	for _, sg := range r.TargetSecurityGroupList {
+		securityGroups[sg.Name] = sg
+	}
+
+	vnetPath := validation.JoinPath(root, "targetVNet")
+	if cloudConnection := cloudConnectionName(r.TargetCloud); cloudConnection != "" && r.TargetVNet.ConnectionName != cloudConnection {
+		errs.Add(validation.JoinPath(vnetPath, "connectionName"), RuleConnection,
+			"connection %q differs from the connection %q of the target cloud", r.TargetVNet.ConnectionName, cloudConnection)
+	}
+	for i, subnet := range r.TargetVNet.SubnetInfoList {
+		if subnet.Zone != "" && !zoneInRegion(subnet.Zone, r.TargetCloud.Region) {
+			errs.Add(validation.JoinPath(validation.IndexPath(validation.JoinPath(vnetPath, "subnetInfoList"), i), "zone"), RuleZone,
+				"zone %q is not in the region %q", subnet.Zone, r.TargetCloud.Region)
+		}
+	}
+	for i, sg := range r.TargetSecurityGroupList {
+		path := validation.IndexPath(validation.JoinPath(root, "targetSecurityGroupList"), i)
+		if sg.VNetId != "" && sg.VNetId != r.TargetVNet.Name {
+			errs.Add(validation.JoinPath(path, "vNetId"), RuleReference, "vNet %q is not the target vNet", sg.VNetId)
+		}
+		if sg.ConnectionName != r.TargetVNet.ConnectionName {
+			errs.Add(validation.JoinPath(path, "connectionName"), RuleConnection,
+				"connection %q differs from the connection %q of the target vNet", sg.ConnectionName, r.TargetVNet.ConnectionName)
+		}
+	}
+	if r.TargetSshKey.ConnectionName != r.TargetVNet.ConnectionName {
+		errs.Add(validation.JoinPath(validation.JoinPath(root, "targetSshKey"), "connectionName"), RuleConnection,
+			"connection %q differs from the connection %q of the target vNet", r.TargetSshKey.ConnectionName, r.TargetVNet.ConnectionName)
+	}
+
+	for i, subGroup := range r.TargetVmInfra.SubGroups {
+		path := validation.IndexPath(validation.JoinPath(validation.JoinPath(root, "targetVmInfra"), "subGroups"), i)
+		connection := subGroup.ConnectionName
+		mismatch := func(field, kind, id, other string) {
+			errs.Add(validation.JoinPath(path, field), RuleConnection,
+				"%s %q belongs to the connection %q, not %q", kind, id, other, connection)
+		}
+
+		if connection != r.TargetVNet.ConnectionName {
+			errs.Add(validation.JoinPath(path, "connectionName"), RuleConnection,
+				"connection %q differs from the connection %q of the target vNet", connection, r.TargetVNet.ConnectionName)
+		}
+
+		if spec, ok := specs[subGroup.SpecId]; !ok {
+			errs.Add(validation.JoinPath(path, "specId"), RuleReference, "spec %q is not in the target spec list", subGroup.SpecId)
+		} else if spec.ConnectionName != "" && spec.ConnectionName != connection {
+			mismatch("specId", "spec", spec.Id, spec.ConnectionName)
+		}
+
+		if image, ok := images[subGroup.ImageId]; !ok {
+			errs.Add(validation.JoinPath(path, "imageId"), RuleReference, "image %q is not in the target OS image list", subGroup.ImageId)
+		} else if image.ConnectionName != "" && image.ConnectionName != connection {
+			mismatch("imageId", "image", image.Id, image.ConnectionName)
+		} else if image.ConnectionName == "" && len(image.RegionList) > 0 && !containsFold(image.RegionList, r.TargetCloud.Region) {
+			errs.Add(validation.JoinPath(path, "imageId"), RuleConnection,
+				"image %q is not available in the region %q", image.Id, r.TargetCloud.Region)
+		}
+
+		if subGroup.VNetId != r.TargetVNet.Name {
+			errs.Add(validation.JoinPath(path, "vNetId"), RuleReference, "vNet %q is not the target vNet", subGroup.VNetId)
+		}
+		if !subnets[subGroup.SubnetId] {
+			errs.Add(validation.JoinPath(path, "subnetId"), RuleReference, "subnet %q is not in the target vNet", subGroup.SubnetId)
+		}
+
+		for j, id := range subGroup.SecurityGroupIds {
+			sgPath := validation.IndexPath(validation.JoinPath(path, "securityGroupIds"), j)
+			if sg, ok := securityGroups[id]; !ok {
+				errs.Add(sgPath, RuleReference, "security group %q is not in the target security group list", id)
+			} else if sg.ConnectionName != connection {
+				errs.Add(sgPath, RuleConnection, "security group %q belongs to the connection %q, not %q", id, sg.ConnectionName, connection)
+			}
+		}
+
+		if subGroup.SshKeyId != r.TargetSshKey.Name {
+			errs.Add(validation.JoinPath(path, "sshKeyId"), RuleReference, "SSH key %q is not the target SSH key", subGroup.SshKeyId)
+		} else if r.TargetSshKey.ConnectionName != connection {
+			mismatch("sshKeyId", "SSH key", subGroup.SshKeyId, r.TargetSshKey.ConnectionName)
+		}
+	}
+
+	return errs.Err()
+}
+
+// zoneInRegion reports whether the zone belongs to the region.
+// Zones are named with the region as a prefix (e.g., ap-northeast-2a, asia-northeast3-a),
+// except numeric zones (e.g., Azure's 1, 2, 3) which are allowed in any region.
+func zoneInRegion(zone, region string) bool {
+	if region == "" {
+		return true
+	}
+	if _, err := strconv.Atoi(zone); err == nil {
+		return true
+	}
+	return strings.HasPrefix(strings.ToLower(zone), strings.ToLower(region))
+}
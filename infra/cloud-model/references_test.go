@@ -0,0 +1,173 @@
+package cloudmodel
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+
+	"github.com/cloud-barista/cm-model/validation"
+)
+
+// referencedInfra returns a recommended VM infrastructure with a subgroup referencing every resource.
+func referencedInfra() RecommendedVmInfra {
+	const connection = "aws-ap-northeast-2"
+	return RecommendedVmInfra{
+		TargetCloud: CloudProperty{Csp: "AWS", Region: "ap-northeast-2"},
+		TargetVmInfra: MciReq{
+			Name: "mig",
+			SubGroups: []CreateSubGroupReq{{
+				Name:             "mig-web01",
+				ConnectionName:   connection,
+				SpecId:           "aws+ap-northeast-2+t3.xlarge",
+				ImageId:          "aws+ap-northeast-2+ubuntu22.04",
+				VNetId:           "mig-vnet-01",
+				SubnetId:         "mig-subnet-01",
+				SecurityGroupIds: []string{"mig-sg-01"},
+				SshKeyId:         "mig-sshkey-01",
+			}},
+		},
+		TargetVNet: VNetReq{
+			Name:           "mig-vnet-01",
+			ConnectionName: connection,
+			CidrBlock:      "10.0.0.0/16",
+			SubnetInfoList: []SubnetReq{{Name: "mig-subnet-01", IPv4_CIDR: "10.0.1.0/24", Zone: "ap-northeast-2a"}},
+		},
+		TargetSshKey:            SshKeyReq{Name: "mig-sshkey-01", ConnectionName: connection},
+		TargetVmSpecList:        []SpecInfo{{Id: "aws+ap-northeast-2+t3.xlarge", ConnectionName: connection}},
+		TargetVmOsImageList:     []ImageInfo{{Id: "aws+ap-northeast-2+ubuntu22.04", ConnectionName: connection}},
+		TargetSecurityGroupList: []SecurityGroupReq{{Name: "mig-sg-01", ConnectionName: connection, VNetId: "mig-vnet-01"}},
+	}
+}
+
+func TestCheckReferences(t *testing.T) {
+	const subGroup = "recommendedVmInfraModel.targetVmInfra.subGroups[0]"
+	tests := []struct {
+		name   string
+		modify func(r *RecommendedVmInfra)
+		want   []validation.FieldError // Paths and rules only
+	}{
+		{
+			name:   "valid",
+			modify: func(r *RecommendedVmInfra) {},
+		},
+		{
+			name:   "spec",
+			modify: func(r *RecommendedVmInfra) { r.TargetVmInfra.SubGroups[0].SpecId = "aws+ap-northeast-2+t3.small" },
+			want:   []validation.FieldError{{Path: subGroup + ".specId", Rule: RuleReference}},
+		},
+		{
+			name:   "spec of another connection",
+			modify: func(r *RecommendedVmInfra) { r.TargetVmSpecList[0].ConnectionName = "aws-us-east-1" },
+			want:   []validation.FieldError{{Path: subGroup + ".specId", Rule: RuleConnection}},
+		},
+		{
+			name:   "image",
+			modify: func(r *RecommendedVmInfra) { r.TargetVmInfra.SubGroups[0].ImageId = "" },
+			want:   []validation.FieldError{{Path: subGroup + ".imageId", Rule: RuleReference}},
+		},
+		{
+			name:   "image of another connection",
+			modify: func(r *RecommendedVmInfra) { r.TargetVmOsImageList[0].ConnectionName = "gcp-asia-northeast3" },
+			want:   []validation.FieldError{{Path: subGroup + ".imageId", Rule: RuleConnection}},
+		},
+		{
+			name: "image of another region",
+			modify: func(r *RecommendedVmInfra) {
+				r.TargetVmOsImageList[0].ConnectionName = ""
+				r.TargetVmOsImageList[0].RegionList = []string{"us-east-1"}
+			},
+			want: []validation.FieldError{{Path: subGroup + ".imageId", Rule: RuleConnection}},
+		},
+		{
+			name:   "vNet",
+			modify: func(r *RecommendedVmInfra) { r.TargetVmInfra.SubGroups[0].VNetId = "vnet-01" },
+			want:   []validation.FieldError{{Path: subGroup + ".vNetId", Rule: RuleReference}},
+		},
+		{
+			name:   "subnet",
+			modify: func(r *RecommendedVmInfra) { r.TargetVmInfra.SubGroups[0].SubnetId = "mig-subnet-02" },
+			want:   []validation.FieldError{{Path: subGroup + ".subnetId", Rule: RuleReference}},
+		},
+		{
+			name: "security group",
+			modify: func(r *RecommendedVmInfra) {
+				r.TargetVmInfra.SubGroups[0].SecurityGroupIds = []string{"mig-sg-01", "mig-sg-02"}
+			},
+			want: []validation.FieldError{{Path: subGroup + ".securityGroupIds[1]", Rule: RuleReference}},
+		},
+		{
+			name:   "SSH key",
+			modify: func(r *RecommendedVmInfra) { r.TargetVmInfra.SubGroups[0].SshKeyId = "sshkey-01" },
+			want:   []validation.FieldError{{Path: subGroup + ".sshKeyId", Rule: RuleReference}},
+		},
+		{
+			name:   "subgroup of another connection",
+			modify: func(r *RecommendedVmInfra) { r.TargetVmInfra.SubGroups[0].ConnectionName = "aws-us-east-1" },
+			want: []validation.FieldError{
+				{Path: subGroup + ".connectionName", Rule: RuleConnection},
+				{Path: subGroup + ".specId", Rule: RuleConnection},
+				{Path: subGroup + ".imageId", Rule: RuleConnection},
+				{Path: subGroup + ".securityGroupIds[0]", Rule: RuleConnection},
+				{Path: subGroup + ".sshKeyId", Rule: RuleConnection},
+			},
+		},
+		{
+			name: "resources of a connection other than the target cloud",
+			modify: func(r *RecommendedVmInfra) {
+				r.TargetCloud = CloudProperty{Csp: "aws", Region: "us-east-1"}
+				r.TargetVNet.SubnetInfoList[0].Zone = "us-east-1a"
+			},
+			want: []validation.FieldError{{Path: "recommendedVmInfraModel.targetVNet.connectionName", Rule: RuleConnection}},
+		},
+		{
+			name: "security group of another vNet and connection",
+			modify: func(r *RecommendedVmInfra) {
+				r.TargetSecurityGroupList[0].VNetId = "vnet-01"
+				r.TargetSecurityGroupList[0].ConnectionName = "aws-us-east-1"
+			},
+			want: []validation.FieldError{
+				{Path: "recommendedVmInfraModel.targetSecurityGroupList[0].vNetId", Rule: RuleReference},
+				{Path: "recommendedVmInfraModel.targetSecurityGroupList[0].connectionName", Rule: RuleConnection},
+				{Path: subGroup + ".securityGroupIds[0]", Rule: RuleConnection},
+			},
+		},
+		{
+			name:   "SSH key of another connection",
+			modify: func(r *RecommendedVmInfra) { r.TargetSshKey.ConnectionName = "aws-us-east-1" },
+			want: []validation.FieldError{
+				{Path: "recommendedVmInfraModel.targetSshKey.connectionName", Rule: RuleConnection},
+				{Path: subGroup + ".sshKeyId", Rule: RuleConnection},
+			},
+		},
+		{
+			name:   "zone of another region",
+			modify: func(r *RecommendedVmInfra) { r.TargetVNet.SubnetInfoList[0].Zone = "us-east-1a" },
+			want:   []validation.FieldError{{Path: "recommendedVmInfraModel.targetVNet.subnetInfoList[0].zone", Rule: RuleZone}},
+		},
+		{
+			name:   "numeric zone",
+			modify: func(r *RecommendedVmInfra) { r.TargetVNet.SubnetInfoList[0].Zone = "2" },
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			infra := referencedInfra()
+			tt.modify(&infra)
+
+			err := infra.CheckReferences()
+			var got []validation.FieldError
+			var errs validation.Errors
+			if errors.As(err, &errs) {
+				for _, e := range errs {
+					got = append(got, validation.FieldError{Path: e.Path, Rule: e.Rule})
+				}
+			} else if err != nil {
+				t.Fatalf("CheckReferences() = %v, want validation.Errors", err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("CheckReferences() = %v\nwant %v", got, tt.want)
+			}
+		})
+	}
+}
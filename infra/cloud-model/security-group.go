@@ -0,0 +1,346 @@
+package cloudmodel
+
+import (
+	"fmt"
+	"net/netip"
+	"sort"
+	"strings"
+
+	onpremisemodel "github.com/cloud-barista/cm-model/infra/on-premise-model"
+)
+
+// Protocols supported by FirewallRuleReq.
+var firewallProtocols = []string{"TCP", "UDP", "ICMP"}
+
+// allPortsRange is the port expression of FirewallRuleReq for all ports.
+const allPortsRange = "1-65535"
+
+// RecommendSecurityGroups converts the firewall tables of the servers into security groups.
+//   - Allow rules are merged by direction, protocol and CIDR into port lists (e.g., "22,900-1000").
+//   - Servers with an identical rule set share a security group, listed in SourceServers (machine IDs, or hostnames if empty).
+//   - Rules which cannot be expressed in an allow-only security group (e.g., deny rules, source ports) are listed in Warnings.
+//     Allow rules overlapping an earlier deny rule are left out, so that the security group never allows more than the source.
+//   - Servers without firewall rules get all outbound traffic allowed and inbound SSH from their own network
+//     (the CIDR blocks of the network containing their interface addresses, see onpremisemodel.DeriveNetworkProperty),
+//     as noted in the Description. Without such a block, no inbound rule is recommended and a warning is given.
+//
+// Names of the security groups are sequential (e.g., sg-01); connection name and vNet ID are left empty
+// so that the caller can bind them to the target cloud.
+func RecommendSecurityGroups(infra onpremisemodel.OnpremInfra) RecommendedSecurityGroupList {
+	list, _ := securityGroupsOf(infra)
+	return list
+}
+
+// securityGroupsOf recommends the security groups as RecommendSecurityGroups does, and also returns
+// the index of the group of each server, since servers may share a machine ID or hostname.
+func securityGroupsOf(infra onpremisemodel.OnpremInfra) (RecommendedSecurityGroupList, []int) {
+	var groups []RecommendedSecurityGroup
+	groupOf := make([]int, len(infra.Servers))
+	index := make(map[string]int)   // Rule set signature -> index of the group
+	defaulted := make(map[int]bool) // Indexes of the groups with the default rules of servers without firewall rules
+	network := onpremisemodel.DeriveNetworkProperty(infra.Network, infra.Servers)
+
+	for n, server := range infra.Servers {
+		serverId := server.MachineId
+		if serverId == "" {
+			serverId = server.Hostname
+		}
+
+		var rules []FirewallRuleReq
+		var warnings []string
+		if len(server.FirewallTable) == 0 {
+			rules, warnings = defaultFirewallRules(serverNetworkBlocks(server, network.IPv4Networks.CidrBlocks))
+		} else {
+			rules, warnings = convertFirewallTable(server.FirewallTable)
+		}
+		signature := rulesSignature(rules)
+		if i, exists := index[signature]; exists {
+			groupOf[n] = i
+			groups[i].SourceServers = append(groups[i].SourceServers, serverId)
+			groups[i].Warnings = appendUnique(groups[i].Warnings, warnings...)
+			defaulted[i] = defaulted[i] || len(server.FirewallTable) == 0
+			continue
+		}
+
+		index[signature] = len(groups)
+		groupOf[n] = len(groups)
+		defaulted[len(groups)] = len(server.FirewallTable) == 0
+		name := fmt.Sprintf("sg-%02d", len(groups)+1)
+		groups = append(groups, RecommendedSecurityGroup{
+			SourceServers: []string{serverId},
+			Warnings:      warnings,
+			TargetSecurityGroup: SecurityGroupReq{
+				Name:          name,
+				Description:   "Recommended security group converted from the source firewall rules",
+				FirewallRules: &rules,
+			},
+		})
+	}
+
+	list := RecommendedSecurityGroupList{
+		Status:                  StatusRecommended,
+		Count:                   len(groups),
+		TargetSecurityGroupList: groups,
+	}
+	for i := range list.TargetSecurityGroupList {
+		group := &list.TargetSecurityGroupList[i]
+		group.Status = StatusRecommended
+		group.Description = fmt.Sprintf("Security group for %d server(s) with %d rule(s)", len(group.SourceServers), len(*group.TargetSecurityGroup.FirewallRules))
+		if defaulted[i] {
+			group.Description += "; no source firewall rule, so all outbound traffic is allowed"
+			var sshCidrs []string
+			for _, rule := range *group.TargetSecurityGroup.FirewallRules {
+				if rule.Direction == "inbound" {
+					sshCidrs = append(sshCidrs, rule.CIDR)
+				}
+			}
+			if len(sshCidrs) > 0 {
+				group.Description += " with inbound SSH from the source network " + strings.Join(sshCidrs, ", ")
+			}
+		}
+		if len(group.Warnings) > 0 {
+			group.Status = StatusPartiallyRecommended
+			group.Description += fmt.Sprintf("; %d warning(s)", len(group.Warnings))
+			list.Status = StatusPartiallyRecommended
+		}
+	}
+	if len(groups) == 0 {
+		list.Status = StatusNotRecommended
+	}
+	list.Description = fmt.Sprintf("%d security group(s) recommended for %d server(s)", len(groups), len(infra.Servers))
+	return list, groupOf
+}
+
+// defaultFirewallRules returns the rules of a server without firewall rules:
+// inbound SSH from the given CIDR blocks of its network, and all outbound traffic.
+func defaultFirewallRules(sshCidrs []string) ([]FirewallRuleReq, []string) {
+	var rules []FirewallRuleReq
+	for _, cidr := range sshCidrs {
+		rules = append(rules, FirewallRuleReq{Ports: "22", Protocol: "TCP", Direction: "inbound", CIDR: cidr})
+	}
+	rules = append(rules,
+		FirewallRuleReq{Ports: allPortsRange, Protocol: "TCP", Direction: "outbound", CIDR: "0.0.0.0/0"},
+		FirewallRuleReq{Ports: allPortsRange, Protocol: "UDP", Direction: "outbound", CIDR: "0.0.0.0/0"},
+		FirewallRuleReq{Protocol: "ICMP", Direction: "outbound", CIDR: "0.0.0.0/0"},
+	)
+	if len(sshCidrs) == 0 {
+		return rules, []string{"no source firewall rule and no network of the server is known, so no inbound rule is recommended"}
+	}
+	return rules, nil
+}
+
+// serverNetworkBlocks returns the CIDR blocks containing an IPv4 interface address of the server.
+func serverNetworkBlocks(server onpremisemodel.ServerProperty, blocks []string) []string {
+	var matched []string
+	for _, block := range blocks {
+		prefix, err := netip.ParsePrefix(block)
+		if err != nil || !prefix.Addr().Is4() {
+			continue
+		}
+		for _, iface := range server.Interfaces {
+			if containsAddress(prefix, iface.IPv4CidrBlocks) {
+				matched = appendUnique(matched, prefix.Masked().String())
+				break
+			}
+		}
+	}
+	return matched
+}
+
+func containsAddress(prefix netip.Prefix, cidrBlocks []string) bool {
+	for _, cidr := range cidrBlocks {
+		if addr, err := netip.ParsePrefix(cidr); err == nil && !addr.Addr().IsLoopback() && prefix.Contains(addr.Addr()) {
+			return true
+		}
+	}
+	return false
+}
+
+// convertFirewallTable converts the allow rules of a firewall table into merged security group rules
+// and describes the rules which cannot be expressed. Allow rules overlapping an earlier deny rule are left out,
+// since the security group cannot express the deny rule and would allow its packets.
+func convertFirewallTable(table []onpremisemodel.FirewallRuleProperty) ([]FirewallRuleReq, []string) {
+	type ruleKey struct{ direction, protocol, cidr string }
+	ports := make(map[ruleKey][]onpremisemodel.PortRange)
+	var keys []ruleKey
+	var warnings []string
+	closed := make(map[string]bool) // Directions closed by a deny-all rule
+	denied := make(map[int]bool)    // Indexes of the deny rules not expressed
+
+	for i, rule := range table {
+		describe := func(reason string) string {
+			return fmt.Sprintf("rule #%d (%s %s %s src=%s:%s dst=%s:%s): %s",
+				i+1, rule.Action, rule.Direction, rule.Protocol, rule.SrcCIDR, rule.SrcPorts, rule.DstCIDR, rule.DstPorts, reason)
+		}
+
+		direction := strings.ToLower(rule.Direction)
+		if direction != "inbound" && direction != "outbound" {
+			warnings = append(warnings, describe("unknown direction"))
+			continue
+		}
+		if closed[direction] {
+			// Unreachable after a deny-all rule
+			continue
+		}
+		if !strings.EqualFold(rule.Action, "allow") {
+			// A deny-all rule is the default behavior of security groups
+			if isCatchAll(rule) {
+				closed[direction] = true
+				continue
+			}
+			warnings = append(warnings, describe("deny rules cannot be expressed in an allow-only security group"))
+			denied[i] = true
+			continue
+		}
+		if shadowing := firstOverlapping(table, denied, rule); shadowing >= 0 {
+			warnings = append(warnings, describe(fmt.Sprintf("the rule overlaps the deny rule #%d, so it is left out not to allow the denied traffic", shadowing+1)))
+			continue
+		}
+
+		cidr := rule.SrcCIDR
+		if direction == "outbound" {
+			cidr = rule.DstCIDR
+		}
+		if cidr == "" {
+			cidr = "0.0.0.0/0"
+		}
+		prefix, err := netip.ParsePrefix(cidr)
+		if err != nil {
+			warnings = append(warnings, describe("invalid CIDR block"))
+			continue
+		}
+		cidr = prefix.Masked().String()
+
+		if rule.SrcPorts != "" && rule.SrcPorts != onpremisemodel.AllPortsExpr {
+			warnings = append(warnings, describe("source ports are ignored, so the rule is widened to any source port"))
+		}
+		dstPorts, err := onpremisemodel.ParsePorts(rule.DstPorts)
+		if err != nil {
+			warnings = append(warnings, describe(err.Error()))
+			continue
+		}
+		if len(dstPorts) == 0 {
+			dstPorts = []onpremisemodel.PortRange{onpremisemodel.AllPorts}
+		}
+
+		protocols := []string{strings.ToUpper(rule.Protocol)}
+		if rule.Protocol == "" || rule.Protocol == "*" || strings.EqualFold(rule.Protocol, "all") {
+			protocols = firewallProtocols
+		}
+		for _, protocol := range protocols {
+			if !contains(firewallProtocols, protocol) {
+				warnings = append(warnings, describe(fmt.Sprintf("protocol %s is not supported", protocol)))
+				continue
+			}
+			key := ruleKey{direction: direction, protocol: protocol, cidr: cidr}
+			if _, exists := ports[key]; !exists {
+				keys = append(keys, key)
+			}
+			ports[key] = append(ports[key], dstPorts...)
+		}
+	}
+
+	sort.Slice(keys, func(i, j int) bool {
+		a, b := keys[i], keys[j]
+		if a.direction != b.direction {
+			return a.direction < b.direction
+		}
+		if a.protocol != b.protocol {
+			return a.protocol < b.protocol
+		}
+		return a.cidr < b.cidr
+	})
+
+	rules := make([]FirewallRuleReq, 0, len(keys))
+	for _, key := range keys {
+		rule := FirewallRuleReq{Protocol: key.protocol, Direction: key.direction, CIDR: key.cidr}
+		if key.protocol != "ICMP" {
+			rule.Ports = onpremisemodel.FormatPorts(ports[key])
+			if rule.Ports == onpremisemodel.AllPortsExpr {
+				rule.Ports = allPortsRange
+			}
+		}
+		rules = append(rules, rule)
+	}
+	return rules, warnings
+}
+
+// firstOverlapping returns the index of the first of the denied rules matching a packet of the rule, or -1.
+func firstOverlapping(table []onpremisemodel.FirewallRuleProperty, denied map[int]bool, rule onpremisemodel.FirewallRuleProperty) int {
+	for i, deny := range table {
+		if denied[i] && overlapsFirewallRule(deny, rule) {
+			return i
+		}
+	}
+	return -1
+}
+
+func overlapsFirewallRule(a, b onpremisemodel.FirewallRuleProperty) bool {
+	return strings.EqualFold(a.Direction, b.Direction) &&
+		overlapsCidr(a.SrcCIDR, b.SrcCIDR) && overlapsCidr(a.DstCIDR, b.DstCIDR) &&
+		overlapsProtocol(a.Protocol, b.Protocol) &&
+		overlapsPorts(a.SrcPorts, b.SrcPorts) && overlapsPorts(a.DstPorts, b.DstPorts)
+}
+
+// overlapsCidr returns whether two CIDR blocks share an address. An empty or invalid block may match any address.
+func overlapsCidr(a, b string) bool {
+	pa, errA := netip.ParsePrefix(a)
+	pb, errB := netip.ParsePrefix(b)
+	if errA != nil || errB != nil || pa.Bits() == 0 || pb.Bits() == 0 {
+		return true
+	}
+	return pa.Overlaps(pb)
+}
+
+func overlapsProtocol(a, b string) bool {
+	isAny := func(s string) bool { return s == "" || s == "*" || strings.EqualFold(s, "all") }
+	return isAny(a) || isAny(b) || strings.EqualFold(a, b)
+}
+
+// overlapsPorts returns whether two port expressions share a port. An empty or invalid expression may match any port.
+func overlapsPorts(a, b string) bool {
+	ra, errA := onpremisemodel.ParsePorts(a)
+	rb, errB := onpremisemodel.ParsePorts(b)
+	if errA != nil || errB != nil || len(ra) == 0 || len(rb) == 0 {
+		return true
+	}
+	for _, x := range ra {
+		for _, y := range rb {
+			if x.From <= y.To && y.From <= x.To {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+func isCatchAll(rule onpremisemodel.FirewallRuleProperty) bool {
+	isAny := func(s string) bool { return s == "" || s == "*" || s == "0.0.0.0/0" || s == "::/0" }
+	return isAny(rule.SrcCIDR) && isAny(rule.DstCIDR) && isAny(rule.SrcPorts) && isAny(rule.DstPorts) && isAny(rule.Protocol)
+}
+
+func rulesSignature(rules []FirewallRuleReq) string {
+	var sb strings.Builder
+	for _, r := range rules {
+		fmt.Fprintf(&sb, "%s|%s|%s|%s;", r.Direction, r.Protocol, r.CIDR, r.Ports)
+	}
+	return sb.String()
+}
+
+func appendUnique(list []string, items ...string) []string {
+	for _, item := range items {
+		if !contains(list, item) {
+			list = append(list, item)
+		}
+	}
+	return list
+}
+
+func contains(list []string, s string) bool {
+	for _, item := range list {
+		if item == s {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,195 @@
+package cloudmodel
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	onpremisemodel "github.com/cloud-barista/cm-model/infra/on-premise-model"
+)
+
+// outboundDefaults are the outbound rules of a server without firewall rules.
+var outboundDefaults = []FirewallRuleReq{
+	{Ports: allPortsRange, Protocol: "TCP", Direction: "outbound", CIDR: "0.0.0.0/0"},
+	{Ports: allPortsRange, Protocol: "UDP", Direction: "outbound", CIDR: "0.0.0.0/0"},
+	{Protocol: "ICMP", Direction: "outbound", CIDR: "0.0.0.0/0"},
+}
+
+func TestConvertFirewallTable(t *testing.T) {
+	allow := func(direction, src, dst, protocol, dstPorts string) onpremisemodel.FirewallRuleProperty {
+		return onpremisemodel.FirewallRuleProperty{SrcCIDR: src, DstCIDR: dst, Protocol: protocol, DstPorts: dstPorts, Direction: direction, Action: "allow"}
+	}
+	deny := func(direction, src, dst, protocol, dstPorts string) onpremisemodel.FirewallRuleProperty {
+		rule := allow(direction, src, dst, protocol, dstPorts)
+		rule.Action = "deny"
+		return rule
+	}
+	tests := []struct {
+		name         string
+		table        []onpremisemodel.FirewallRuleProperty
+		want         []FirewallRuleReq
+		wantWarnings []string // Substrings of the warnings, in order
+	}{
+		{
+			name: "merged allow rules",
+			table: []onpremisemodel.FirewallRuleProperty{
+				allow("inbound", "10.0.0.0/16", "", "tcp", "22"),
+				allow("inbound", "10.0.1.5/16", "", "TCP", "900-1000"),
+				allow("Outbound", "", "", "*", "*"),
+			},
+			want: []FirewallRuleReq{
+				{Ports: "22,900-1000", Protocol: "TCP", Direction: "inbound", CIDR: "10.0.0.0/16"},
+				{Protocol: "ICMP", Direction: "outbound", CIDR: "0.0.0.0/0"},
+				{Ports: allPortsRange, Protocol: "TCP", Direction: "outbound", CIDR: "0.0.0.0/0"},
+				{Ports: allPortsRange, Protocol: "UDP", Direction: "outbound", CIDR: "0.0.0.0/0"},
+			},
+		},
+		{
+			name: "deny-all closing the direction",
+			table: []onpremisemodel.FirewallRuleProperty{
+				allow("inbound", "0.0.0.0/0", "", "tcp", "22"),
+				deny("inbound", "", "", "*", "*"),
+				allow("inbound", "0.0.0.0/0", "", "tcp", "80"),
+			},
+			want: []FirewallRuleReq{{Ports: "22", Protocol: "TCP", Direction: "inbound", CIDR: "0.0.0.0/0"}},
+		},
+		{
+			name: "deny rule shadowing later allow rules",
+			table: []onpremisemodel.FirewallRuleProperty{
+				allow("inbound", "10.0.0.0/16", "", "tcp", "22"), // Before the deny rule: kept
+				deny("inbound", "192.168.1.0/24", "", "tcp", "*"),
+				allow("inbound", "0.0.0.0/0", "", "tcp", "80"),     // Overlapping the source of the deny rule
+				allow("inbound", "192.168.0.0/16", "", "*", "443"), // Overlapping through the protocol
+			},
+			want: []FirewallRuleReq{{Ports: "22", Protocol: "TCP", Direction: "inbound", CIDR: "10.0.0.0/16"}},
+			wantWarnings: []string{
+				"rule #2 (deny inbound tcp src=192.168.1.0/24", "deny rules cannot be expressed",
+				"rule #3", "overlaps the deny rule #2",
+				"rule #4", "overlaps the deny rule #2",
+			},
+		},
+		{
+			name: "deny rule not overlapping later allow rules",
+			table: []onpremisemodel.FirewallRuleProperty{
+				deny("inbound", "192.168.1.0/24", "", "tcp", "22"),
+				allow("inbound", "10.0.0.0/16", "", "tcp", "22"), // Another source
+				allow("inbound", "0.0.0.0/0", "", "tcp", "80"),   // Another port
+				allow("inbound", "0.0.0.0/0", "", "udp", "22"),   // Another protocol
+				allow("outbound", "", "0.0.0.0/0", "tcp", "22"),  // Another direction
+			},
+			want: []FirewallRuleReq{
+				{Ports: "80", Protocol: "TCP", Direction: "inbound", CIDR: "0.0.0.0/0"},
+				{Ports: "22", Protocol: "TCP", Direction: "inbound", CIDR: "10.0.0.0/16"},
+				{Ports: "22", Protocol: "UDP", Direction: "inbound", CIDR: "0.0.0.0/0"},
+				{Ports: "22", Protocol: "TCP", Direction: "outbound", CIDR: "0.0.0.0/0"},
+			},
+			wantWarnings: []string{"rule #1", "deny rules cannot be expressed"},
+		},
+		{
+			name: "inexpressible rules",
+			table: []onpremisemodel.FirewallRuleProperty{
+				allow("forward", "", "", "tcp", "22"),
+				allow("inbound", "10.0.0.0/33", "", "tcp", "22"),
+				allow("inbound", "", "", "sctp", "22"),
+				allow("inbound", "", "", "tcp", "443-80"),
+				{SrcPorts: "1024:65535", DstPorts: "53", Protocol: "udp", Direction: "inbound", Action: "allow"},
+			},
+			want: []FirewallRuleReq{{Ports: "53", Protocol: "UDP", Direction: "inbound", CIDR: "0.0.0.0/0"}},
+			wantWarnings: []string{
+				"rule #1", "unknown direction",
+				"rule #2", "invalid CIDR block",
+				"rule #3", "protocol SCTP is not supported",
+				"rule #4", "reversed",
+				"rule #5", "source ports are ignored",
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, warnings := convertFirewallTable(tt.table)
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("rules = %+v\nwant %+v", got, tt.want)
+			}
+			joined := strings.Join(warnings, "\n")
+			rest := joined
+			for _, want := range tt.wantWarnings {
+				i := strings.Index(rest, want)
+				if i < 0 {
+					t.Errorf("warnings %q: no %q in order", joined, want)
+					break
+				}
+				rest = rest[i+len(want):]
+			}
+			if len(tt.wantWarnings) == 0 && len(warnings) > 0 {
+				t.Errorf("warnings = %q, want none", warnings)
+			}
+		})
+	}
+}
+
+func TestRecommendSecurityGroups(t *testing.T) {
+	server := func(machineId string, cidrs ...string) onpremisemodel.ServerProperty {
+		return onpremisemodel.ServerProperty{
+			MachineId: machineId,
+			Interfaces: []onpremisemodel.NetworkInterfaceProperty{
+				{Name: "lo", IPv4CidrBlocks: []string{"127.0.0.1/8"}},
+				{Name: "eth0", IPv4CidrBlocks: cidrs},
+			},
+		}
+	}
+	web := server("m-web", "10.0.1.10/24")
+	web.FirewallTable = []onpremisemodel.FirewallRuleProperty{
+		{SrcCIDR: "0.0.0.0/0", DstPorts: "80,443", Protocol: "tcp", Direction: "inbound", Action: "allow"},
+	}
+
+	infra := onpremisemodel.OnpremInfra{
+		Network: onpremisemodel.NetworkProperty{IPv4Networks: onpremisemodel.NetworkDetail{CidrBlocks: []string{"10.0.0.0/16", "192.168.0.0/24"}}},
+		Servers: []onpremisemodel.ServerProperty{
+			web,
+			server("m-app1", "10.0.2.10/24"),
+			server("m-app2", "10.0.3.10/24"), // The same network as m-app1
+			server("m-isolated"),             // Only the loopback interface
+		},
+	}
+	list := RecommendSecurityGroups(infra)
+
+	if list.Status != StatusPartiallyRecommended || list.Count != 3 {
+		t.Fatalf("status %s, count %d, want %s, 3", list.Status, list.Count, StatusPartiallyRecommended)
+	}
+	groups := list.TargetSecurityGroupList
+
+	if !reflect.DeepEqual(groups[0].SourceServers, []string{"m-web"}) || groups[0].Status != StatusRecommended {
+		t.Errorf("group 0: servers %v, status %s", groups[0].SourceServers, groups[0].Status)
+	}
+
+	// Without firewall rules, SSH is allowed only from the network of the servers, never from anywhere
+	wantApp := append([]FirewallRuleReq{{Ports: "22", Protocol: "TCP", Direction: "inbound", CIDR: "10.0.0.0/16"}}, outboundDefaults...)
+	if got := *groups[1].TargetSecurityGroup.FirewallRules; !reflect.DeepEqual(got, wantApp) {
+		t.Errorf("group 1: rules %+v\nwant %+v", got, wantApp)
+	}
+	if !reflect.DeepEqual(groups[1].SourceServers, []string{"m-app1", "m-app2"}) || groups[1].Status != StatusRecommended {
+		t.Errorf("group 1: servers %v, status %s", groups[1].SourceServers, groups[1].Status)
+	}
+	if !strings.Contains(groups[1].Description, "inbound SSH from the source network 10.0.0.0/16") {
+		t.Errorf("group 1: description %q", groups[1].Description)
+	}
+
+	// Without a known network, no inbound rule at all
+	if got := *groups[2].TargetSecurityGroup.FirewallRules; !reflect.DeepEqual(got, outboundDefaults) {
+		t.Errorf("group 2: rules %+v\nwant %+v", got, outboundDefaults)
+	}
+	if groups[2].Status != StatusPartiallyRecommended || len(groups[2].Warnings) != 1 || !strings.Contains(groups[2].Warnings[0], "no inbound rule") {
+		t.Errorf("group 2: status %s, warnings %q", groups[2].Status, groups[2].Warnings)
+	}
+	if strings.Contains(groups[2].Description, "SSH") {
+		t.Errorf("group 2: description %q", groups[2].Description)
+	}
+}
+
+func TestRecommendSecurityGroupsWithoutServers(t *testing.T) {
+	list := RecommendSecurityGroups(onpremisemodel.OnpremInfra{})
+	if list.Status != StatusNotRecommended || list.Count != 0 {
+		t.Errorf("status %s, count %d, want %s, 0", list.Status, list.Count, StatusNotRecommended)
+	}
+}
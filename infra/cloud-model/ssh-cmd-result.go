@@ -0,0 +1,126 @@
+package cloudmodel
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+)
+
+// SshCmdErrorKind is the kind of an SshCmdError.
+type SshCmdErrorKind string
+
+const (
+	SshCmdErrorExit    SshCmdErrorKind = "exit"    // The command exited with a non-zero status
+	SshCmdErrorTimeout SshCmdErrorKind = "timeout" // The command or the connection timed out
+	SshCmdErrorFailed  SshCmdErrorKind = "error"   // The command could not be run (e.g., SSH connection failure)
+	SshCmdErrorUnknown SshCmdErrorKind = "unknown" // The reason was lost (e.g., CB-Tumblebug encoded the error as {})
+)
+
+// SshCmdError is the JSON-safe error of an SshCmdResult.
+type SshCmdError struct {
+	Message  string          `json:"message"`
+	Kind     SshCmdErrorKind `json:"kind"`
+	ExitCode int             `json:"exitCode,omitempty"` // Exit status of the command if Kind is "exit"
+}
+
+func (e *SshCmdError) Error() string {
+	msg := e.Message
+	if msg == "" {
+		msg = "unknown error"
+	}
+	if e.Kind == SshCmdErrorExit {
+		return fmt.Sprintf("%s (exit code %d)", msg, e.ExitCode)
+	}
+	return msg
+}
+
+// NewSshCmdError converts err to an SshCmdError, or returns nil if err is nil.
+// The exit code is taken from errors with an ExitStatus method, such as *ssh.ExitError of golang.org/x/crypto/ssh.
+func NewSshCmdError(err error) *SshCmdError {
+	if err == nil {
+		return nil
+	}
+	var cmdErr *SshCmdError
+	if errors.As(err, &cmdErr) {
+		return cmdErr
+	}
+
+	e := &SshCmdError{Message: err.Error(), Kind: SshCmdErrorFailed}
+	var exitErr interface{ ExitStatus() int }
+	switch {
+	case errors.As(err, &exitErr):
+		e.Kind = SshCmdErrorExit
+		e.ExitCode = exitErr.ExitStatus()
+	case errors.Is(err, context.DeadlineExceeded), errors.Is(err, os.ErrDeadlineExceeded):
+		e.Kind = SshCmdErrorTimeout
+	}
+	return e
+}
+
+// sshCmdResult has the fields of SshCmdResult without its methods.
+type sshCmdResult SshCmdResult
+
+// MarshalJSON encodes Err as an SshCmdError object (or null) instead of the {} of the encoding/json default.
+func (r SshCmdResult) MarshalJSON() ([]byte, error) {
+	return json.Marshal(struct {
+		sshCmdResult
+		Err *SshCmdError `json:"err"`
+	}{sshCmdResult(r), NewSshCmdError(r.Err)})
+}
+
+// UnmarshalJSON decodes Err from an SshCmdError object, a string, null,
+// or the {} CB-Tumblebug produces for errors without exported fields.
+// A decoded Err is always an *SshCmdError.
+func (r *SshCmdResult) UnmarshalJSON(data []byte) error {
+	var v struct {
+		sshCmdResult
+		Err json.RawMessage `json:"err"`
+	}
+	if err := json.Unmarshal(data, &v); err != nil {
+		return err
+	}
+	*r = SshCmdResult(v.sshCmdResult)
+
+	cmdErr, err := decodeSshCmdError(v.Err)
+	if err != nil {
+		return err
+	}
+	r.Err = nil
+	if cmdErr != nil {
+		r.Err = cmdErr
+	}
+	return nil
+}
+
+func decodeSshCmdError(data json.RawMessage) (*SshCmdError, error) {
+	data = bytes.TrimSpace(data)
+	if len(data) == 0 || bytes.Equal(data, []byte("null")) {
+		return nil, nil
+	}
+
+	switch data[0] {
+	case '"':
+		var msg string
+		if err := json.Unmarshal(data, &msg); err != nil {
+			return nil, err
+		}
+		return &SshCmdError{Message: msg, Kind: SshCmdErrorFailed}, nil
+	case '{':
+		var e SshCmdError
+		if err := json.Unmarshal(data, &e); err != nil {
+			return nil, fmt.Errorf("invalid err of SshCmdResult: %w", err)
+		}
+		if e.Kind == "" {
+			e.Kind = SshCmdErrorUnknown
+			if e.Message != "" {
+				e.Kind = SshCmdErrorFailed
+			}
+		}
+		return &e, nil
+	default:
+		return nil, fmt.Errorf("invalid err of SshCmdResult: %s", data)
+	}
+}
@@ -0,0 +1,148 @@
+package cloudmodel
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"reflect"
+	"testing"
+)
+
+// exitError has the ExitStatus method of *ssh.ExitError.
+type exitError struct{ status int }
+
+func (e exitError) Error() string   { return fmt.Sprintf("Process exited with status %d", e.status) }
+func (e exitError) ExitStatus() int { return e.status }
+
+func TestSshCmdResultJSONRoundTrip(t *testing.T) {
+	result := func(err error) SshCmdResult {
+		return SshCmdResult{
+			MciId: "mci01", VmId: "g1-1", VmIp: "203.0.113.10",
+			Command: map[int]string{0: "systemctl restart nginx"},
+			Stdout:  map[int]string{0: ""},
+			Stderr:  map[int]string{0: "Job for nginx.service failed."},
+			Err:     err,
+		}
+	}
+	tests := []struct {
+		name    string
+		err     error
+		wantErr *SshCmdError
+	}{
+		{
+			name:    "exit error",
+			err:     fmt.Errorf("command failed: %w", exitError{status: 5}),
+			wantErr: &SshCmdError{Message: "command failed: Process exited with status 5", Kind: SshCmdErrorExit, ExitCode: 5},
+		},
+		{
+			name:    "timeout",
+			err:     fmt.Errorf("dial tcp 203.0.113.10:22: %w", context.DeadlineExceeded),
+			wantErr: &SshCmdError{Message: "dial tcp 203.0.113.10:22: context deadline exceeded", Kind: SshCmdErrorTimeout},
+		},
+		{
+			name:    "other error",
+			err:     errors.New("ssh: handshake failed"),
+			wantErr: &SshCmdError{Message: "ssh: handshake failed", Kind: SshCmdErrorFailed},
+		},
+		{
+			name:    "populated SshCmdError",
+			err:     &SshCmdError{Message: "Process exited with status 127", Kind: SshCmdErrorExit, ExitCode: 127},
+			wantErr: &SshCmdError{Message: "Process exited with status 127", Kind: SshCmdErrorExit, ExitCode: 127},
+		},
+		{
+			name: "no error",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			data, err := json.Marshal(result(tt.err))
+			if err != nil {
+				t.Fatal(err)
+			}
+			var decoded SshCmdResult
+			if err := json.Unmarshal(data, &decoded); err != nil {
+				t.Fatalf("json.Unmarshal(%s) = %v", data, err)
+			}
+
+			want := result(nil)
+			if tt.wantErr != nil {
+				want.Err = tt.wantErr
+			}
+			if !reflect.DeepEqual(decoded, want) {
+				t.Errorf("round trip = %+v\nwant %+v (JSON %s)", decoded, want, data)
+			}
+
+			// Encoding the decoded result again gives the same JSON
+			again, err := json.Marshal(decoded)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if string(again) != string(data) {
+				t.Errorf("re-encoded %s\nwant %s", again, data)
+			}
+		})
+	}
+}
+
+func TestSshCmdResultUnmarshalJSONErr(t *testing.T) {
+	tests := []struct {
+		name    string
+		err     string // JSON of the err field
+		want    *SshCmdError
+		wantErr bool
+	}{
+		{name: "legacy string", err: `"Process exited with status 1"`, want: &SshCmdError{Message: "Process exited with status 1", Kind: SshCmdErrorFailed}},
+		{name: "empty object of CB-Tumblebug", err: `{}`, want: &SshCmdError{Kind: SshCmdErrorUnknown}},
+		{name: "object without kind", err: `{"message": "ssh: unable to authenticate"}`, want: &SshCmdError{Message: "ssh: unable to authenticate", Kind: SshCmdErrorFailed}},
+		{name: "object", err: `{"message": "exited", "kind": "exit", "exitCode": 2}`, want: &SshCmdError{Message: "exited", Kind: SshCmdErrorExit, ExitCode: 2}},
+		{name: "null", err: `null`},
+		{name: "number", err: `1`, wantErr: true},
+		{name: "invalid object", err: `{"exitCode": "2"}`, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var r SshCmdResult
+			err := json.Unmarshal([]byte(`{"mciId": "mci01", "err": `+tt.err+`}`), &r)
+			if tt.wantErr {
+				if err == nil {
+					t.Errorf("json.Unmarshal() = %+v, want an error", r)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if r.MciId != "mci01" {
+				t.Errorf("mciId %q, want mci01", r.MciId)
+			}
+			if tt.want == nil {
+				if r.Err != nil {
+					t.Errorf("Err = %#v, want nil", r.Err)
+				}
+				return
+			}
+			if got, ok := r.Err.(*SshCmdError); !ok || *got != *tt.want {
+				t.Errorf("Err = %#v, want %#v", r.Err, tt.want)
+			}
+		})
+	}
+}
+
+func TestSshCmdErrorError(t *testing.T) {
+	tests := []struct {
+		err  SshCmdError
+		want string
+	}{
+		{SshCmdError{Message: "Process exited with status 1", Kind: SshCmdErrorExit, ExitCode: 1}, "Process exited with status 1 (exit code 1)"},
+		{SshCmdError{Message: "i/o timeout", Kind: SshCmdErrorTimeout}, "i/o timeout"},
+		{SshCmdError{Kind: SshCmdErrorUnknown}, "unknown error"},
+	}
+	for _, tt := range tests {
+		if got := tt.err.Error(); got != tt.want {
+			t.Errorf("Error() = %q, want %q", got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,372 @@
+package cloudmodel
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+	"testing"
+)
+
+// tbFixturesDir holds the CB-Tumblebug JSON payloads checked against copied-tb-model.go (see its README.md).
+var tbFixturesDir = filepath.Join("..", "..", "data", "tb-fixtures")
+
+// TestTbCompatibility decodes each CB-Tumblebug fixture (hand-made after v0.12.5, see data/tb-fixtures/README.md)
+// into its cm-model type, re-encodes it and fails
+// on the fields dropped, renamed (e.g., a json tag whose casing differs from CB-Tumblebug's) or changed by the round trip,
+// unless they are accepted in known-differences.json. Run with -v to also list the omitted zero values and added fields.
+func TestTbCompatibility(t *testing.T) {
+	fixtures := []struct {
+		file string
+		new  func() any
+	}{
+		{"mci-info.json", func() any { return new(MciInfo) }},
+		{"vm-info.json", func() any { return new(VmInfo) }},
+		{"spec-info.json", func() any { return new(SpecInfo) }},
+		{"image-info.json", func() any { return new(ImageInfo) }},
+		{"security-group-req.json", func() any { return new(SecurityGroupReq) }},
+	}
+
+	known, err := loadKnownDifferences(tbFixturesDir)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, f := range fixtures {
+		t.Run(f.file, func(t *testing.T) {
+			diffs, err := roundTrip(filepath.Join(tbFixturesDir, f.file), f.new())
+			if err != nil {
+				t.Fatal(err)
+			}
+			for _, d := range diffs {
+				switch {
+				case !d.kind.breaking():
+					t.Log(d)
+				case known.find(f.file, d) != nil:
+					t.Logf("%s (known: %s)", d, known.find(f.file, d).Reason)
+				default:
+					t.Error(d)
+				}
+			}
+		})
+	}
+
+	// Every accepted difference must still occur, so that fixed differences are removed from the list
+	t.Run(knownDifferencesFile, func(t *testing.T) {
+		for i := range known {
+			k := &known[i]
+			var v any
+			for _, f := range fixtures {
+				if f.file == k.File {
+					v = f.new()
+				}
+			}
+			if v == nil {
+				t.Errorf("%s: no such fixture", k.File)
+				continue
+			}
+			diffs, err := roundTrip(filepath.Join(tbFixturesDir, k.File), v)
+			if err != nil {
+				t.Fatal(err)
+			}
+			found := false
+			for _, d := range diffs {
+				found = found || known.find(k.File, d) == k
+			}
+			if !found {
+				t.Errorf("%s: %s %s no longer occurs", k.File, k.Kind, k.Path)
+			}
+		}
+	})
+}
+
+func TestCompare(t *testing.T) {
+	tests := []struct {
+		name      string
+		original  string
+		reencoded string
+		want      []string
+	}{
+		{"identical", `{"a":1,"b":[1,2],"c":{"d":"x"}}`, `{"a":1,"b":[1,2],"c":{"d":"x"}}`, nil},
+		{"number formats", `{"a":1.0}`, `{"a":1}`, nil},
+		{"dropped", `{"a":1,"b":"x"}`, `{"a":1}`, []string{`dropped  b: value "x"`}},
+		{"renamed", `{"rootDeviceName":"/dev/sda1"}`, `{"RootDeviceName":"/dev/sda1"}`, []string{`renamed  rootDeviceName: re-encoded as "RootDeviceName"`}},
+		{"changed", `{"a":[{"b":1}]}`, `{"a":[{"b":"1"}]}`, []string{`changed  a[0].b: 1 became "1"`}},
+		{"omitted", `{"a":1,"b":0,"c":""}`, `{"a":1}`, []string{"omitted  b", "omitted  c"}},
+		{"added", `{"a":1}`, `{"a":1,"b":2}`, []string{"added    b"}},
+		{"nullized", `{"a":[]}`, `{"a":null}`, []string{"nullized a"}},
+		{"array length", `{"a":[1,2]}`, `{"a":[1]}`, []string{"changed  a: 2 elements became 1"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var original, reencoded any
+			if err := decodeNumbers([]byte(tt.original), &original); err != nil {
+				t.Fatal(err)
+			}
+			if err := decodeNumbers([]byte(tt.reencoded), &reencoded); err != nil {
+				t.Fatal(err)
+			}
+			var diffs []difference
+			compare("", original, reencoded, &diffs)
+
+			var got []string
+			for _, d := range diffs {
+				got = append(got, d.String())
+			}
+			if strings.Join(got, "\n") != strings.Join(tt.want, "\n") {
+				t.Errorf("got %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+// differenceKind is the kind of a difference between a fixture and its round trip.
+type differenceKind string
+
+const (
+	kindDropped  differenceKind = "dropped"  // The field is lost (no field has the json name)
+	kindRenamed  differenceKind = "renamed"  // The field is re-encoded under another name (e.g., another casing)
+	kindChanged  differenceKind = "changed"  // The value differs (e.g., type or precision)
+	kindOmitted  differenceKind = "omitted"  // A zero value is omitted by `omitempty`
+	kindAdded    differenceKind = "added"    // A field is not in the fixture
+	kindNullized differenceKind = "nullized" // An empty array or object is re-encoded as null
+)
+
+// breaking reports whether a client of CB-Tumblebug would lose data by the difference.
+func (k differenceKind) breaking() bool {
+	return k == kindDropped || k == kindRenamed || k == kindChanged
+}
+
+// difference is a difference at a JSON path between a fixture and its round trip.
+type difference struct {
+	kind   differenceKind
+	path   string
+	detail string
+}
+
+func (d difference) String() string {
+	if d.detail == "" {
+		return fmt.Sprintf("%-8s %s", d.kind, d.path)
+	}
+	return fmt.Sprintf("%-8s %s: %s", d.kind, d.path, d.detail)
+}
+
+// knownDifference is a difference accepted in known-differences.json.
+type knownDifference struct {
+	File   string `json:"file"`
+	Path   string `json:"path"` // Array indexes are written as [*] (e.g., vm[*].rootDeviceName)
+	Kind   string `json:"kind"`
+	Reason string `json:"reason"`
+}
+
+type knownDifferences []knownDifference
+
+const knownDifferencesFile = "known-differences.json"
+
+// loadKnownDifferences loads the accepted differences of the fixtures in dir, if any.
+func loadKnownDifferences(dir string) (knownDifferences, error) {
+	data, err := os.ReadFile(filepath.Join(dir, knownDifferencesFile))
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	var known knownDifferences
+	if err := json.Unmarshal(data, &known); err != nil {
+		return nil, fmt.Errorf("invalid %s: %w", knownDifferencesFile, err)
+	}
+	return known, nil
+}
+
+var indexPattern = regexp.MustCompile(`\[\d+\]`)
+
+func (k knownDifferences) find(file string, d difference) *knownDifference {
+	path := indexPattern.ReplaceAllString(d.path, "[*]")
+	for i := range k {
+		if k[i].File == file && k[i].Path == path && k[i].Kind == string(d.kind) {
+			return &k[i]
+		}
+	}
+	return nil
+}
+
+// roundTrip decodes a fixture into v, re-encodes it and returns the differences from the fixture.
+func roundTrip(file string, v any) ([]difference, error) {
+	data, err := os.ReadFile(file)
+	if err != nil {
+		return nil, err
+	}
+
+	var original any
+	if err := decodeNumbers(data, &original); err != nil {
+		return nil, fmt.Errorf("invalid JSON: %w", err)
+	}
+	if err := json.Unmarshal(data, v); err != nil {
+		return nil, fmt.Errorf("failed to decode into %T: %w", v, err)
+	}
+	encoded, err := json.Marshal(v)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode %T: %w", v, err)
+	}
+
+	var reencoded any
+	if err := decodeNumbers(encoded, &reencoded); err != nil {
+		return nil, err
+	}
+
+	var diffs []difference
+	compare("", original, reencoded, &diffs)
+	return diffs, nil
+}
+
+// decodeNumbers decodes JSON keeping the numbers as json.Number to compare them exactly.
+func decodeNumbers(data []byte, v any) error {
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.UseNumber()
+	return dec.Decode(v)
+}
+
+// compare appends the differences between the original and re-encoded JSON values.
+func compare(path string, original, reencoded any, diffs *[]difference) {
+	switch o := original.(type) {
+	case map[string]any:
+		r, ok := reencoded.(map[string]any)
+		if !ok {
+			if reencoded == nil && len(o) == 0 {
+				*diffs = append(*diffs, difference{kind: kindNullized, path: path})
+				return
+			}
+			*diffs = append(*diffs, difference{kind: kindChanged, path: path, detail: fmt.Sprintf("object became %s", jsonText(reencoded))})
+			return
+		}
+		compareObjects(path, o, r, diffs)
+
+	case []any:
+		r, ok := reencoded.([]any)
+		if !ok {
+			if reencoded == nil && len(o) == 0 {
+				*diffs = append(*diffs, difference{kind: kindNullized, path: path})
+				return
+			}
+			*diffs = append(*diffs, difference{kind: kindChanged, path: path, detail: fmt.Sprintf("array became %s", jsonText(reencoded))})
+			return
+		}
+		if len(o) != len(r) {
+			*diffs = append(*diffs, difference{kind: kindChanged, path: path, detail: fmt.Sprintf("%d elements became %d", len(o), len(r))})
+			return
+		}
+		for i := range o {
+			compare(fmt.Sprintf("%s[%d]", path, i), o[i], r[i], diffs)
+		}
+
+	case json.Number:
+		r, ok := reencoded.(json.Number)
+		if ok {
+			of, oerr := o.Float64()
+			rf, rerr := r.Float64()
+			if oerr == nil && rerr == nil && of == rf {
+				return
+			}
+		}
+		*diffs = append(*diffs, difference{kind: kindChanged, path: path, detail: fmt.Sprintf("%s became %s", o, jsonText(reencoded))})
+
+	default:
+		if original != reencoded {
+			*diffs = append(*diffs, difference{kind: kindChanged, path: path, detail: fmt.Sprintf("%s became %s", jsonText(original), jsonText(reencoded))})
+		}
+	}
+}
+
+func compareObjects(path string, original, reencoded map[string]any, diffs *[]difference) {
+	for _, key := range sortedKeys(original) {
+		value := original[key]
+		p := childPath(path, key)
+		if r, ok := reencoded[key]; ok {
+			compare(p, value, r, diffs)
+			continue
+		}
+		if other, ok := findFold(reencoded, key); ok {
+			*diffs = append(*diffs, difference{kind: kindRenamed, path: p, detail: fmt.Sprintf("re-encoded as %q", other)})
+			continue
+		}
+		if isZero(value) {
+			*diffs = append(*diffs, difference{kind: kindOmitted, path: p})
+			continue
+		}
+		*diffs = append(*diffs, difference{kind: kindDropped, path: p, detail: fmt.Sprintf("value %s", jsonText(value))})
+	}
+	for _, key := range sortedKeys(reencoded) {
+		if _, ok := original[key]; ok {
+			continue
+		}
+		if _, ok := findFold(original, key); ok {
+			continue // Reported as renamed
+		}
+		*diffs = append(*diffs, difference{kind: kindAdded, path: childPath(path, key)})
+	}
+}
+
+func childPath(path, key string) string {
+	if path == "" {
+		return key
+	}
+	return path + "." + key
+}
+
+// isZero reports whether a decoded JSON value is omitted by `omitempty`.
+func isZero(v any) bool {
+	switch v := v.(type) {
+	case nil:
+		return true
+	case bool:
+		return !v
+	case string:
+		return v == ""
+	case json.Number:
+		f, err := v.Float64()
+		return err == nil && f == 0
+	case []any:
+		return len(v) == 0
+	case map[string]any:
+		return len(v) == 0
+	}
+	return false
+}
+
+// findFold returns the key of m equal to key under case folding, if any.
+func findFold(m map[string]any, key string) (string, bool) {
+	for k := range m {
+		if k != key && strings.EqualFold(k, key) {
+			return k, true
+		}
+	}
+	return "", false
+}
+
+func sortedKeys(m map[string]any) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// jsonText returns a decoded JSON value as short JSON text.
+func jsonText(v any) string {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return fmt.Sprint(v)
+	}
+	if len(data) > 60 {
+		return string(data[:57]) + "..."
+	}
+	return string(data)
+}
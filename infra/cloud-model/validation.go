@@ -0,0 +1,15 @@
+package cloudmodel
+
+import "github.com/cloud-barista/cm-model/validation"
+
+// Validate checks the `validate` tags of the whole recommended VM infrastructure model.
+// It returns validation.Errors listing every failure with its JSON path, or nil.
+func (m RecommendedVmInfraModel) Validate() error {
+	return validation.Check(m).Err()
+}
+
+// Validate checks the `validate` tags of the whole migrated VM infrastructure model.
+// It returns validation.Errors listing every failure with its JSON path, or nil.
+func (m MigratedVmInfraModel) Validate() error {
+	return validation.Check(m).Err()
+}
@@ -1,9 +1,5 @@
 package cloudmodel
 
-// type MigratedVmInfraModel struct {
-// 	MigratedVmInfraModel MigratedVmInfraInfo `json:"migratedVmInfraModel" validate:"required"`
-// }
-
 type VmInfraInfo struct {
 	MciInfo
 }
@@ -0,0 +1,296 @@
+package cloudmodel
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	onpremisemodel "github.com/cloud-barista/cm-model/infra/on-premise-model"
+)
+
+// ImageMatch represents an image of the catalog evaluated for a server.
+type ImageMatch struct {
+	Image    ImageInfo `json:"image"`
+	Score    float64   `json:"score"`    // 0-1, higher is better; 0 if not eligible
+	Eligible bool      `json:"eligible"` // Whether the image can be used for the server
+	Chosen   bool      `json:"chosen"`   // Whether the image is the best match
+	Reasons  []string  `json:"reasons"`  // Why the image is chosen, ranked, or rejected
+}
+
+// osDistributions maps the names of OS distributions (in lower case) to the IDs of `/etc/os-release`.
+// Longer names come first to be matched before their prefixes.
+var osDistributions = []struct{ name, id string }{
+	{"red hat enterprise linux", "rhel"},
+	{"amazon linux", "amzn"},
+	{"oracle linux", "ol"},
+	{"rocky linux", "rocky"},
+	{"alma linux", "almalinux"},
+	{"almalinux", "almalinux"},
+	{"opensuse", "opensuse-leap"},
+	{"ubuntu", "ubuntu"},
+	{"debian", "debian"},
+	{"centos", "centos"},
+	{"redhat", "rhel"},
+	{"rhel", "rhel"},
+	{"rocky", "rocky"},
+	{"fedora", "fedora"},
+	{"sles", "sles"},
+	{"suse", "sles"},
+	{"windows", "windows"},
+}
+
+// osFamilies maps the IDs of OS distributions to their families, like ID_LIKE of `/etc/os-release`.
+var osFamilies = map[string]string{
+	"debian": "debian", "ubuntu": "debian",
+	"rhel": "rhel", "centos": "rhel", "rocky": "rhel", "almalinux": "rhel", "ol": "rhel", "fedora": "rhel", "amzn": "rhel",
+	"sles": "suse", "opensuse-leap": "suse", "suse": "suse",
+	"windows": "windows",
+}
+
+// rhelCompatibles are the distributions sharing the major versions of RHEL, so their versions are comparable.
+var rhelCompatibles = map[string]bool{"rhel": true, "centos": true, "rocky": true, "almalinux": true, "ol": true}
+
+var versionPattern = regexp.MustCompile(`\d+(\.\d+)*`)
+
+// MatchVmOsImages evaluates the images of the catalog for the server, best first.
+// An image is eligible if it is not deprecated or unavailable, matches the architecture of the server (if known), and runs
+//   - the same distribution and version (score 1.0), or the same major version (0.9; newer ones LTS releases only for Ubuntu),
+//   - a newer release of the same distribution (0.8, LTS releases only for Ubuntu),
+//   - the same or a newer release of a distribution of the same family (0.6, 0.5; e.g., Rocky Linux for CentOS),
+//     or any release if the versions are not comparable across the distributions (0.4; e.g., Debian for Ubuntu), or
+//   - the same distribution of an unknown version, either of the image or of the server (0.3).
+//
+// Newer releases closer to the source rank higher. GPU and Kubernetes images get -0.05,
+// and basic OS images are preferred among the images of the same score. Each match explains its evaluation in Reasons.
+func MatchVmOsImages(server onpremisemodel.ServerProperty, catalog []ImageInfo) []ImageMatch {
+	srcId := strings.ToLower(server.OS.ID)
+	srcFamily := osFamilyOf(srcId, server.OS.IDLike)
+	srcVersion := parseVersion(server.OS.VersionID)
+	arch := NormalizeArchitecture(server.CPU.Architecture)
+
+	matches := make([]ImageMatch, 0, len(catalog))
+	for _, image := range catalog {
+		m := ImageMatch{Image: image}
+		reject := func(format string, args ...any) {
+			m.Reasons = append(m.Reasons, "rejected: "+fmt.Sprintf(format, args...))
+		}
+
+		imgId, imgVersion := imageOS(image)
+		switch {
+		case image.ImageStatus == ImageDeprecated || image.ImageStatus == ImageUnavailable:
+			reject("image status is %s", image.ImageStatus)
+		case image.InfraType != "" && !strings.Contains(strings.ToLower(image.InfraType), "vm"):
+			reject("infra type %s is not vm", image.InfraType)
+		case !IsCompatibleArchitecture(arch, NormalizeArchitecture(string(image.OSArchitecture))):
+			reject("architecture %s does not match %s", image.OSArchitecture, arch)
+		case imgId == "":
+			reject("OS distribution of %q is unknown", image.OSType)
+		case srcId == "":
+			reject("OS distribution of the server is unknown")
+		default:
+			m.evaluate(srcId, srcFamily, srcVersion, imgId, imgVersion)
+		}
+
+		if m.Eligible {
+			if image.IsBasicImage {
+				m.Reasons = append(m.Reasons, "basic OS image (preferred on a tie)")
+			}
+			if image.IsGPUImage {
+				m.Score -= 0.05
+				m.Reasons = append(m.Reasons, "GPU image (-0.05)")
+			}
+			if image.IsKubernetesImage {
+				m.Score -= 0.05
+				m.Reasons = append(m.Reasons, "Kubernetes image (-0.05)")
+			}
+			m.Score = min(max(m.Score, 0.01), 1)
+		}
+		matches = append(matches, m)
+	}
+
+	sort.SliceStable(matches, func(i, j int) bool {
+		if matches[i].Score != matches[j].Score {
+			return matches[i].Score > matches[j].Score
+		}
+		if matches[i].Eligible && matches[i].Image.IsBasicImage != matches[j].Image.IsBasicImage {
+			return matches[i].Image.IsBasicImage
+		}
+		return matches[i].Image.Id < matches[j].Image.Id
+	})
+	if len(matches) > 0 && matches[0].Eligible {
+		matches[0].Chosen = true
+		matches[0].Reasons = append(matches[0].Reasons, "chosen: best score")
+	}
+	return matches
+}
+
+// evaluate scores the image by its distribution and version against the source.
+func (m *ImageMatch) evaluate(srcId, srcFamily string, srcVersion []int, imgId, imgVersionId string) {
+	imgName := strings.TrimSpace(imgId + " " + imgVersionId)
+	imgVersion := parseVersion(imgVersionId)
+	cmp := compareVersions(imgVersion, srcVersion)
+	comparable := imgId == srcId || (rhelCompatibles[imgId] && rhelCompatibles[srcId])
+	distance := 0.0
+	if len(imgVersion) > 0 && len(srcVersion) > 0 {
+		distance = min(float64(imgVersion[0]-srcVersion[0]), 10) * 0.01
+	}
+
+	switch {
+	case imgId == srcId && (len(srcVersion) == 0 || len(imgVersion) == 0):
+		// compareVersions cannot tell an unknown version from an exact match
+		m.Score = 0.3
+		m.Reasons = append(m.Reasons, fmt.Sprintf("same distribution (%s) of an unknown version", imgName))
+	case imgId == srcId && cmp == 0:
+		m.Score = 1.0
+		m.Reasons = append(m.Reasons, fmt.Sprintf("exact match of %s", imgName))
+	case imgId == srcId && cmp > 0 && imgId == "ubuntu" && !isUbuntuLTS(imgVersion):
+		// Before the same major version, as the major version of Ubuntu is the release year
+		m.Reasons = append(m.Reasons, fmt.Sprintf("rejected: %s is a newer but non-LTS release", imgName))
+		return
+	case imgId == srcId && imgVersion[0] == srcVersion[0]:
+		m.Score = 0.9
+		m.Reasons = append(m.Reasons, fmt.Sprintf("same major version (%s)", imgName))
+	case imgId == srcId && cmp > 0:
+		m.Score = 0.8 - distance
+		m.Reasons = append(m.Reasons, fmt.Sprintf("newer release of the same distribution (%s)", imgName))
+	case imgId == srcId:
+		m.Reasons = append(m.Reasons, fmt.Sprintf("rejected: %s is older than the source", imgName))
+		return
+	case srcFamily != "" && osFamilies[imgId] == srcFamily && !comparable:
+		if imgId == "ubuntu" && !isUbuntuLTS(imgVersion) {
+			m.Reasons = append(m.Reasons, fmt.Sprintf("rejected: %s is a non-LTS release of the same family (%s)", imgName, srcFamily))
+			return
+		}
+		m.Score = 0.4
+		m.Reasons = append(m.Reasons, fmt.Sprintf("same family (%s) distribution (%s) with an incomparable version", srcFamily, imgName))
+	case srcFamily != "" && osFamilies[imgId] == srcFamily && cmp >= 0 && len(imgVersion) > 0:
+		if imgId == "ubuntu" && !isUbuntuLTS(imgVersion) {
+			m.Reasons = append(m.Reasons, fmt.Sprintf("rejected: %s is a non-LTS release of the same family (%s)", imgName, srcFamily))
+			return
+		}
+		m.Score = 0.6
+		if cmp > 0 {
+			m.Score = 0.5 - distance
+		}
+		m.Reasons = append(m.Reasons, fmt.Sprintf("same family (%s) distribution (%s)", srcFamily, imgName))
+	case srcFamily != "" && osFamilies[imgId] == srcFamily:
+		m.Reasons = append(m.Reasons, fmt.Sprintf("rejected: %s of the same family (%s) is older than the source", imgName, srcFamily))
+		return
+	default:
+		m.Reasons = append(m.Reasons, fmt.Sprintf("rejected: %s is not in the family of %s", imgName, srcId))
+		return
+	}
+	m.Eligible = true
+}
+
+// RecommendVmOsImages recommends the best matching image for each server.
+// Servers recommended the same image share an entry, listed in SourceServers (machine IDs, or hostnames if empty).
+func RecommendVmOsImages(servers []onpremisemodel.ServerProperty, catalog []ImageInfo) RecommendedVmOsImageList {
+	list := RecommendedVmOsImageList{Status: StatusRecommended}
+	index := make(map[string]int) // Image ID -> index of the entry
+	unmatched := 0
+
+	for _, server := range servers {
+		serverId := server.MachineId
+		if serverId == "" {
+			serverId = server.Hostname
+		}
+		matches := MatchVmOsImages(server, catalog)
+		if len(matches) == 0 || !matches[0].Chosen {
+			unmatched++
+			continue
+		}
+		best := matches[0]
+		if i, exists := index[best.Image.Id]; exists {
+			list.RecommendedVmOsImageList[i].SourceServers = append(list.RecommendedVmOsImageList[i].SourceServers, serverId)
+			continue
+		}
+
+		entry := RecommendedVmOsImage{
+			Status:          StatusRecommended,
+			SourceServers:   []string{serverId},
+			Description:     strings.Join(best.Reasons, "; "),
+			TargetVmOsImage: best.Image,
+		}
+		if best.Score < 0.9 {
+			entry.Status = StatusPartiallyRecommended
+		}
+		index[best.Image.Id] = len(list.RecommendedVmOsImageList)
+		list.RecommendedVmOsImageList = append(list.RecommendedVmOsImageList, entry)
+	}
+
+	list.Count = len(list.RecommendedVmOsImageList)
+	for _, entry := range list.RecommendedVmOsImageList {
+		if entry.Status != StatusRecommended {
+			list.Status = StatusPartiallyRecommended
+		}
+	}
+	switch {
+	case list.Count == 0:
+		list.Status = StatusNotRecommended
+	case unmatched > 0:
+		list.Status = StatusPartiallyRecommended
+	}
+	list.Description = fmt.Sprintf("%d image(s) recommended for %d server(s); %d server(s) without a matching image", list.Count, len(servers), unmatched)
+	return list
+}
+
+// imageOS returns the distribution ID and version ID of the image, from OSType (e.g., "ubuntu 22.04")
+// or else OSDistribution (e.g., "Ubuntu 22.04~").
+func imageOS(image ImageInfo) (string, string) {
+	for _, text := range []string{image.OSType, image.OSDistribution} {
+		lower := strings.ToLower(text)
+		for _, d := range osDistributions {
+			if strings.Contains(lower, d.name) {
+				rest := lower[strings.Index(lower, d.name)+len(d.name):]
+				return d.id, versionPattern.FindString(rest)
+			}
+		}
+	}
+	return "", ""
+}
+
+// osFamilyOf returns the family of the distribution, looking up ID_LIKE if the ID is unknown.
+func osFamilyOf(id, idLike string) string {
+	if family, ok := osFamilies[id]; ok {
+		return family
+	}
+	for _, like := range strings.Fields(strings.ToLower(idLike)) {
+		if family, ok := osFamilies[like]; ok {
+			return family
+		}
+	}
+	return ""
+}
+
+func parseVersion(version string) []int {
+	var parts []int
+	for _, p := range strings.Split(versionPattern.FindString(version), ".") {
+		n, err := strconv.Atoi(p)
+		if err != nil {
+			break
+		}
+		parts = append(parts, n)
+	}
+	return parts
+}
+
+// compareVersions compares versions by their common parts (e.g., 9 and 9.3 are equal).
+func compareVersions(a, b []int) int {
+	for i := 0; i < len(a) && i < len(b); i++ {
+		if a[i] != b[i] {
+			if a[i] < b[i] {
+				return -1
+			}
+			return 1
+		}
+	}
+	return 0
+}
+
+// isUbuntuLTS returns true for the LTS releases of Ubuntu (i.e., even years in April, e.g., 22.04).
+func isUbuntuLTS(version []int) bool {
+	return len(version) >= 2 && version[0]%2 == 0 && version[1] == 4
+}
@@ -0,0 +1,113 @@
+package cloudmodel
+
+import (
+	"testing"
+
+	onpremisemodel "github.com/cloud-barista/cm-model/infra/on-premise-model"
+)
+
+func TestMatchVmOsImages(t *testing.T) {
+	ubuntu2204 := onpremisemodel.ServerProperty{
+		OS:  onpremisemodel.OsProperty{ID: "ubuntu", IDLike: "debian", VersionID: "22.04"},
+		CPU: onpremisemodel.CpuProperty{Architecture: "x86_64"},
+	}
+	rocky93 := onpremisemodel.ServerProperty{
+		OS:  onpremisemodel.OsProperty{ID: "rocky", IDLike: "rhel centos fedora", VersionID: "9.3"},
+		CPU: onpremisemodel.CpuProperty{Architecture: "x86_64"},
+	}
+	image := func(id, osType string, basic bool) ImageInfo {
+		return ImageInfo{Id: id, OSType: osType, OSArchitecture: X86_64, ImageStatus: ImageAvailable, IsBasicImage: basic}
+	}
+	tests := []struct {
+		name      string
+		server    onpremisemodel.ServerProperty
+		catalog   []ImageInfo
+		wantId    string
+		wantScore float64
+	}{
+		{
+			name:      "versioned image over versionless image",
+			server:    ubuntu2204,
+			catalog:   []ImageInfo{image("a-ubuntu", "Ubuntu", false), image("b-ubuntu-2204", "Ubuntu 22.04", false)},
+			wantId:    "b-ubuntu-2204",
+			wantScore: 1.0,
+		},
+		{
+			name:      "versionless image over nothing",
+			server:    ubuntu2204,
+			catalog:   []ImageInfo{image("a-ubuntu", "Ubuntu", false), image("b-ubuntu-2004", "Ubuntu 20.04", false)},
+			wantId:    "a-ubuntu",
+			wantScore: 0.3,
+		},
+		{
+			name:      "basic image on a tie",
+			server:    ubuntu2204,
+			catalog:   []ImageInfo{image("a-ubuntu-2204", "Ubuntu 22.04", false), image("b-ubuntu-2204", "Ubuntu 22.04", true)},
+			wantId:    "b-ubuntu-2204",
+			wantScore: 1.0,
+		},
+		{
+			name:      "exact match over basic image of the same major version",
+			server:    rocky93,
+			catalog:   []ImageInfo{image("a-rocky-94", "Rocky Linux 9.4", true), image("b-rocky-93", "Rocky Linux 9.3", false)},
+			wantId:    "b-rocky-93",
+			wantScore: 1.0,
+		},
+		{
+			name:      "same major version",
+			server:    rocky93,
+			catalog:   []ImageInfo{image("a-rocky-810", "Rocky Linux 8.10", true), image("b-rocky-94", "Rocky Linux 9.4", false)},
+			wantId:    "b-rocky-94",
+			wantScore: 0.9,
+		},
+		{
+			name:      "LTS release over non-LTS release of the same major version",
+			server:    ubuntu2204,
+			catalog:   []ImageInfo{image("a-ubuntu-2210", "Ubuntu 22.10", true), image("b-ubuntu-2404", "Ubuntu 24.04", false)},
+			wantId:    "b-ubuntu-2404",
+			wantScore: 0.78,
+		},
+		{
+			name:    "non-LTS release of the same major version only",
+			server:  ubuntu2204,
+			catalog: []ImageInfo{image("a-ubuntu-2210", "Ubuntu 22.10", true), image("b-ubuntu-2004", "Ubuntu 20.04", false)},
+			wantId:  "", // Nothing eligible
+		},
+		{
+			name:      "newer LTS release",
+			server:    ubuntu2204,
+			catalog:   []ImageInfo{image("a-ubuntu-2310", "Ubuntu 23.10", false), image("b-ubuntu-2404", "Ubuntu 24.04", false)},
+			wantId:    "b-ubuntu-2404",
+			wantScore: 0.78,
+		},
+		{
+			name: "server of an unknown version",
+			server: onpremisemodel.ServerProperty{
+				OS: onpremisemodel.OsProperty{ID: "rocky"}, CPU: onpremisemodel.CpuProperty{Architecture: "x86_64"},
+			},
+			catalog:   []ImageInfo{image("a-rocky-9", "Rocky Linux 9.3", false)},
+			wantId:    "a-rocky-9",
+			wantScore: 0.3,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			matches := MatchVmOsImages(tt.server, tt.catalog)
+			if tt.wantId == "" {
+				for _, m := range matches {
+					if m.Eligible || m.Chosen {
+						t.Errorf("%s eligible (%.2f); reasons %v", m.Image.Id, m.Score, m.Reasons)
+					}
+				}
+				return
+			}
+			if len(matches) == 0 || !matches[0].Chosen {
+				t.Fatalf("no image chosen: %+v", matches)
+			}
+			if matches[0].Image.Id != tt.wantId || matches[0].Score != tt.wantScore {
+				t.Errorf("chosen %s (%.2f), want %s (%.2f); reasons %v",
+					matches[0].Image.Id, matches[0].Score, tt.wantId, tt.wantScore, matches[0].Reasons)
+			}
+		})
+	}
+}
@@ -0,0 +1,229 @@
+package cloudmodel
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"strings"
+
+	onpremisemodel "github.com/cloud-barista/cm-model/infra/on-premise-model"
+)
+
+// Evaluation scores written by MatchVmSpecs into SpecInfo (each in 0-1, higher is better).
+//   - EvaluationScore01: overall score, the weighted sum of the scores below
+//   - EvaluationScore02: vCPU fit (required / offered vCPUs), weight 0.35
+//   - EvaluationScore03: memory fit (required / offered memory), weight 0.35
+//   - EvaluationScore04: cost (lowest cost among the candidates / cost; 0.5 if unknown), weight 0.2
+//   - EvaluationScore05: architecture match (1 if exact, 0.5 if unknown on either side), weight 0.05
+//   - EvaluationScore06: accelerator fit (1 if as required, 0.5 if an unrequired accelerator is included), weight 0.05
+//   - EvaluationScore07-10: reserved (0)
+const (
+	specWeightVCPU         = 0.35
+	specWeightMemory       = 0.35
+	specWeightCost         = 0.2
+	specWeightArchitecture = 0.05
+	specWeightAccelerator  = 0.05
+)
+
+// Evaluation statuses written by MatchVmSpecs into SpecInfo.EvaluationStatus.
+const (
+	SpecEvaluationMatched = "matched" // Within the over-provisioning tolerance
+	SpecEvaluationRelaxed = "relaxed" // Meets the requirements, but exceeds the over-provisioning tolerance
+)
+
+// SpecMatchOptions represents the options of MatchVmSpecs.
+type SpecMatchOptions struct {
+	// OverProvisionTolerance is the maximum excess ratio of vCPUs and memory over the requirements
+	// (e.g., 0.5 allows up to 150% of the requirements, 0 or less only an exact fit). Default: 1.0 if nil
+	OverProvisionTolerance *float64 `json:"overProvisionTolerance,omitempty" default:"1.0"`
+	// RequireAccelerator requires specs with accelerators (e.g., GPU) matching the accelerator fields below.
+	RequireAccelerator  bool   `json:"requireAccelerator" default:"false"`
+	AcceleratorType     string `json:"acceleratorType,omitempty" example:"GPU"`                // Case-insensitive exact match, if given
+	AcceleratorModel    string `json:"acceleratorModel,omitempty" example:"NVIDIA Tesla V100"` // Case-insensitive substring match, if given
+	MinAcceleratorCount uint8  `json:"minAcceleratorCount,omitempty" example:"1"`
+	// MaxResults is the maximum number of specs to return. Default: 5
+	MaxResults int `json:"maxResults" default:"5"`
+}
+
+// requiredVCPUs returns the number of logical CPUs of the server (i.e., sockets × threads per socket).
+func requiredVCPUs(cpu onpremisemodel.CpuProperty) float64 {
+	sockets := max(cpu.Cpus, 1)
+	threads := cpu.Threads
+	if threads == 0 {
+		threads = cpu.Cores
+	}
+	return float64(max(sockets*threads, 1))
+}
+
+// MatchVmSpecs ranks the specs in the catalog for the server.
+// Specs must match the architecture of the server (if known), offer at least the vCPUs (sockets × threads)
+// and memory of the server, and satisfy the accelerator requirement. Specs within the over-provisioning tolerance
+// are returned first; if there is none, the specs exceeding the tolerance are returned as relaxed.
+// The scores and the rank are written into EvaluationScore01..10, EvaluationStatus and OrderInFilteredResult
+// of the returned copies (see the evaluation score constants). Ties are broken by cost and ID.
+func MatchVmSpecs(server onpremisemodel.ServerProperty, catalog []SpecInfo, opts SpecMatchOptions) []SpecInfo {
+	tolerance := 1.0
+	if opts.OverProvisionTolerance != nil {
+		tolerance = max(*opts.OverProvisionTolerance, 0)
+	}
+	if opts.MaxResults <= 0 {
+		opts.MaxResults = 5
+	}
+
+	vcpus := requiredVCPUs(server.CPU)
+	memory := math.Max(float64(server.Memory.TotalSize), 1)
+	arch := NormalizeArchitecture(server.CPU.Architecture)
+
+	var matched, relaxed []SpecInfo
+	for _, spec := range catalog {
+		if spec.InfraType != "" && !strings.EqualFold(spec.InfraType, "vm") {
+			continue
+		}
+		specArch := NormalizeArchitecture(spec.Architecture)
+		if !IsCompatibleArchitecture(arch, specArch) || !opts.acceleratorSatisfied(spec) {
+			continue
+		}
+		if float64(spec.VCPU) < vcpus || float64(spec.MemoryGiB) < memory {
+			continue
+		}
+
+		spec.EvaluationScore02 = float32(vcpus / float64(spec.VCPU))
+		spec.EvaluationScore03 = float32(memory / float64(spec.MemoryGiB))
+		spec.EvaluationScore05 = 1
+		if arch == ArchitectureUnknown || specArch == ArchitectureUnknown {
+			spec.EvaluationScore05 = 0.5
+		}
+		spec.EvaluationScore06 = 1
+		if !opts.RequireAccelerator && spec.AcceleratorCount > 0 {
+			spec.EvaluationScore06 = 0.5
+		}
+
+		limit := 1 + tolerance
+		if float64(spec.VCPU) <= vcpus*limit && float64(spec.MemoryGiB) <= memory*limit {
+			spec.EvaluationStatus = SpecEvaluationMatched
+			matched = append(matched, spec)
+		} else {
+			spec.EvaluationStatus = SpecEvaluationRelaxed
+			relaxed = append(relaxed, spec)
+		}
+	}
+
+	candidates := matched
+	if len(candidates) == 0 {
+		candidates = relaxed
+	}
+	scoreCost(candidates)
+	for i := range candidates {
+		s := &candidates[i]
+		s.EvaluationScore01 = float32(specWeightVCPU*float64(s.EvaluationScore02) +
+			specWeightMemory*float64(s.EvaluationScore03) +
+			specWeightCost*float64(s.EvaluationScore04) +
+			specWeightArchitecture*float64(s.EvaluationScore05) +
+			specWeightAccelerator*float64(s.EvaluationScore06))
+		s.EvaluationScore07, s.EvaluationScore08, s.EvaluationScore09, s.EvaluationScore10 = 0, 0, 0, 0
+	}
+
+	sort.SliceStable(candidates, func(i, j int) bool {
+		a, b := candidates[i], candidates[j]
+		if a.EvaluationScore01 != b.EvaluationScore01 {
+			return a.EvaluationScore01 > b.EvaluationScore01
+		}
+		if a.CostPerHour != b.CostPerHour {
+			return a.CostPerHour < b.CostPerHour
+		}
+		return a.Id < b.Id
+	})
+	if len(candidates) > opts.MaxResults {
+		candidates = candidates[:opts.MaxResults]
+	}
+	for i := range candidates {
+		candidates[i].OrderInFilteredResult = uint16(i + 1)
+	}
+	return candidates
+}
+
+// RecommendVmSpecs recommends the best matching spec for each server.
+// Servers recommended the same spec share an entry, listed in SourceServers (machine IDs, or hostnames if empty).
+func RecommendVmSpecs(servers []onpremisemodel.ServerProperty, catalog []SpecInfo, opts SpecMatchOptions) RecommendedVmSpecList {
+	list := RecommendedVmSpecList{Status: StatusRecommended}
+	index := make(map[string]int) // Spec ID -> index of the entry
+	unmatched := 0
+
+	for _, server := range servers {
+		serverId := server.MachineId
+		if serverId == "" {
+			serverId = server.Hostname
+		}
+		ranked := MatchVmSpecs(server, catalog, opts)
+		if len(ranked) == 0 {
+			unmatched++
+			continue
+		}
+		best := ranked[0]
+		if i, exists := index[best.Id]; exists {
+			list.RecommendedVmSpecList[i].SourceServers = append(list.RecommendedVmSpecList[i].SourceServers, serverId)
+			continue
+		}
+
+		entry := RecommendedVmSpec{
+			Status:        StatusRecommended,
+			SourceServers: []string{serverId},
+			Description:   fmt.Sprintf("Spec %s (%d vCPU, %.1f GiB) scored %.2f", best.Id, best.VCPU, best.MemoryGiB, best.EvaluationScore01),
+			TargetVmSpec:  best,
+		}
+		if best.EvaluationStatus == SpecEvaluationRelaxed {
+			entry.Status = StatusPartiallyRecommended
+			entry.Description += " exceeding the over-provisioning tolerance"
+		}
+		index[best.Id] = len(list.RecommendedVmSpecList)
+		list.RecommendedVmSpecList = append(list.RecommendedVmSpecList, entry)
+	}
+
+	list.Count = len(list.RecommendedVmSpecList)
+	for _, entry := range list.RecommendedVmSpecList {
+		if entry.Status != StatusRecommended {
+			list.Status = StatusPartiallyRecommended
+		}
+	}
+	switch {
+	case list.Count == 0:
+		list.Status = StatusNotRecommended
+	case unmatched > 0:
+		list.Status = StatusPartiallyRecommended
+	}
+	list.Description = fmt.Sprintf("%d spec(s) recommended for %d server(s); %d server(s) without a matching spec", list.Count, len(servers), unmatched)
+	return list
+}
+
+func (opts SpecMatchOptions) acceleratorSatisfied(spec SpecInfo) bool {
+	if !opts.RequireAccelerator {
+		return true
+	}
+	if spec.AcceleratorCount == 0 || spec.AcceleratorCount < opts.MinAcceleratorCount {
+		return false
+	}
+	if opts.AcceleratorType != "" && !strings.EqualFold(spec.AcceleratorType, opts.AcceleratorType) {
+		return false
+	}
+	if opts.AcceleratorModel != "" && !strings.Contains(strings.ToLower(spec.AcceleratorModel), strings.ToLower(opts.AcceleratorModel)) {
+		return false
+	}
+	return true
+}
+
+// scoreCost writes the cost scores relative to the lowest known cost of the candidates.
+func scoreCost(candidates []SpecInfo) {
+	lowest := float32(math.MaxFloat32)
+	for _, s := range candidates {
+		if s.CostPerHour > 0 && s.CostPerHour < lowest {
+			lowest = s.CostPerHour
+		}
+	}
+	for i := range candidates {
+		s := &candidates[i]
+		s.EvaluationScore04 = 0.5
+		if s.CostPerHour > 0 {
+			s.EvaluationScore04 = lowest / s.CostPerHour
+		}
+	}
+}
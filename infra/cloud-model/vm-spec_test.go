@@ -0,0 +1,85 @@
+package cloudmodel
+
+import (
+	"encoding/json"
+	"reflect"
+	"testing"
+
+	onpremisemodel "github.com/cloud-barista/cm-model/infra/on-premise-model"
+)
+
+func TestMatchVmSpecsTolerance(t *testing.T) {
+	server := onpremisemodel.ServerProperty{
+		CPU:    onpremisemodel.CpuProperty{Architecture: "x86_64", Cpus: 1, Cores: 2, Threads: 2},
+		Memory: onpremisemodel.MemoryProperty{TotalSize: 4},
+	}
+	spec := func(id string, vcpu uint16, memory, cost float32) SpecInfo {
+		return SpecInfo{Id: id, InfraType: "vm", Architecture: "x86_64", VCPU: vcpu, MemoryGiB: memory, CostPerHour: cost}
+	}
+	exact := spec("exact", 2, 4, 0.1)
+	double := spec("double", 4, 8, 0.15)
+	huge := spec("huge", 16, 64, 0.5)
+	small := spec("small", 1, 2, 0.05)
+	arm := spec("arm", 2, 4, 0.08)
+	arm.Architecture = "arm64"
+	catalog := []SpecInfo{huge, double, small, arm, exact}
+
+	tolerance := func(f float64) *float64 { return &f }
+	tests := []struct {
+		name       string
+		catalog    []SpecInfo
+		tolerance  *float64
+		wantIds    []string
+		wantStatus string
+	}{
+		{"default tolerance", catalog, nil, []string{"exact", "double"}, SpecEvaluationMatched},
+		{"exact fit only", catalog, tolerance(0), []string{"exact"}, SpecEvaluationMatched},
+		{"negative as exact fit", catalog, tolerance(-1), []string{"exact"}, SpecEvaluationMatched},
+		{"wide tolerance", catalog, tolerance(20), []string{"exact", "double", "huge"}, SpecEvaluationMatched},
+		{"relaxed without exact fit", []SpecInfo{huge, double}, tolerance(0), []string{"double", "huge"}, SpecEvaluationRelaxed},
+		{"nothing large enough", []SpecInfo{small, arm}, nil, nil, ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ranked := MatchVmSpecs(server, tt.catalog, SpecMatchOptions{OverProvisionTolerance: tt.tolerance})
+			var ids []string
+			for i, s := range ranked {
+				ids = append(ids, s.Id)
+				if s.EvaluationStatus != tt.wantStatus || s.OrderInFilteredResult != uint16(i+1) {
+					t.Errorf("%s: status %s, order %d, want %s, %d", s.Id, s.EvaluationStatus, s.OrderInFilteredResult, tt.wantStatus, i+1)
+				}
+			}
+			if !reflect.DeepEqual(ids, tt.wantIds) {
+				t.Errorf("MatchVmSpecs() = %v, want %v", ids, tt.wantIds)
+			}
+		})
+	}
+}
+
+func TestSpecMatchOptionsJSON(t *testing.T) {
+	tests := []struct {
+		json string
+		want *float64
+	}{
+		{`{}`, nil},
+		{`{"overProvisionTolerance": 0}`, new(float64)},
+	}
+	for _, tt := range tests {
+		var opts SpecMatchOptions
+		if err := json.Unmarshal([]byte(tt.json), &opts); err != nil {
+			t.Fatal(err)
+		}
+		if !reflect.DeepEqual(opts.OverProvisionTolerance, tt.want) {
+			t.Errorf("%s: tolerance %v, want %v", tt.json, opts.OverProvisionTolerance, tt.want)
+		}
+	}
+
+	// An unset tolerance is omitted, so that it stays unset on a round trip
+	b, err := json.Marshal(SpecMatchOptions{MaxResults: 5})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := `{"requireAccelerator":false,"maxResults":5}`; string(b) != want {
+		t.Errorf("json.Marshal() = %s, want %s", b, want)
+	}
+}
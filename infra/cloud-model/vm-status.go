@@ -0,0 +1,231 @@
+package cloudmodel
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// VmStatus is the status of a VM, or the aggregated status of an MCI (see MciInfo.Status and VmInfo.Status).
+type VmStatus string
+
+const (
+	VmStatusPreparing   VmStatus = "Preparing" // Resources of the VM are being prepared before its creation
+	VmStatusPrepared    VmStatus = "Prepared"  // Resources of the VM are prepared and it is ready to be created
+	VmStatusCreating    VmStatus = "Creating"
+	VmStatusRunning     VmStatus = "Running"
+	VmStatusSuspending  VmStatus = "Suspending"
+	VmStatusSuspended   VmStatus = "Suspended"
+	VmStatusResuming    VmStatus = "Resuming"
+	VmStatusRebooting   VmStatus = "Rebooting"
+	VmStatusTerminating VmStatus = "Terminating"
+	VmStatusTerminated  VmStatus = "Terminated"
+	VmStatusRegistering VmStatus = "Registering"
+	VmStatusFailed      VmStatus = "Failed"
+	VmStatusUndefined   VmStatus = "Undefined"
+	VmStatusEmpty       VmStatus = "Empty" // Status of an MCI without VMs
+	VmStatusNone        VmStatus = "None"  // TargetStatus when no action is in progress
+)
+
+// VmAction is an action on a VM or an MCI (see MciInfo.TargetAction and VmInfo.TargetAction).
+type VmAction string
+
+const (
+	VmActionCreate    VmAction = "Create"
+	VmActionSuspend   VmAction = "Suspend"
+	VmActionResume    VmAction = "Resume"
+	VmActionReboot    VmAction = "Reboot"
+	VmActionTerminate VmAction = "Terminate"
+	VmActionRefine    VmAction = "Refine" // Removes the failed VMs
+	VmActionNone      VmAction = "None"   // TargetAction when no action is in progress
+)
+
+var vmStatuses = []VmStatus{
+	VmStatusPreparing, VmStatusPrepared, VmStatusCreating, VmStatusRunning, VmStatusSuspending, VmStatusSuspended,
+	VmStatusResuming, VmStatusRebooting, VmStatusTerminating, VmStatusTerminated, VmStatusRegistering, VmStatusFailed,
+	VmStatusUndefined, VmStatusEmpty, VmStatusNone,
+}
+
+var vmActions = []VmAction{
+	VmActionCreate, VmActionSuspend, VmActionResume, VmActionReboot, VmActionTerminate, VmActionRefine, VmActionNone,
+}
+
+// vmActionTransitions is the transition table: the statuses an action is allowed from,
+// and the transitional and target statuses of the action.
+var vmActionTransitions = map[VmAction]struct {
+	from         []VmStatus
+	transitional VmStatus
+	target       VmStatus
+}{
+	VmActionCreate:  {from: []VmStatus{VmStatusUndefined, VmStatusPrepared}, transitional: VmStatusCreating, target: VmStatusRunning}, // A VM that does not exist yet
+	VmActionSuspend: {from: []VmStatus{VmStatusRunning}, transitional: VmStatusSuspending, target: VmStatusSuspended},
+	VmActionResume:  {from: []VmStatus{VmStatusSuspended}, transitional: VmStatusResuming, target: VmStatusRunning},
+	VmActionReboot:  {from: []VmStatus{VmStatusRunning}, transitional: VmStatusRebooting, target: VmStatusRunning},
+	VmActionTerminate: {
+		from: []VmStatus{
+			VmStatusPreparing, VmStatusPrepared, VmStatusCreating, VmStatusRunning, VmStatusSuspending, VmStatusSuspended,
+			VmStatusResuming, VmStatusRebooting, VmStatusRegistering, VmStatusFailed, VmStatusUndefined,
+		},
+		transitional: VmStatusTerminating,
+		target:       VmStatusTerminated,
+	},
+	VmActionRefine: {from: []VmStatus{VmStatusFailed, VmStatusUndefined, VmStatusTerminated}, target: VmStatusNone},
+}
+
+// ParseVmStatus parses a status of CB-Tumblebug case-insensitively (e.g., "running" is VmStatusRunning).
+// An empty string is VmStatusUndefined.
+// Composite MCI statuses such as "Partial-Running:2 (R:2/3)" are parsed into their status; see ParseMciStatus.
+func ParseVmStatus(s string) (VmStatus, error) {
+	status, err := ParseMciStatus(s)
+	if err != nil {
+		return "", err
+	}
+	return status.Status, nil
+}
+
+// ParseVmAction parses an action of CB-Tumblebug case-insensitively. An empty string is VmActionNone.
+func ParseVmAction(s string) (VmAction, error) {
+	s = strings.TrimSpace(s)
+	if s == "" {
+		return VmActionNone, nil
+	}
+	for _, action := range vmActions {
+		if strings.EqualFold(s, string(action)) {
+			return action, nil
+		}
+	}
+	return "", fmt.Errorf("unknown VM action %q", s)
+}
+
+// MciStatus is a parsed MciInfo.Status.
+type MciStatus struct {
+	Status  VmStatus // Status of the VMs (the most common one if Partial)
+	Partial bool     // Only some of the VMs have Status (e.g., "Partial-Running")
+	Count   int      // Number of VMs with Status, if given (e.g., 2 of "Running:2")
+	Running int      // Number of running VMs, if given (e.g., 2 of "(R:2/3)")
+	Total   int      // Number of VMs, if given (e.g., 3 of "(R:2/3)")
+}
+
+// mciStatusPattern matches "[Partial-]Status[:Count][ (R:Running/Total)]".
+var mciStatusPattern = regexp.MustCompile(`^(?i)(partial-)?([a-z]+)(?::\s*(\d+))?(?:\s*\(R:\s*(\d+)\s*/\s*(\d+)\s*\))?$`)
+
+// ParseMciStatus parses a composite status of CB-Tumblebug such as "Partial-Running:2 (R:2/3)", "Running:3 (R:3/3)",
+// "Partial-Suspended" or "Running". An empty string is VmStatusUndefined.
+func ParseMciStatus(s string) (MciStatus, error) {
+	s = strings.TrimSpace(s)
+	if s == "" {
+		return MciStatus{Status: VmStatusUndefined}, nil
+	}
+	m := mciStatusPattern.FindStringSubmatch(s)
+	if m == nil {
+		return MciStatus{}, fmt.Errorf("invalid status %q", s)
+	}
+
+	var parsed MciStatus
+	for _, status := range vmStatuses {
+		if strings.EqualFold(m[2], string(status)) {
+			parsed.Status = status
+			break
+		}
+	}
+	if parsed.Status == "" {
+		return MciStatus{}, fmt.Errorf("unknown status %q", s)
+	}
+	parsed.Partial = m[1] != ""
+	parsed.Count, _ = strconv.Atoi(m[3])
+	parsed.Running, _ = strconv.Atoi(m[4])
+	parsed.Total, _ = strconv.Atoi(m[5])
+	return parsed, nil
+}
+
+// String formats the status as CB-Tumblebug does.
+func (s MciStatus) String() string {
+	str := string(s.Status)
+	if s.Partial {
+		str = "Partial-" + str
+	}
+	if s.Count > 0 {
+		str += ":" + strconv.Itoa(s.Count)
+	}
+	if s.Total > 0 {
+		str += fmt.Sprintf(" (R:%d/%d)", s.Running, s.Total)
+	}
+	return str
+}
+
+// IsTransitional returns true if the status is in the middle of an action (e.g., Suspending).
+func (s VmStatus) IsTransitional() bool {
+	for _, t := range vmActionTransitions {
+		if t.transitional != "" && t.transitional == s {
+			return true
+		}
+	}
+	return false
+}
+
+// IsActionAllowed returns true if the action is valid for a VM (or all VMs of an MCI) in the current status.
+// An empty status is VmStatusUndefined, as in ParseVmStatus; VmActionCreate is only valid from it (a VM that does not exist yet)
+// and from VmStatusPrepared.
+func IsActionAllowed(current VmStatus, action VmAction) bool {
+	t, ok := vmActionTransitions[action]
+	if !ok {
+		return false
+	}
+	if current == "" {
+		current = VmStatusUndefined
+	}
+	for _, from := range t.from {
+		if from == current {
+			return true
+		}
+	}
+	return false
+}
+
+// TransitionalStatus returns the status of a VM during the action (e.g., Suspending for Suspend), or "" if there is none.
+func (a VmAction) TransitionalStatus() VmStatus {
+	return vmActionTransitions[a].transitional
+}
+
+// TargetStatus returns the status of a VM after the action (e.g., Suspended for Suspend), or "" for an unknown action.
+func (a VmAction) TargetStatus() VmStatus {
+	return vmActionTransitions[a].target
+}
+
+// ComputeStatusCount counts the VMs by status. A VM with an unknown status, or a status without a counter
+// in StatusCountInfo (Preparing, Prepared, Empty and None), is counted as undefined.
+func ComputeStatusCount(vms []VmInfo) StatusCountInfo {
+	count := StatusCountInfo{CountTotal: len(vms)}
+	for _, vm := range vms {
+		status, err := ParseVmStatus(vm.Status)
+		if err != nil {
+			status = VmStatusUndefined
+		}
+		switch status {
+		case VmStatusCreating:
+			count.CountCreating++
+		case VmStatusRunning:
+			count.CountRunning++
+		case VmStatusFailed:
+			count.CountFailed++
+		case VmStatusSuspended:
+			count.CountSuspended++
+		case VmStatusRebooting:
+			count.CountRebooting++
+		case VmStatusTerminated:
+			count.CountTerminated++
+		case VmStatusSuspending:
+			count.CountSuspending++
+		case VmStatusResuming:
+			count.CountResuming++
+		case VmStatusTerminating:
+			count.CountTerminating++
+		case VmStatusRegistering:
+			count.CountRegistering++
+		default:
+			count.CountUndefined++
+		}
+	}
+	return count
+}
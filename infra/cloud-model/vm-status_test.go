@@ -0,0 +1,134 @@
+package cloudmodel
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestIsActionAllowed(t *testing.T) {
+	parse := func(s string) VmStatus {
+		status, err := ParseVmStatus(s)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return status
+	}
+	tests := []struct {
+		current VmStatus
+		action  VmAction
+		want    bool
+	}{
+		{"", VmActionCreate, true},
+		{parse(""), VmActionCreate, true},
+		{VmStatusUndefined, VmActionCreate, true},
+		{VmStatusRunning, VmActionCreate, false},
+		{VmStatusTerminated, VmActionCreate, false},
+		{VmStatusPrepared, VmActionCreate, true},
+		{VmStatusPreparing, VmActionCreate, false},
+		{VmStatusPreparing, VmActionTerminate, true},
+		{VmStatusEmpty, VmActionSuspend, false},
+		{VmStatusRunning, VmActionSuspend, true},
+		{parse("Partial-Suspended:1 (R:0/2)"), VmActionResume, true},
+		{VmStatusSuspended, VmActionReboot, false},
+		{"", VmActionTerminate, true},
+		{VmStatusTerminated, VmActionTerminate, false},
+		{VmStatusFailed, VmActionRefine, true},
+		{VmStatusRunning, VmActionNone, false},
+	}
+
+	for _, tt := range tests {
+		if got := IsActionAllowed(tt.current, tt.action); got != tt.want {
+			t.Errorf("IsActionAllowed(%q, %q) = %v, want %v", tt.current, tt.action, got, tt.want)
+		}
+	}
+}
+
+func TestParseMciStatus(t *testing.T) {
+	tests := []struct {
+		s       string
+		want    MciStatus
+		wantErr bool
+	}{
+		{s: "", want: MciStatus{Status: VmStatusUndefined}},
+		{s: "  ", want: MciStatus{Status: VmStatusUndefined}},
+		{s: "Running", want: MciStatus{Status: VmStatusRunning}},
+		{s: "running", want: MciStatus{Status: VmStatusRunning}},
+		{s: "Running:3 (R:3/3)", want: MciStatus{Status: VmStatusRunning, Count: 3, Running: 3, Total: 3}},
+		{s: "Partial-Running:2 (R:2/3)", want: MciStatus{Status: VmStatusRunning, Partial: true, Count: 2, Running: 2, Total: 3}},
+		{s: "partial-suspended:1 (R: 0 / 2)", want: MciStatus{Status: VmStatusSuspended, Partial: true, Count: 1, Total: 2}},
+		{s: "Partial-Suspended", want: MciStatus{Status: VmStatusSuspended, Partial: true}},
+		{s: "Terminated:2", want: MciStatus{Status: VmStatusTerminated, Count: 2}},
+		{s: "Failed (R:0/1)", want: MciStatus{Status: VmStatusFailed, Total: 1}},
+		{s: "Preparing", want: MciStatus{Status: VmStatusPreparing}},
+		{s: "Prepared:2 (R:0/2)", want: MciStatus{Status: VmStatusPrepared, Count: 2, Total: 2}},
+		{s: "Empty", want: MciStatus{Status: VmStatusEmpty}},
+		{s: "None", want: MciStatus{Status: VmStatusNone}},
+		{s: "Sleeping", wantErr: true},
+		{s: "Partial-", wantErr: true},
+		{s: "Running:", wantErr: true},
+		{s: "Running:two", wantErr: true},
+		{s: "Running (R:2)", wantErr: true},
+		{s: "Running:2 (R:2/3) extra", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		got, err := ParseMciStatus(tt.s)
+		if tt.wantErr {
+			if err == nil {
+				t.Errorf("ParseMciStatus(%q) = %+v, want an error", tt.s, got)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("ParseMciStatus(%q) error = %v", tt.s, err)
+			continue
+		}
+		if got != tt.want {
+			t.Errorf("ParseMciStatus(%q) = %+v, want %+v", tt.s, got, tt.want)
+		}
+	}
+}
+
+func TestMciStatusString(t *testing.T) {
+	for _, s := range []string{"Running", "Partial-Running:2 (R:2/3)", "Running:3 (R:3/3)", "Partial-Suspended", "Prepared:2", "Empty"} {
+		status, err := ParseMciStatus(s)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got := status.String(); got != s {
+			t.Errorf("ParseMciStatus(%q).String() = %q", s, got)
+		}
+	}
+}
+
+func TestComputeStatusCount(t *testing.T) {
+	var vms []VmInfo
+	for _, status := range []string{
+		"Running", "running", "Partial-Running:1 (R:1/2)", "Creating", "Failed", "Suspended", "Rebooting",
+		"Terminated", "Suspending", "Resuming", "Terminating", "Registering",
+		"Undefined", "", "Preparing", "Prepared", "Empty", "Sleeping",
+	} {
+		vms = append(vms, VmInfo{Status: status})
+	}
+
+	want := StatusCountInfo{
+		CountTotal:       18,
+		CountRunning:     3,
+		CountCreating:    1,
+		CountFailed:      1,
+		CountSuspended:   1,
+		CountRebooting:   1,
+		CountTerminated:  1,
+		CountSuspending:  1,
+		CountResuming:    1,
+		CountTerminating: 1,
+		CountRegistering: 1,
+		CountUndefined:   6, // Undefined, empty, Preparing, Prepared, Empty and an unknown status
+	}
+	if got := ComputeStatusCount(vms); !reflect.DeepEqual(got, want) {
+		t.Errorf("ComputeStatusCount() = %+v\nwant %+v", got, want)
+	}
+	if got := ComputeStatusCount(nil); got != (StatusCountInfo{}) {
+		t.Errorf("ComputeStatusCount(nil) = %+v, want zero counts", got)
+	}
+}
@@ -0,0 +1,285 @@
+package cloudmodel
+
+import (
+	"encoding/binary"
+	"fmt"
+	"net/netip"
+	"sort"
+	"strings"
+
+	onpremisemodel "github.com/cloud-barista/cm-model/infra/on-premise-model"
+)
+
+// VNetPlanMode represents how the source address plan is mapped to the target vNet.
+type VNetPlanMode string
+
+const (
+	VNetPlanPreserve VNetPlanMode = "preserve" // Mirror the source subnets to keep the original IPs (re-base only if impossible)
+	VNetPlanCompact  VNetPlanMode = "compact"  // Re-base the source subnets sequentially into the base CIDR block
+)
+
+// VNetPlanOptions represents the options of PlanVNet.
+type VNetPlanOptions struct {
+	Mode            VNetPlanMode `json:"mode" default:"preserve" enums:"preserve,compact"`
+	Name            string       `json:"name" default:"vnet-01"`
+	ConnectionName  string       `json:"connectionName"`
+	BaseCidrBlock   string       `json:"baseCidrBlock" default:"10.0.0.0/16"` // Address space to re-base the subnets into
+	MinPrefixLength int          `json:"minPrefixLength" default:"16"`        // Prefix length of the largest vNet allowed by the target cloud
+	MaxPrefixLength int          `json:"maxPrefixLength" default:"28"`        // Prefix length of the smallest subnet allowed by the target cloud
+}
+
+// SubnetMapping represents a source subnet mapped to a target subnet.
+type SubnetMapping struct {
+	SourceCidrBlock string `json:"sourceCidrBlock"`
+	TargetCidrBlock string `json:"targetCidrBlock"`
+	SubnetName      string `json:"subnetName"`
+}
+
+// VNetPlan represents the recommended vNet and how the source subnets are mapped into it.
+type VNetPlan struct {
+	RecommendedVNet RecommendedVNet `json:"recommendedVNet"`
+	SubnetMappings  []SubnetMapping `json:"subnetMappings"`
+}
+
+// PlanVNet recommends a target vNet covering the source address space, with subnets mirroring the source subnets
+// (i.e., the IPv4 interface subnets of the servers). In preserve mode, the subnets and the vNet CIDR block are kept
+// as in the source (using the CIDR blocks of the network if they fit), unless the subnets overlap each other,
+// are not private (RFC 1918), or cannot be covered by a vNet of MinPrefixLength; then they are re-based as in compact mode.
+// In compact mode, the subnets are allocated sequentially, largest first, from BaseCidrBlock.
+func PlanVNet(network onpremisemodel.NetworkProperty, servers []onpremisemodel.ServerProperty, opts VNetPlanOptions) (VNetPlan, error) {
+	opts = opts.withDefaults()
+	base, err := netip.ParsePrefix(opts.BaseCidrBlock)
+	if err != nil || !base.Addr().Is4() {
+		return VNetPlan{}, fmt.Errorf("invalid base CIDR block %q", opts.BaseCidrBlock)
+	}
+	base = base.Masked()
+
+	subnets := sourceSubnets(servers, opts.MaxPrefixLength)
+	if len(subnets) == 0 {
+		// No source subnet: a default address plan
+		plan, err := compactPlan([]netip.Prefix{netip.PrefixFrom(base.Addr(), max(24, base.Bits()))}, base)
+		if err != nil {
+			return VNetPlan{}, err
+		}
+		plan.SubnetMappings[0].SourceCidrBlock = ""
+		return opts.build(plan, StatusPartiallyRecommended, "no source subnet found; a default address plan is recommended"), nil
+	}
+
+	reasons := preserveBlockers(subnets, opts)
+	if opts.Mode == VNetPlanPreserve && len(reasons) == 0 {
+		plan := preservePlan(network, subnets, opts)
+		return opts.build(plan, StatusRecommended, "source subnets are preserved"), nil
+	}
+
+	plan, err := compactPlan(subnets, base)
+	if err != nil {
+		return VNetPlan{}, err
+	}
+	if opts.Mode == VNetPlanPreserve {
+		return opts.build(plan, StatusPartiallyRecommended, "source subnets are re-based because "+strings.Join(reasons, ", ")), nil
+	}
+	return opts.build(plan, StatusRecommended, "source subnets are re-based into "+base.String()), nil
+}
+
+// SubnetFor returns the name of the target subnet for the server:
+// the subnet of the interface of the default route, or else of the first interface with a mapped subnet.
+func (p VNetPlan) SubnetFor(server onpremisemodel.ServerProperty) (string, bool) {
+	primary := ""
+	for _, route := range server.RoutingTable {
+		if route.Destination == "default" || route.Destination == "0.0.0.0/0" {
+			primary = route.Interface
+			break
+		}
+	}
+
+	var candidates []onpremisemodel.NetworkInterfaceProperty
+	for _, iface := range server.Interfaces {
+		if iface.Name == primary {
+			candidates = append([]onpremisemodel.NetworkInterfaceProperty{iface}, candidates...)
+		} else {
+			candidates = append(candidates, iface)
+		}
+	}
+	for _, iface := range candidates {
+		for _, block := range iface.IPv4CidrBlocks {
+			prefix, err := netip.ParsePrefix(block)
+			if err != nil {
+				continue
+			}
+			for _, m := range p.SubnetMappings {
+				if source, err := netip.ParsePrefix(m.SourceCidrBlock); err == nil && source.Contains(prefix.Addr()) {
+					return m.SubnetName, true
+				}
+			}
+		}
+	}
+	return "", false
+}
+
+func (opts VNetPlanOptions) withDefaults() VNetPlanOptions {
+	if opts.Mode == "" {
+		opts.Mode = VNetPlanPreserve
+	}
+	if opts.Name == "" {
+		opts.Name = "vnet-01"
+	}
+	if opts.BaseCidrBlock == "" {
+		opts.BaseCidrBlock = "10.0.0.0/16"
+	}
+	if opts.MinPrefixLength == 0 {
+		opts.MinPrefixLength = 16
+	}
+	if opts.MaxPrefixLength == 0 {
+		opts.MaxPrefixLength = 28
+	}
+	return opts
+}
+
+// addressPlan is a vNet CIDR block with the mapped subnets.
+type addressPlan struct {
+	CidrBlock      netip.Prefix
+	SubnetMappings []SubnetMapping
+}
+
+func (opts VNetPlanOptions) build(plan addressPlan, status, description string) VNetPlan {
+	vnet := VNetReq{
+		Name:           opts.Name,
+		ConnectionName: opts.ConnectionName,
+		CidrBlock:      plan.CidrBlock.String(),
+		Description:    "Recommended vNet (" + description + ")",
+	}
+	for _, m := range plan.SubnetMappings {
+		desc := "Recommended subnet"
+		if m.SourceCidrBlock != "" {
+			desc += " for the source subnet " + m.SourceCidrBlock
+		}
+		vnet.SubnetInfoList = append(vnet.SubnetInfoList, SubnetReq{Name: m.SubnetName, IPv4_CIDR: m.TargetCidrBlock, Description: desc})
+	}
+
+	return VNetPlan{
+		RecommendedVNet: RecommendedVNet{Status: status, Description: description, TargetVNet: vnet},
+		SubnetMappings:  plan.SubnetMappings,
+	}
+}
+
+// sourceSubnets returns the deduplicated IPv4 interface subnets of the servers sorted by address.
+// Subnets smaller than the smallest allowed subnet are widened.
+func sourceSubnets(servers []onpremisemodel.ServerProperty, maxPrefixLength int) []netip.Prefix {
+	seen := make(map[netip.Prefix]bool)
+	var subnets []netip.Prefix
+	for _, server := range servers {
+		for _, iface := range server.Interfaces {
+			for _, block := range iface.IPv4CidrBlocks {
+				prefix, err := netip.ParsePrefix(block)
+				if err != nil || !prefix.Addr().Is4() || prefix.Addr().IsLoopback() || prefix.Addr().IsLinkLocalUnicast() {
+					continue
+				}
+				subnet := netip.PrefixFrom(prefix.Addr(), min(prefix.Bits(), maxPrefixLength)).Masked()
+				if !seen[subnet] {
+					seen[subnet] = true
+					subnets = append(subnets, subnet)
+				}
+			}
+		}
+	}
+	sort.Slice(subnets, func(i, j int) bool { return subnets[i].Addr().Less(subnets[j].Addr()) })
+	return subnets
+}
+
+// preserveBlockers returns the reasons why the source subnets cannot be preserved.
+func preserveBlockers(subnets []netip.Prefix, opts VNetPlanOptions) []string {
+	var reasons []string
+	for i, a := range subnets {
+		for _, b := range subnets[i+1:] {
+			if a.Overlaps(b) {
+				reasons = append(reasons, fmt.Sprintf("%s overlaps %s", a, b))
+			}
+		}
+		if !a.Addr().IsPrivate() {
+			reasons = append(reasons, fmt.Sprintf("%s is not a private (RFC 1918) address space", a))
+		}
+		if a.Bits() < opts.MinPrefixLength {
+			reasons = append(reasons, fmt.Sprintf("%s is larger than /%d", a, opts.MinPrefixLength))
+		}
+	}
+	if super, _ := onpremisemodel.Supernet(subnets...); super.Bits() < opts.MinPrefixLength {
+		reasons = append(reasons, fmt.Sprintf("covering the subnets requires %s, larger than /%d", super, opts.MinPrefixLength))
+	}
+	return reasons
+}
+
+// preservePlan mirrors the subnets in a vNet covering the source address space.
+// The CIDR blocks of the network are used if they can be covered by an allowed vNet.
+func preservePlan(network onpremisemodel.NetworkProperty, subnets []netip.Prefix, opts VNetPlanOptions) addressPlan {
+	cidrBlock, _ := onpremisemodel.Supernet(subnets...)
+
+	spaces := append([]netip.Prefix(nil), subnets...)
+	for _, block := range network.IPv4Networks.CidrBlocks {
+		if prefix, err := netip.ParsePrefix(block); err == nil && prefix.Addr().Is4() && prefix.Addr().IsPrivate() {
+			spaces = append(spaces, prefix.Masked())
+		}
+	}
+	if super, ok := onpremisemodel.Supernet(spaces...); ok && super.Bits() >= opts.MinPrefixLength {
+		cidrBlock = super
+	}
+
+	plan := addressPlan{CidrBlock: cidrBlock}
+	for i, subnet := range subnets {
+		plan.SubnetMappings = append(plan.SubnetMappings, SubnetMapping{
+			SourceCidrBlock: subnet.String(),
+			TargetCidrBlock: subnet.String(),
+			SubnetName:      fmt.Sprintf("subnet-%02d", i+1),
+		})
+	}
+	return plan
+}
+
+// compactPlan allocates the subnets, largest first, sequentially from the start of the base CIDR block.
+// Overlapping source subnets are allocated separately.
+func compactPlan(subnets []netip.Prefix, base netip.Prefix) (addressPlan, error) {
+	order := append([]netip.Prefix(nil), subnets...)
+	sort.SliceStable(order, func(i, j int) bool { return order[i].Bits() < order[j].Bits() })
+
+	next := addrToUint(base.Addr())
+	end := next + blockSize(base.Bits())
+	allocated := make(map[netip.Prefix]netip.Prefix, len(order))
+	for _, subnet := range order {
+		if subnet.Bits() < base.Bits() {
+			return addressPlan{}, fmt.Errorf("subnet %s is larger than the base CIDR block %s", subnet, base)
+		}
+		size := blockSize(subnet.Bits())
+		// Align to the subnet size
+		if rem := next % size; rem != 0 {
+			next += size - rem
+		}
+		if next+size > end {
+			return addressPlan{}, fmt.Errorf("source subnets do not fit into the base CIDR block %s", base)
+		}
+		allocated[subnet] = netip.PrefixFrom(uintToAddr(next), subnet.Bits())
+		next += size
+	}
+
+	plan := addressPlan{CidrBlock: base}
+	for i, subnet := range subnets {
+		plan.SubnetMappings = append(plan.SubnetMappings, SubnetMapping{
+			SourceCidrBlock: subnet.String(),
+			TargetCidrBlock: allocated[subnet].String(),
+			SubnetName:      fmt.Sprintf("subnet-%02d", i+1),
+		})
+	}
+	return plan, nil
+}
+
+func blockSize(bits int) uint64 {
+	return 1 << (32 - bits)
+}
+
+func addrToUint(addr netip.Addr) uint64 {
+	return uint64(binary.BigEndian.Uint32(addr.AsSlice()))
+}
+
+func uintToAddr(i uint64) netip.Addr {
+	var b [4]byte
+	binary.BigEndian.PutUint32(b[:], uint32(i))
+	return netip.AddrFrom4(b)
+}
@@ -0,0 +1,249 @@
+package cloudmodel
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+	"testing"
+
+	onpremisemodel "github.com/cloud-barista/cm-model/infra/on-premise-model"
+)
+
+// serversIn returns a server for each of the interface CIDR blocks.
+func serversIn(cidrs ...string) []onpremisemodel.ServerProperty {
+	var servers []onpremisemodel.ServerProperty
+	for _, cidr := range cidrs {
+		servers = append(servers, onpremisemodel.ServerProperty{
+			Interfaces: []onpremisemodel.NetworkInterfaceProperty{
+				{Name: "lo", IPv4CidrBlocks: []string{"127.0.0.1/8"}},
+				{Name: "eth0", IPv4CidrBlocks: []string{cidr, "169.254.1.1/16"}},
+			},
+		})
+	}
+	return servers
+}
+
+func TestPlanVNet(t *testing.T) {
+	network := func(cidrs ...string) onpremisemodel.NetworkProperty {
+		return onpremisemodel.NetworkProperty{IPv4Networks: onpremisemodel.NetworkDetail{CidrBlocks: cidrs}}
+	}
+	mapping := func(source, target string, i int) SubnetMapping {
+		return SubnetMapping{SourceCidrBlock: source, TargetCidrBlock: target, SubnetName: fmt.Sprintf("subnet-%02d", i)}
+	}
+	tests := []struct {
+		name      string
+		network   onpremisemodel.NetworkProperty
+		servers   []onpremisemodel.ServerProperty
+		opts      VNetPlanOptions
+		wantCidr  string
+		want      []SubnetMapping
+		wantState string
+		wantDesc  string // Substring of the description
+		wantErr   string
+	}{
+		{
+			name:      "preserve with the network block",
+			network:   network("10.0.0.0/16"),
+			servers:   serversIn("10.0.2.5/24", "10.0.1.21/24", "10.0.1.22/24"),
+			wantCidr:  "10.0.0.0/16",
+			want:      []SubnetMapping{mapping("10.0.1.0/24", "10.0.1.0/24", 1), mapping("10.0.2.0/24", "10.0.2.0/24", 2)},
+			wantState: StatusRecommended,
+			wantDesc:  "preserved",
+		},
+		{
+			name:      "preserve without network block",
+			servers:   serversIn("10.0.1.21/24", "10.0.2.5/24"),
+			wantCidr:  "10.0.0.0/22",
+			want:      []SubnetMapping{mapping("10.0.1.0/24", "10.0.1.0/24", 1), mapping("10.0.2.0/24", "10.0.2.0/24", 2)},
+			wantState: StatusRecommended,
+		},
+		{
+			name:      "preserve ignoring public and too large network blocks",
+			network:   network("203.0.113.0/24", "10.0.0.0/8"),
+			servers:   serversIn("10.0.1.21/24"),
+			wantCidr:  "10.0.1.0/24",
+			want:      []SubnetMapping{mapping("10.0.1.0/24", "10.0.1.0/24", 1)},
+			wantState: StatusRecommended,
+		},
+		{
+			name:      "preserve widening a subnet smaller than allowed",
+			servers:   serversIn("192.168.7.9/30"),
+			wantCidr:  "192.168.7.0/28",
+			want:      []SubnetMapping{mapping("192.168.7.0/28", "192.168.7.0/28", 1)},
+			wantState: StatusRecommended,
+		},
+		{
+			name:      "preserve re-based for overlapping subnets",
+			servers:   serversIn("10.0.1.21/24", "10.0.200.1/16"),
+			opts:      VNetPlanOptions{BaseCidrBlock: "172.16.0.0/12"},
+			wantCidr:  "172.16.0.0/12",
+			want:      []SubnetMapping{mapping("10.0.0.0/16", "172.16.0.0/16", 1), mapping("10.0.1.0/24", "172.17.0.0/24", 2)},
+			wantState: StatusPartiallyRecommended,
+			wantDesc:  "re-based because 10.0.0.0/16 overlaps 10.0.1.0/24",
+		},
+		{
+			name:      "preserve re-based for a public subnet",
+			servers:   serversIn("203.0.113.10/28"),
+			wantCidr:  "10.0.0.0/16",
+			want:      []SubnetMapping{mapping("203.0.113.0/28", "10.0.0.0/28", 1)},
+			wantState: StatusPartiallyRecommended,
+			wantDesc:  "203.0.113.0/28 is not a private (RFC 1918) address space",
+		},
+		{
+			name:      "preserve re-based for a subnet larger than allowed",
+			servers:   serversIn("10.0.1.21/16"),
+			opts:      VNetPlanOptions{MinPrefixLength: 20, BaseCidrBlock: "10.0.0.0/8"},
+			wantCidr:  "10.0.0.0/8",
+			want:      []SubnetMapping{mapping("10.0.0.0/16", "10.0.0.0/16", 1)},
+			wantState: StatusPartiallyRecommended,
+			wantDesc:  "10.0.0.0/16 is larger than /20",
+		},
+		{
+			name:      "preserve re-based for distant subnets",
+			servers:   serversIn("192.168.1.5/24", "10.0.1.21/24"),
+			wantCidr:  "10.0.0.0/16",
+			want:      []SubnetMapping{mapping("10.0.1.0/24", "10.0.0.0/24", 1), mapping("192.168.1.0/24", "10.0.1.0/24", 2)},
+			wantState: StatusPartiallyRecommended,
+			wantDesc:  "covering the subnets requires 0.0.0.0/0, larger than /16",
+		},
+		{
+			name:      "compact largest first",
+			servers:   serversIn("10.1.5.7/24", "10.2.1.1/22", "10.3.0.9/30"),
+			opts:      VNetPlanOptions{Mode: VNetPlanCompact, Name: "vnet-a", ConnectionName: "aws-ap-northeast-2"},
+			wantCidr:  "10.0.0.0/16",
+			want:      []SubnetMapping{mapping("10.1.5.0/24", "10.0.4.0/24", 1), mapping("10.2.0.0/22", "10.0.0.0/22", 2), mapping("10.3.0.0/28", "10.0.5.0/28", 3)},
+			wantState: StatusRecommended,
+			wantDesc:  "re-based into 10.0.0.0/16",
+		},
+		{
+			name:      "compact into an unmasked base",
+			servers:   serversIn("10.1.5.7/24"),
+			opts:      VNetPlanOptions{Mode: VNetPlanCompact, BaseCidrBlock: "192.168.3.4/23"},
+			wantCidr:  "192.168.2.0/23",
+			want:      []SubnetMapping{mapping("10.1.5.0/24", "192.168.2.0/24", 1)},
+			wantState: StatusRecommended,
+		},
+		{
+			name:      "no source subnet",
+			servers:   []onpremisemodel.ServerProperty{{Hostname: "without-interface"}},
+			wantCidr:  "10.0.0.0/16",
+			want:      []SubnetMapping{mapping("", "10.0.0.0/24", 1)},
+			wantState: StatusPartiallyRecommended,
+			wantDesc:  "default address plan",
+		},
+		{
+			name:      "no source subnet in a small base",
+			opts:      VNetPlanOptions{BaseCidrBlock: "10.9.9.0/26"},
+			wantCidr:  "10.9.9.0/26",
+			want:      []SubnetMapping{mapping("", "10.9.9.0/26", 1)},
+			wantState: StatusPartiallyRecommended,
+		},
+		{
+			name:    "invalid base",
+			servers: serversIn("10.0.1.21/24"),
+			opts:    VNetPlanOptions{BaseCidrBlock: "10.0.0.0"},
+			wantErr: `invalid base CIDR block "10.0.0.0"`,
+		},
+		{
+			name:    "IPv6 base",
+			servers: serversIn("10.0.1.21/24"),
+			opts:    VNetPlanOptions{BaseCidrBlock: "fd00::/48"},
+			wantErr: "invalid base CIDR block",
+		},
+		{
+			name:    "compact subnet larger than the base",
+			servers: serversIn("10.1.0.1/22"),
+			opts:    VNetPlanOptions{Mode: VNetPlanCompact, BaseCidrBlock: "10.0.0.0/24"},
+			wantErr: "subnet 10.1.0.0/22 is larger than the base CIDR block 10.0.0.0/24",
+		},
+		{
+			name:    "compact exhausting the base",
+			servers: serversIn("10.1.0.1/25", "10.2.0.1/25", "10.3.0.1/28"),
+			opts:    VNetPlanOptions{Mode: VNetPlanCompact, BaseCidrBlock: "10.0.0.0/24"},
+			wantErr: "source subnets do not fit into the base CIDR block 10.0.0.0/24",
+		},
+		{
+			name:    "preserve re-based exhausting the base",
+			servers: serversIn("10.0.1.21/24", "10.0.200.1/16"), // Overlapping, and the /16 fills the default base
+			wantErr: "do not fit",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			plan, err := PlanVNet(tt.network, tt.servers, tt.opts)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("PlanVNet() error = %v, want %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("PlanVNet() error = %v", err)
+			}
+
+			vnet := plan.RecommendedVNet
+			if vnet.TargetVNet.CidrBlock != tt.wantCidr {
+				t.Errorf("CIDR block %s, want %s", vnet.TargetVNet.CidrBlock, tt.wantCidr)
+			}
+			if !reflect.DeepEqual(plan.SubnetMappings, tt.want) {
+				t.Errorf("subnet mappings %+v\nwant %+v", plan.SubnetMappings, tt.want)
+			}
+			if vnet.Status != tt.wantState || !strings.Contains(vnet.Description, tt.wantDesc) {
+				t.Errorf("status %s (%s), want %s (%s)", vnet.Status, vnet.Description, tt.wantState, tt.wantDesc)
+			}
+
+			// The subnets of the vNet follow the mappings, within the vNet
+			if len(vnet.TargetVNet.SubnetInfoList) != len(tt.want) {
+				t.Fatalf("%d subnets, want %d", len(vnet.TargetVNet.SubnetInfoList), len(tt.want))
+			}
+			for i, subnet := range vnet.TargetVNet.SubnetInfoList {
+				if subnet.Name != tt.want[i].SubnetName || subnet.IPv4_CIDR != tt.want[i].TargetCidrBlock {
+					t.Errorf("subnet %d: %s %s, want %s %s", i, subnet.Name, subnet.IPv4_CIDR, tt.want[i].SubnetName, tt.want[i].TargetCidrBlock)
+				}
+			}
+			wantName, wantConn := tt.opts.Name, tt.opts.ConnectionName
+			if wantName == "" {
+				wantName = "vnet-01"
+			}
+			if vnet.TargetVNet.Name != wantName || vnet.TargetVNet.ConnectionName != wantConn {
+				t.Errorf("vNet %s on %q, want %s on %q", vnet.TargetVNet.Name, vnet.TargetVNet.ConnectionName, wantName, wantConn)
+			}
+		})
+	}
+}
+
+func TestSubnetFor(t *testing.T) {
+	plan, err := PlanVNet(onpremisemodel.NetworkProperty{}, serversIn("10.0.1.21/24", "10.0.2.5/24"), VNetPlanOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	server := onpremisemodel.ServerProperty{
+		Interfaces: []onpremisemodel.NetworkInterfaceProperty{
+			{Name: "eth0", IPv4CidrBlocks: []string{"10.0.1.30/24"}},
+			{Name: "eth1", IPv4CidrBlocks: []string{"10.0.2.30/24"}},
+		},
+	}
+
+	tests := []struct {
+		name   string
+		routes []onpremisemodel.RouteProperty
+		want   string
+	}{
+		{"first interface without default route", nil, "subnet-01"},
+		{"interface of the default route", []onpremisemodel.RouteProperty{{Destination: "default", Interface: "eth1"}}, "subnet-02"},
+		{"interface of 0.0.0.0/0", []onpremisemodel.RouteProperty{{Destination: "0.0.0.0/0", Interface: "eth1"}}, "subnet-02"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server.RoutingTable = tt.routes
+			if got, ok := plan.SubnetFor(server); !ok || got != tt.want {
+				t.Errorf("SubnetFor() = %s, %v, want %s", got, ok, tt.want)
+			}
+		})
+	}
+
+	if got, ok := plan.SubnetFor(serversIn("192.168.1.1/24")[0]); ok {
+		t.Errorf("SubnetFor() = %s for an unmapped subnet", got)
+	}
+}
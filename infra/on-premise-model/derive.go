@@ -0,0 +1,233 @@
+package onpremisemodel
+
+import (
+	"net/netip"
+	"sort"
+)
+
+// privateBlocks are the address blocks of private networks.
+// Interface subnets within the same block are estimated to belong to one upper layer address space.
+var privateBlocks = []netip.Prefix{
+	netip.MustParsePrefix("10.0.0.0/8"),
+	netip.MustParsePrefix("172.16.0.0/12"),
+	netip.MustParsePrefix("192.168.0.0/16"),
+	netip.MustParsePrefix("100.64.0.0/10"), // Shared address space (RFC 6598)
+	netip.MustParsePrefix("fc00::/7"),      // Unique local addresses (RFC 4193)
+}
+
+// DeriveNetworkProperty completes the given network (e.g., input by a network operator) with the network
+// estimated from the collected data of the servers. The given CIDR blocks and default gateways are kept as they are.
+//   - DefaultGateways: the gateways of the default routes (i.e., "default", 0.0.0.0/0, ::/0) in each routing table,
+//     deduplicated by IP address. The interface name and machine ID of the first server using the gateway are kept.
+//   - CidrBlocks: for the interface subnets outside the given CIDR blocks, the smallest supernet covering them
+//     within each private address block, and the interface subnets themselves for public addresses.
+//     Nested blocks are removed, and a supernet overlapping a given CIDR block is replaced by its subnets.
+//
+// Loopback, link-local and unspecified addresses are ignored.
+func DeriveNetworkProperty(network NetworkProperty, servers []ServerProperty) NetworkProperty {
+	var v4Subnets, v6Subnets []netip.Prefix
+	var v4Gateways, v6Gateways []GatewayProperty
+	seenGateways := make(map[netip.Addr]bool)
+
+	for _, server := range servers {
+		for _, iface := range server.Interfaces {
+			for _, block := range append(append([]string{}, iface.IPv4CidrBlocks...), iface.IPv6CidrBlocks...) {
+				prefix, err := netip.ParsePrefix(block)
+				if err != nil || !isRoutable(prefix.Addr()) {
+					continue
+				}
+				if prefix.Addr().Is4() {
+					v4Subnets = append(v4Subnets, prefix.Masked())
+				} else {
+					v6Subnets = append(v6Subnets, prefix.Masked())
+				}
+			}
+		}
+
+		for _, route := range server.RoutingTable {
+			if !isDefaultRoute(route.Destination) {
+				continue
+			}
+			gw, err := netip.ParseAddr(route.Gateway)
+			if err != nil || gw.IsUnspecified() || seenGateways[gw] {
+				continue
+			}
+			seenGateways[gw] = true
+			gateway := GatewayProperty{IP: gw.String(), InterfaceName: route.Interface, MachineId: server.MachineId}
+			if gw.Is4() {
+				v4Gateways = append(v4Gateways, gateway)
+			} else {
+				v6Gateways = append(v6Gateways, gateway)
+			}
+		}
+	}
+
+	return NetworkProperty{
+		IPv4Networks: deriveNetworkDetail(network.IPv4Networks, v4Subnets, v4Gateways),
+		IPv6Networks: deriveNetworkDetail(network.IPv6Networks, v6Subnets, v6Gateways),
+	}
+}
+
+// deriveNetworkDetail appends the address space estimated from the subnets outside the given CIDR blocks
+// and the gateways of other IP addresses to the given network detail.
+func deriveNetworkDetail(given NetworkDetail, subnets []netip.Prefix, gateways []GatewayProperty) NetworkDetail {
+	var blocks []netip.Prefix
+	for _, block := range given.CidrBlocks {
+		if prefix, err := netip.ParsePrefix(block); err == nil {
+			blocks = append(blocks, prefix.Masked())
+		}
+	}
+	var uncovered []netip.Prefix
+	for _, subnet := range subnets {
+		if !isCovered(subnet, blocks) {
+			uncovered = append(uncovered, subnet)
+		}
+	}
+
+	detail := NetworkDetail{
+		CidrBlocks:      append(append([]string(nil), given.CidrBlocks...), formatPrefixes(estimateAddressSpace(uncovered, blocks))...),
+		DefaultGateways: append([]GatewayProperty(nil), given.DefaultGateways...),
+	}
+	givenGateways := make(map[netip.Addr]bool, len(given.DefaultGateways))
+	for _, gateway := range given.DefaultGateways {
+		if gw, err := netip.ParseAddr(gateway.IP); err == nil {
+			givenGateways[gw] = true
+		}
+	}
+	for _, gateway := range gateways {
+		if !givenGateways[netip.MustParseAddr(gateway.IP)] {
+			detail.DefaultGateways = append(detail.DefaultGateways, gateway)
+		}
+	}
+	return detail
+}
+
+// Supernet returns the smallest CIDR block covering all the given prefixes of the same address family.
+// It returns false if no prefix is given or the address families are mixed.
+func Supernet(prefixes ...netip.Prefix) (netip.Prefix, bool) {
+	if len(prefixes) == 0 {
+		return netip.Prefix{}, false
+	}
+	super := prefixes[0].Masked()
+	for _, p := range prefixes[1:] {
+		if p.Addr().Is4() != super.Addr().Is4() {
+			return netip.Prefix{}, false
+		}
+		bits := commonBits(super.Addr(), p.Addr())
+		bits = min(bits, super.Bits(), p.Bits())
+		super = netip.PrefixFrom(super.Addr(), bits).Masked()
+	}
+	return super, true
+}
+
+// commonBits returns the number of leading bits shared by two addresses of the same family.
+func commonBits(a, b netip.Addr) int {
+	as, bs := a.AsSlice(), b.AsSlice()
+	bits := 0
+	for i := range as {
+		x := as[i] ^ bs[i]
+		if x == 0 {
+			bits += 8
+			continue
+		}
+		for mask := byte(0x80); mask != 0 && x&mask == 0; mask >>= 1 {
+			bits++
+		}
+		break
+	}
+	return bits
+}
+
+// estimateAddressSpace groups the subnets by private address block and covers each group with its supernet,
+// or keeps the subnets of the group if the supernet overlaps one of the reserved blocks.
+func estimateAddressSpace(subnets, reserved []netip.Prefix) []netip.Prefix {
+	groups := make(map[netip.Prefix][]netip.Prefix)
+	var estimated []netip.Prefix
+	for _, subnet := range subnets {
+		block, isPrivate := privateBlockOf(subnet)
+		if !isPrivate {
+			estimated = append(estimated, subnet)
+			continue
+		}
+		groups[block] = append(groups[block], subnet)
+	}
+	for _, group := range groups {
+		if super, ok := Supernet(group...); ok && !overlapsAny(super, reserved) {
+			estimated = append(estimated, super)
+		} else {
+			estimated = append(estimated, group...)
+		}
+	}
+	return collapsePrefixes(estimated)
+}
+
+// isCovered returns whether the prefix is nested in one of the blocks.
+func isCovered(prefix netip.Prefix, blocks []netip.Prefix) bool {
+	for _, block := range blocks {
+		if block.Bits() <= prefix.Bits() && block.Contains(prefix.Addr()) {
+			return true
+		}
+	}
+	return false
+}
+
+func overlapsAny(prefix netip.Prefix, blocks []netip.Prefix) bool {
+	for _, block := range blocks {
+		if block.Overlaps(prefix) {
+			return true
+		}
+	}
+	return false
+}
+
+func privateBlockOf(prefix netip.Prefix) (netip.Prefix, bool) {
+	for _, block := range privateBlocks {
+		if block.Bits() <= prefix.Bits() && block.Contains(prefix.Addr()) {
+			return block, true
+		}
+	}
+	return netip.Prefix{}, false
+}
+
+// collapsePrefixes sorts the prefixes and removes duplicates and prefixes nested in another one.
+func collapsePrefixes(prefixes []netip.Prefix) []netip.Prefix {
+	sorted := append([]netip.Prefix(nil), prefixes...)
+	sort.Slice(sorted, func(i, j int) bool {
+		if c := sorted[i].Addr().Compare(sorted[j].Addr()); c != 0 {
+			return c < 0
+		}
+		return sorted[i].Bits() < sorted[j].Bits()
+	})
+
+	var collapsed []netip.Prefix
+	for _, p := range sorted {
+		if n := len(collapsed); n > 0 && collapsed[n-1].Bits() <= p.Bits() && collapsed[n-1].Contains(p.Addr()) {
+			continue
+		}
+		collapsed = append(collapsed, p)
+	}
+	return collapsed
+}
+
+func formatPrefixes(prefixes []netip.Prefix) []string {
+	if len(prefixes) == 0 {
+		return nil
+	}
+	blocks := make([]string, 0, len(prefixes))
+	for _, p := range prefixes {
+		blocks = append(blocks, p.String())
+	}
+	return blocks
+}
+
+func isRoutable(addr netip.Addr) bool {
+	return !addr.IsLoopback() && !addr.IsLinkLocalUnicast() && !addr.IsUnspecified() && !addr.IsMulticast()
+}
+
+func isDefaultRoute(destination string) bool {
+	switch destination {
+	case "default", "0.0.0.0/0", "::/0":
+		return true
+	}
+	return false
+}
@@ -0,0 +1,177 @@
+package onpremisemodel
+
+import (
+	"net/netip"
+	"reflect"
+	"testing"
+)
+
+// derivedServers are servers in two private subnets of 10.0.0.0/8, a public subnet and an IPv6 ULA subnet.
+func derivedServers() []ServerProperty {
+	return []ServerProperty{
+		{
+			MachineId: "m-1",
+			Interfaces: []NetworkInterfaceProperty{
+				{Name: "lo", IPv4CidrBlocks: []string{"127.0.0.1/8"}, IPv6CidrBlocks: []string{"::1/128"}},
+				{Name: "eth0", IPv4CidrBlocks: []string{"10.0.1.21/24"}, IPv6CidrBlocks: []string{"fd00:0:0:1::21/64", "fe80::21/64"}},
+				{Name: "eth1", IPv4CidrBlocks: []string{"203.0.113.10/28"}},
+			},
+			RoutingTable: []RouteProperty{
+				{Destination: "default", Gateway: "10.0.1.1", Interface: "eth0"},
+				{Destination: "::/0", Gateway: "fd00:0:0:1::1", Interface: "eth0"},
+				{Destination: "10.0.0.0/8", Gateway: "10.0.1.254", Interface: "eth0"}, // Not a default route
+			},
+		},
+		{
+			MachineId:  "m-2",
+			Interfaces: []NetworkInterfaceProperty{{Name: "ens5", IPv4CidrBlocks: []string{"10.0.6.7/24", "invalid"}}},
+			RoutingTable: []RouteProperty{
+				{Destination: "0.0.0.0/0", Gateway: "10.0.1.1", Interface: "ens5"}, // A duplicate gateway
+				{Destination: "default", Gateway: "0.0.0.0", Interface: "ens5"},
+			},
+		},
+	}
+}
+
+func TestDeriveNetworkProperty(t *testing.T) {
+	tests := []struct {
+		name  string
+		given NetworkProperty
+		want  NetworkProperty
+	}{
+		{
+			name: "nothing given",
+			want: NetworkProperty{
+				IPv4Networks: NetworkDetail{
+					CidrBlocks:      []string{"10.0.0.0/21", "203.0.113.0/28"},
+					DefaultGateways: []GatewayProperty{{IP: "10.0.1.1", InterfaceName: "eth0", MachineId: "m-1"}},
+				},
+				IPv6Networks: NetworkDetail{
+					CidrBlocks:      []string{"fd00:0:0:1::/64"},
+					DefaultGateways: []GatewayProperty{{IP: "fd00:0:0:1::1", InterfaceName: "eth0", MachineId: "m-1"}},
+				},
+			},
+		},
+		{
+			name: "given blocks covering every subnet",
+			given: NetworkProperty{IPv4Networks: NetworkDetail{
+				CidrBlocks:      []string{"10.0.0.0/16", "203.0.113.0/24"},
+				DefaultGateways: []GatewayProperty{{IP: "10.0.1.1", InterfaceName: "bond0"}},
+			}},
+			want: NetworkProperty{
+				IPv4Networks: NetworkDetail{
+					CidrBlocks:      []string{"10.0.0.0/16", "203.0.113.0/24"},
+					DefaultGateways: []GatewayProperty{{IP: "10.0.1.1", InterfaceName: "bond0"}}, // Kept as given
+				},
+				IPv6Networks: NetworkDetail{
+					CidrBlocks:      []string{"fd00:0:0:1::/64"},
+					DefaultGateways: []GatewayProperty{{IP: "fd00:0:0:1::1", InterfaceName: "eth0", MachineId: "m-1"}},
+				},
+			},
+		},
+		{
+			name: "given block covering a part of the subnets",
+			given: NetworkProperty{IPv4Networks: NetworkDetail{
+				CidrBlocks:      []string{"10.0.6.0/24"},
+				DefaultGateways: []GatewayProperty{{IP: "10.0.6.1"}},
+			}},
+			want: NetworkProperty{
+				IPv4Networks: NetworkDetail{
+					CidrBlocks: []string{"10.0.6.0/24", "10.0.1.0/24", "203.0.113.0/28"},
+					DefaultGateways: []GatewayProperty{
+						{IP: "10.0.6.1"},
+						{IP: "10.0.1.1", InterfaceName: "eth0", MachineId: "m-1"},
+					},
+				},
+				IPv6Networks: NetworkDetail{
+					CidrBlocks:      []string{"fd00:0:0:1::/64"},
+					DefaultGateways: []GatewayProperty{{IP: "fd00:0:0:1::1", InterfaceName: "eth0", MachineId: "m-1"}},
+				},
+			},
+		},
+		{
+			name: "supernet overlapping a given block",
+			// 10.0.1.0/24 and 10.0.6.0/24 would be covered by 10.0.0.0/21, which overlaps 10.0.4.0/24
+			given: NetworkProperty{IPv4Networks: NetworkDetail{CidrBlocks: []string{"10.0.4.0/24"}}},
+			want: NetworkProperty{
+				IPv4Networks: NetworkDetail{
+					CidrBlocks:      []string{"10.0.4.0/24", "10.0.1.0/24", "10.0.6.0/24", "203.0.113.0/28"},
+					DefaultGateways: []GatewayProperty{{IP: "10.0.1.1", InterfaceName: "eth0", MachineId: "m-1"}},
+				},
+				IPv6Networks: NetworkDetail{
+					CidrBlocks:      []string{"fd00:0:0:1::/64"},
+					DefaultGateways: []GatewayProperty{{IP: "fd00:0:0:1::1", InterfaceName: "eth0", MachineId: "m-1"}},
+				},
+			},
+		},
+		{
+			name: "invalid given block kept as it is",
+			given: NetworkProperty{IPv6Networks: NetworkDetail{
+				CidrBlocks:      []string{"fd00::/48", "not a block"},
+				DefaultGateways: []GatewayProperty{{IP: "fd00:0:0:1::1"}},
+			}},
+			want: NetworkProperty{
+				IPv4Networks: NetworkDetail{
+					CidrBlocks:      []string{"10.0.0.0/21", "203.0.113.0/28"},
+					DefaultGateways: []GatewayProperty{{IP: "10.0.1.1", InterfaceName: "eth0", MachineId: "m-1"}},
+				},
+				IPv6Networks: NetworkDetail{
+					CidrBlocks:      []string{"fd00::/48", "not a block"},
+					DefaultGateways: []GatewayProperty{{IP: "fd00:0:0:1::1"}},
+				},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			given := tt.given
+			if got := DeriveNetworkProperty(tt.given, derivedServers()); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("DeriveNetworkProperty() = %+v\nwant %+v", got, tt.want)
+			}
+			if !reflect.DeepEqual(tt.given, given) {
+				t.Errorf("DeriveNetworkProperty() modified the given network: %+v", tt.given)
+			}
+		})
+	}
+}
+
+func TestDeriveNetworkPropertyWithoutServers(t *testing.T) {
+	if got := DeriveNetworkProperty(NetworkProperty{}, nil); !reflect.DeepEqual(got, NetworkProperty{}) {
+		t.Errorf("DeriveNetworkProperty() = %+v, want an empty network", got)
+	}
+}
+
+func TestSupernet(t *testing.T) {
+	tests := []struct {
+		prefixes []string
+		want     string // Empty if no supernet
+	}{
+		{nil, ""},
+		{[]string{"10.0.1.21/24"}, "10.0.1.0/24"},
+		{[]string{"10.0.1.0/24", "10.0.2.0/24"}, "10.0.0.0/22"},
+		{[]string{"10.0.1.0/24", "10.0.1.128/25"}, "10.0.1.0/24"},
+		{[]string{"10.0.1.128/25", "10.0.1.0/24"}, "10.0.1.0/24"},
+		{[]string{"10.0.0.0/8", "192.168.0.0/16"}, "0.0.0.0/0"},
+		{[]string{"172.16.0.0/24", "172.31.255.0/24"}, "172.16.0.0/12"},
+		{[]string{"2001:db8:0:1::/64", "2001:db8:0:2::/64"}, "2001:db8::/62"},
+		{[]string{"10.0.1.0/24", "2001:db8::/64"}, ""},
+	}
+
+	for _, tt := range tests {
+		var prefixes []netip.Prefix
+		for _, p := range tt.prefixes {
+			prefixes = append(prefixes, netip.MustParsePrefix(p))
+		}
+		got, ok := Supernet(prefixes...)
+		if tt.want == "" {
+			if ok {
+				t.Errorf("Supernet(%v) = %s, want none", tt.prefixes, got)
+			}
+			continue
+		}
+		if !ok || got != netip.MustParsePrefix(tt.want) {
+			t.Errorf("Supernet(%v) = %s, %v, want %s", tt.prefixes, got, ok, tt.want)
+		}
+	}
+}
@@ -0,0 +1,117 @@
+package onpremisemodel
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// AllPortsExpr is the port expression for all ports in FirewallRuleProperty.
+const AllPortsExpr = "*"
+
+// PortRange represents an inclusive range of ports (e.g., 1024-65535). A single port has From == To.
+type PortRange struct {
+	From uint16 `json:"from"`
+	To   uint16 `json:"to"`
+}
+
+// AllPorts is the port range that AllPortsExpr ("*") stands for.
+var AllPorts = PortRange{From: 1, To: 65535}
+
+func (r PortRange) String() string {
+	if r.From == r.To {
+		return strconv.Itoa(int(r.From))
+	}
+	return fmt.Sprintf("%d-%d", r.From, r.To)
+}
+
+// ParsePorts parses a port expression of FirewallRuleProperty (e.g., "80", "80,443", "1024-65535", "*").
+// An empty expression returns no range and no error.
+func ParsePorts(expr string) ([]PortRange, error) {
+	expr = strings.TrimSpace(expr)
+	if expr == "" {
+		return nil, nil
+	}
+	if expr == AllPortsExpr {
+		return []PortRange{AllPorts}, nil
+	}
+
+	var ranges []PortRange
+	for _, item := range strings.Split(expr, ",") {
+		item = strings.TrimSpace(item)
+		fromStr, toStr, isRange := strings.Cut(item, "-")
+		if !isRange {
+			// Also accept the iptables notation (e.g., 1024:65535)
+			fromStr, toStr, isRange = strings.Cut(item, ":")
+		}
+		from, err := parsePort(fromStr)
+		if err != nil {
+			return nil, fmt.Errorf("invalid port expression %q: %w", expr, err)
+		}
+		to := from
+		if isRange {
+			if to, err = parsePort(toStr); err != nil {
+				return nil, fmt.Errorf("invalid port expression %q: %w", expr, err)
+			}
+			if from > to {
+				return nil, fmt.Errorf("invalid port expression %q: range %s is reversed", expr, item)
+			}
+		}
+		ranges = append(ranges, PortRange{From: from, To: to})
+	}
+	return ranges, nil
+}
+
+func parsePort(s string) (uint16, error) {
+	s = strings.TrimSpace(s)
+	if s == "" {
+		return 0, fmt.Errorf("empty port")
+	}
+	port, err := strconv.ParseUint(s, 10, 16)
+	if err != nil || port == 0 {
+		return 0, fmt.Errorf("port %q is not in 1-65535", s)
+	}
+	return uint16(port), nil
+}
+
+// MergePorts sorts the port ranges and merges overlapping or adjacent ones.
+func MergePorts(ranges []PortRange) []PortRange {
+	if len(ranges) == 0 {
+		return nil
+	}
+	sorted := append([]PortRange(nil), ranges...)
+	sort.Slice(sorted, func(i, j int) bool {
+		if sorted[i].From != sorted[j].From {
+			return sorted[i].From < sorted[j].From
+		}
+		return sorted[i].To < sorted[j].To
+	})
+
+	merged := []PortRange{sorted[0]}
+	for _, r := range sorted[1:] {
+		last := &merged[len(merged)-1]
+		if uint32(r.From) <= uint32(last.To)+1 {
+			if r.To > last.To {
+				last.To = r.To
+			}
+			continue
+		}
+		merged = append(merged, r)
+	}
+	return merged
+}
+
+// FormatPorts merges the port ranges and formats them as a port expression (e.g., "22,900-1000").
+// A range covering all ports is formatted as AllPortsExpr.
+func FormatPorts(ranges []PortRange) string {
+	merged := MergePorts(ranges)
+	if len(merged) == 1 && merged[0] == AllPorts {
+		return AllPortsExpr
+	}
+	items := make([]string, 0, len(merged))
+	for _, r := range merged {
+		items = append(items, r.String())
+	}
+	return strings.Join(items, ",")
+}
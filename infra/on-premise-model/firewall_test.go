@@ -0,0 +1,111 @@
+package onpremisemodel
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestParsePorts(t *testing.T) {
+	tests := []struct {
+		expr    string
+		want    []PortRange
+		wantErr string
+	}{
+		{expr: "", want: nil},
+		{expr: "  ", want: nil},
+		{expr: "*", want: []PortRange{AllPorts}},
+		{expr: " * ", want: []PortRange{AllPorts}},
+		{expr: "80", want: []PortRange{{80, 80}}},
+		{expr: "80, 443", want: []PortRange{{80, 80}, {443, 443}}},
+		{expr: "1024-65535", want: []PortRange{{1024, 65535}}},
+		{expr: "1024:65535", want: []PortRange{{1024, 65535}}}, // iptables notation
+		{expr: "22,20-21,22", want: []PortRange{{22, 22}, {20, 21}, {22, 22}}},
+		{expr: "8080-8080", want: []PortRange{{8080, 8080}}},
+		{expr: "443-80", wantErr: "range 443-80 is reversed"},
+		{expr: "0", wantErr: `port "0" is not in 1-65535`},
+		{expr: "65536", wantErr: `port "65536" is not in 1-65535`},
+		{expr: "-1", wantErr: "empty port"},
+		{expr: "80-", wantErr: "empty port"},
+		{expr: "80,,443", wantErr: "empty port"},
+		{expr: "80,*", wantErr: `port "*" is not in 1-65535`},
+		{expr: "http", wantErr: `port "http" is not in 1-65535`},
+	}
+
+	for _, tt := range tests {
+		got, err := ParsePorts(tt.expr)
+		if tt.wantErr != "" {
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("ParsePorts(%q) error = %v, want %q", tt.expr, err, tt.wantErr)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("ParsePorts(%q) error = %v", tt.expr, err)
+			continue
+		}
+		if !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("ParsePorts(%q) = %v, want %v", tt.expr, got, tt.want)
+		}
+	}
+}
+
+func TestMergePorts(t *testing.T) {
+	tests := []struct {
+		name   string
+		ranges []PortRange
+		want   []PortRange
+	}{
+		{"empty", nil, nil},
+		{"single", []PortRange{{22, 22}}, []PortRange{{22, 22}}},
+		{"sorted", []PortRange{{443, 443}, {80, 80}}, []PortRange{{80, 80}, {443, 443}}},
+		{"overlap", []PortRange{{1000, 2000}, {1500, 3000}}, []PortRange{{1000, 3000}}},
+		{"contained", []PortRange{{1, 65535}, {22, 22}}, []PortRange{AllPorts}},
+		{"adjacent", []PortRange{{20, 21}, {22, 22}, {23, 30}}, []PortRange{{20, 30}}},
+		{"duplicates", []PortRange{{22, 22}, {22, 22}}, []PortRange{{22, 22}}},
+		{"gap", []PortRange{{20, 21}, {23, 23}}, []PortRange{{20, 21}, {23, 23}}},
+		{"upper bound", []PortRange{{65535, 65535}, {65000, 65535}}, []PortRange{{65000, 65535}}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			input := append([]PortRange(nil), tt.ranges...)
+			if got := MergePorts(tt.ranges); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("MergePorts(%v) = %v, want %v", tt.ranges, got, tt.want)
+			}
+			if !reflect.DeepEqual(tt.ranges, input) {
+				t.Errorf("MergePorts() modified its input: %v", tt.ranges)
+			}
+		})
+	}
+}
+
+func TestFormatPorts(t *testing.T) {
+	tests := []struct {
+		ranges []PortRange
+		want   string
+	}{
+		{nil, ""},
+		{[]PortRange{{22, 22}}, "22"},
+		{[]PortRange{{900, 1000}, {22, 22}, {950, 1010}}, "22,900-1010"},
+		{[]PortRange{{1, 1024}, {1025, 65535}}, AllPortsExpr},
+		{[]PortRange{{1, 65534}}, "1-65534"},
+	}
+
+	for _, tt := range tests {
+		if got := FormatPorts(tt.ranges); got != tt.want {
+			t.Errorf("FormatPorts(%v) = %q, want %q", tt.ranges, got, tt.want)
+		}
+	}
+
+	// Parsing a formatted expression returns the merged ranges
+	for _, expr := range []string{"22,900-1010", "*", "80,443,8000-8999"} {
+		ranges, err := ParsePorts(expr)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got := FormatPorts(ranges); got != expr {
+			t.Errorf("FormatPorts(ParsePorts(%q)) = %q", expr, got)
+		}
+	}
+}
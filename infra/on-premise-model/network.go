@@ -12,6 +12,7 @@ type NetworkProperty struct { // note: reference command `ip route`, `netstat -r
 // NetworkDetail represents a collection of the default route interfaces extracted from each host.
 // Note: A network admin/operator "manually" inputs CIDR blocks (e.g., 10.0.0.0/16) of their networks.
 // Note: The default gateways are "extracted" from each host and is used to estimate the upper layer address space of the network.
+// Note: DeriveNetworkProperty() adds the CIDR blocks estimated from the interfaces of servers outside the given ones.
 type NetworkDetail struct {
 	CidrBlocks      []string          `json:"cidrBlocks,omitempty"`
 	DefaultGateways []GatewayProperty `json:"defaultGateways,omitempty"`
@@ -0,0 +1,98 @@
+package parser
+
+import (
+	"math"
+	"strconv"
+	"strings"
+
+	onpremisemodel "github.com/cloud-barista/cm-model/infra/on-premise-model"
+)
+
+// Pseudo file systems excluded from disks.
+var pseudoFileSystems = map[string]bool{
+	"tmpfs": true, "devtmpfs": true, "overlay": true, "squashfs": true, "udev": true, "shm": true, "none": true,
+}
+
+// ParseDf parses the output of `df -h` (or `df -hT`) into disks labeled by their mount points.
+// Sizes are converted to GiB (totals are rounded up). Pseudo file systems (e.g., tmpfs) are excluded.
+// The disk type (SSD, HDD) is not available from `df` and is left empty.
+//
+//	Filesystem      Size  Used Avail Use% Mounted on
+//	/dev/sda1        49G  8.1G   39G  18% /
+//	/dev/sdb1       1.8T  1.2T  512G  71% /data
+func ParseDf(output string) []onpremisemodel.DiskProperty {
+	var disks []onpremisemodel.DiskProperty
+	var pending []string // A long file system name may wrap the line
+	hasType := false     // Whether the type column of `df -hT` exists
+
+	for _, line := range lines(output) {
+		fields := append(pending, strings.Fields(line)...)
+		if fields[0] == "Filesystem" {
+			hasType = len(fields) > 1 && fields[1] == "Type"
+			pending = nil
+			continue
+		}
+		if len(fields) == 1 {
+			pending = fields
+			continue
+		}
+		pending = nil
+
+		fsType := ""
+		if hasType && len(fields) >= 7 {
+			fsType = fields[1]
+			fields = append(fields[:1:1], fields[2:]...)
+		}
+		if len(fields) < 6 || pseudoFileSystems[fields[0]] || pseudoFileSystems[fsType] {
+			continue
+		}
+
+		total, ok := parseSize(fields[1], math.Ceil)
+		if !ok {
+			continue
+		}
+		used, _ := parseSize(fields[2], math.Round)
+		avail, _ := parseSize(fields[3], math.Round)
+		disks = append(disks, onpremisemodel.DiskProperty{
+			Label:     strings.Join(fields[5:], " "),
+			TotalSize: total,
+			Used:      used,
+			Available: avail,
+		})
+	}
+	return disks
+}
+
+// SplitDisks returns the disk mounted on "/" as the root disk and the others as data disks.
+func SplitDisks(disks []onpremisemodel.DiskProperty) (onpremisemodel.DiskProperty, []onpremisemodel.DiskProperty) {
+	var root onpremisemodel.DiskProperty
+	var data []onpremisemodel.DiskProperty
+	for _, disk := range disks {
+		if disk.Label == "/" {
+			root = disk
+			continue
+		}
+		data = append(data, disk)
+	}
+	return root, data
+}
+
+// parseSize converts a human-readable size of `df -h` (e.g., 512M, 1.8T) into GiB.
+func parseSize(size string, round func(float64) float64) (uint64, bool) {
+	units := map[byte]float64{'K': 1.0 / (1024 * 1024), 'M': 1.0 / 1024, 'G': 1, 'T': 1024, 'P': 1024 * 1024}
+	if size == "0" {
+		return 0, true
+	}
+	if len(size) < 2 {
+		return 0, false
+	}
+	unit, ok := units[size[len(size)-1]]
+	if !ok {
+		return 0, false
+	}
+	value, err := strconv.ParseFloat(strings.Replace(size[:len(size)-1], ",", ".", 1), 64)
+	if err != nil {
+		return 0, false
+	}
+	return uint64(round(value * unit)), true
+}
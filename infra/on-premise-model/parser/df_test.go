@@ -0,0 +1,65 @@
+package parser
+
+import (
+	"reflect"
+	"testing"
+
+	onpremisemodel "github.com/cloud-barista/cm-model/infra/on-premise-model"
+)
+
+func TestParseDf(t *testing.T) {
+	tests := []struct {
+		name   string
+		output string
+		want   []onpremisemodel.DiskProperty
+	}{
+		{
+			name: "df -h",
+			output: `Filesystem      Size  Used Avail Use% Mounted on
+tmpfs           392M  1.4M  391M   1% /run
+/dev/sda1        49G  8.1G   39G  18% /
+/dev/sdb1       1.8T  1.2T  512G  71% /data`,
+			want: []onpremisemodel.DiskProperty{
+				{Label: "/", TotalSize: 49, Used: 8, Available: 39},
+				{Label: "/data", TotalSize: 1844, Used: 1229, Available: 512},
+			},
+		},
+		{
+			name: "df -hT",
+			output: `Filesystem     Type      Size  Used Avail Use% Mounted on
+devtmpfs       devtmpfs  3.8G     0  3.8G   0% /dev
+/dev/xvda1     xfs      1014M  232M  783M  23% /boot`,
+			want: []onpremisemodel.DiskProperty{{Label: "/boot", TotalSize: 1, Used: 0, Available: 1}},
+		},
+		{
+			name: "wrapped file system name and mount point with spaces",
+			output: `Filesystem                  Size  Used Avail Use% Mounted on
+/dev/mapper/vg-var_lib_postgresql
+                            100G  7.3G   93G   8% /mnt/backup disk`,
+			want: []onpremisemodel.DiskProperty{{Label: "/mnt/backup disk", TotalSize: 100, Used: 7, Available: 93}},
+		},
+		{
+			name:   "decimal comma",
+			output: "Filesystem Size Used Avail Use% Mounted on\n/dev/sda1 1,5T 0 1,5T 0% /data",
+			want:   []onpremisemodel.DiskProperty{{Label: "/data", TotalSize: 1536, Used: 0, Available: 1536}},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := ParseDf(tt.output); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("ParseDf() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestSplitDisks(t *testing.T) {
+	disks := []onpremisemodel.DiskProperty{{Label: "/boot"}, {Label: "/"}, {Label: "/data"}}
+	root, data := SplitDisks(disks)
+	if root.Label != "/" {
+		t.Errorf("root = %+v, want /", root)
+	}
+	if want := []onpremisemodel.DiskProperty{{Label: "/boot"}, {Label: "/data"}}; !reflect.DeepEqual(data, want) {
+		t.Errorf("data = %+v, want %+v", data, want)
+	}
+}
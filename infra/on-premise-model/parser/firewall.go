@@ -0,0 +1,479 @@
+package parser
+
+import (
+	"fmt"
+	"net/netip"
+	"strings"
+
+	onpremisemodel "github.com/cloud-barista/cm-model/infra/on-premise-model"
+)
+
+// UnsupportedRule reports a firewall rule (or a construct of it) which cannot be represented by FirewallRuleProperty.
+type UnsupportedRule struct {
+	Chain  string `json:"chain"`  // Chain containing the rule (e.g., INPUT, inet/filter/input)
+	Rule   string `json:"rule"`   // Original rule text
+	Reason string `json:"reason"` // Why the rule is not (fully) represented
+}
+
+// NormalizedFirewall is the effective filter policy of a host flattened into allow/deny rules.
+// Rules are in evaluation order (i.e., the first matching rule decides), and each direction ends with
+// a catch-all rule of the default policy. Every construct not represented in Rules is listed in Unsupported.
+type NormalizedFirewall struct {
+	Rules       []onpremisemodel.FirewallRuleProperty `json:"rules"`
+	Unsupported []UnsupportedRule                     `json:"unsupported,omitempty"`
+}
+
+// filterChain is a chain of the filter table.
+type filterChain struct {
+	Name      string
+	Direction string // Direction of a base chain (i.e., inbound, outbound); empty for user-defined chains
+	Family    string // Table family of a base chain of nftables (i.e., ip, ip6, inet); empty for iptables
+	Policy    string // Default policy of a base chain (e.g., ACCEPT, DROP)
+	Rules     []filterRule
+}
+
+// filterRuleset is a set of filter chains to be flattened from its base chains.
+type filterRuleset struct {
+	Chains map[string]*filterChain
+	Base   []string // Base chains in evaluation order
+}
+
+func newFilterRuleset() *filterRuleset {
+	return &filterRuleset{Chains: make(map[string]*filterChain)}
+}
+
+func (rs *filterRuleset) chain(name string) *filterChain {
+	c, ok := rs.Chains[name]
+	if !ok {
+		c = &filterChain{Name: name}
+		rs.Chains[name] = c
+	}
+	return c
+}
+
+// Connection tracking states handled implicitly by stateful firewalls such as cloud security groups.
+var implicitStates = map[string]string{
+	"ESTABLISHED": "return traffic of allowed connections is implicitly allowed by stateful firewalls",
+	"RELATED":     "return traffic of allowed connections is implicitly allowed by stateful firewalls",
+	"INVALID":     "invalid packets are implicitly dropped by stateful firewalls",
+	"UNTRACKED":   "untracked packets cannot be represented",
+}
+
+// NormalizeIptables flattens the filter table of an `iptables-save` (or `iptables -S`) dump into
+// the effective policy of the INPUT and OUTPUT chains. User-defined chains reached by jumps (-j) and gotos (-g)
+// are inlined with the matches of the jumping rule, and unreachable or redundant rules are removed.
+func NormalizeIptables(dump string) NormalizedFirewall {
+	rs := newFilterRuleset()
+	inFilterTable := true
+
+	for _, line := range lines(dump) {
+		line = strings.TrimSpace(line)
+		switch {
+		case strings.HasPrefix(line, "#"), line == "COMMIT":
+			continue
+		case strings.HasPrefix(line, "*"):
+			inFilterTable = line == "*filter"
+			continue
+		}
+		if !inFilterTable {
+			continue
+		}
+
+		args := splitArgs(line)
+		switch {
+		case strings.HasPrefix(args[0], ":") && len(args) >= 2:
+			// :INPUT DROP [0:0] or :MY-CHAIN - [0:0]
+			c := rs.chain(strings.TrimPrefix(args[0], ":"))
+			if args[1] != "-" {
+				c.Policy = args[1]
+			}
+		case args[0] == "-P" && len(args) >= 3:
+			rs.chain(args[1]).Policy = args[2]
+		case args[0] == "-N" && len(args) >= 2:
+			rs.chain(args[1])
+		case args[0] == "-A" && len(args) >= 2:
+			rule := parseIptablesRule(line, args[1:])
+			c := rs.chain(rule.Chain)
+			c.Rules = append(c.Rules, rule)
+		}
+	}
+
+	for _, name := range []string{"INPUT", "OUTPUT"} {
+		if c, ok := rs.Chains[name]; ok {
+			c.Direction = chainDirections[name]
+			rs.Base = append(rs.Base, name)
+		}
+	}
+	return rs.normalize()
+}
+
+// normalize flattens each base chain and minimizes the resulting rules.
+// Since a packet must be accepted by every base chain of its hook, the chains of the same direction are intersected.
+func (rs *filterRuleset) normalize() NormalizedFirewall {
+	result := NormalizedFirewall{Rules: []onpremisemodel.FirewallRuleProperty{}}
+	var directions []string
+	policies := make(map[string][]onpremisemodel.FirewallRuleProperty) // Direction -> rules
+	families := make(map[string]string)                                // Direction -> family of the chains
+	for _, name := range rs.Base {
+		base := rs.Chains[name]
+		var rules []onpremisemodel.FirewallRuleProperty
+		terminated := rs.flatten(base, filterRule{}, base.Direction, []string{name}, &rules, &result.Unsupported)
+		if !terminated {
+			policy := base.Policy
+			if policy == "" {
+				policy = "ACCEPT"
+			}
+			action, ok := targetActions[policy]
+			if !ok {
+				result.Unsupported = append(result.Unsupported, UnsupportedRule{Chain: name, Rule: "policy " + policy, Reason: "unknown default policy; ACCEPT is assumed"})
+				action = "allow"
+			}
+			rules = append(rules, filterRule{}.toFirewallRule(base.Direction, action))
+		}
+		rules = minimizeRules(rules)
+
+		previous, exists := policies[base.Direction]
+		switch {
+		case !exists:
+			directions = append(directions, base.Direction)
+			policies[base.Direction] = rules
+			families[base.Direction] = base.Family
+		case acceptsAll(rules):
+			// Accepting every packet, the chain does not restrict the others
+		case acceptsAll(previous):
+			policies[base.Direction] = rules
+			families[base.Direction] = base.Family
+		case families[base.Direction] == base.Family:
+			policies[base.Direction] = minimizeRules(intersectPolicies(previous, rules))
+		default:
+			// A chain of an ip or ip6 table only sees the packets of its family, which the rules cannot tell apart
+			result.Unsupported = append(result.Unsupported, UnsupportedRule{
+				Chain:  name,
+				Rule:   "hook of " + base.Direction + " packets",
+				Reason: "base chains of tables of different families on the same hook cannot be combined; the rules of the chain are appended to those of the other chains",
+			})
+			policies[base.Direction] = append(previous, rules...)
+		}
+	}
+	for _, direction := range directions {
+		result.Rules = append(result.Rules, policies[direction]...)
+	}
+	return result
+}
+
+// intersectPolicies combines two first-match rule lists of the same direction, each ending with a catch-all rule,
+// into the rule list allowing the packets allowed by both. The rules are ordered by the first list, then the second,
+// so the first matching rule of the result is the intersection of the first matching rules of both lists.
+func intersectPolicies(a, b []onpremisemodel.FirewallRuleProperty) []onpremisemodel.FirewallRuleProperty {
+	var rules []onpremisemodel.FirewallRuleProperty
+	for _, x := range a {
+		for _, y := range b {
+			rule, ok := intersectFirewallRules(x, y)
+			if !ok {
+				continue
+			}
+			if x.Action != "allow" || y.Action != "allow" {
+				rule.Action = "deny"
+			}
+			rules = append(rules, rule)
+		}
+	}
+	return rules
+}
+
+// intersectFirewallRules returns the rule matching packets matched by both rules, or false if no packet can match both.
+// A block of prefix length 0 (e.g., 0.0.0.0/0 of a rule without address) matches the addresses of both families.
+func intersectFirewallRules(a, b onpremisemodel.FirewallRuleProperty) (onpremisemodel.FirewallRuleProperty, bool) {
+	result := a
+	var srcOk, dstOk, protoOk, srcPortsOk, dstPortsOk bool
+	result.SrcCIDR, srcOk = intersectAnyCidr(a.SrcCIDR, b.SrcCIDR)
+	result.DstCIDR, dstOk = intersectAnyCidr(a.DstCIDR, b.DstCIDR)
+	result.Protocol, protoOk = intersectProtocol(a.Protocol, b.Protocol)
+	result.SrcPorts, srcPortsOk = intersectPorts(a.SrcPorts, b.SrcPorts)
+	result.DstPorts, dstPortsOk = intersectPorts(a.DstPorts, b.DstPorts)
+	if !srcOk || !dstOk || !protoOk || !srcPortsOk || !dstPortsOk {
+		return onpremisemodel.FirewallRuleProperty{}, false
+	}
+
+	// The "any" block of one end follows the address family of the other end
+	src, dst := netip.MustParsePrefix(result.SrcCIDR), netip.MustParsePrefix(result.DstCIDR)
+	switch {
+	case src.Addr().Is4() == dst.Addr().Is4():
+	case src.Bits() == 0:
+		result.SrcCIDR = normalizeCidr("", result.DstCIDR)
+	case dst.Bits() == 0:
+		result.DstCIDR = normalizeCidr("", result.SrcCIDR)
+	default:
+		return onpremisemodel.FirewallRuleProperty{}, false
+	}
+	return result, true
+}
+
+func intersectAnyCidr(a, b string) (string, bool) {
+	pa, errA := netip.ParsePrefix(a)
+	pb, errB := netip.ParsePrefix(b)
+	switch {
+	case errA != nil || errB != nil:
+		return "", false
+	case pa.Bits() == 0 && pb.Bits() == 0 && pa.Addr().Is4():
+		return a, true
+	case pa.Bits() == 0:
+		return b, true
+	case pb.Bits() == 0:
+		return a, true
+	}
+	return intersectCidr(a, b)
+}
+
+// acceptsAll returns true if the rules allow every packet (i.e., a single catch-all allow rule).
+func acceptsAll(rules []onpremisemodel.FirewallRuleProperty) bool {
+	if len(rules) != 1 || rules[0].Action != "allow" {
+		return false
+	}
+	r := rules[0]
+	return r.SrcCIDR == normalizeCidr("", "") && r.DstCIDR == normalizeCidr("", "") && r.Protocol == "*" &&
+		r.SrcPorts == onpremisemodel.AllPortsExpr && r.DstPorts == onpremisemodel.AllPortsExpr
+}
+
+// flatten appends the rules of the chain restricted by the matches of the context (i.e., the jumping rules).
+// It returns true if the chain always terminates with a verdict, so the following rules of the caller are unreachable.
+func (rs *filterRuleset) flatten(c *filterChain, context filterRule, direction string, stack []string, out *[]onpremisemodel.FirewallRuleProperty, unsupported *[]UnsupportedRule) bool {
+	for _, rule := range c.Rules {
+		report := func(format string, args ...any) {
+			*unsupported = append(*unsupported, UnsupportedRule{Chain: c.Name, Rule: rule.Line, Reason: fmt.Sprintf(format, args...)})
+		}
+
+		if len(rule.Negated) > 0 {
+			report("negated match (%s) cannot be represented", strings.Join(rule.Negated, ", "))
+			continue
+		}
+		if len(rule.Unknown) > 0 {
+			report("match (%s) cannot be represented", strings.Join(rule.Unknown, ", "))
+			continue
+		}
+		if reason, implicit := stateReason(rule.States); implicit {
+			report("%s", reason)
+			continue
+		}
+
+		matched, ok := intersectRules(context, rule)
+		if !ok {
+			// Never matches in this context
+			continue
+		}
+		unconditional := isUnconditional(rule)
+
+		switch rule.Target {
+		case "ACCEPT", "DROP", "REJECT":
+			*out = append(*out, matched.toFirewallRule(direction, targetActions[rule.Target]))
+			if unconditional {
+				return true
+			}
+		case "RETURN":
+			if unconditional {
+				return false
+			}
+			report("conditional RETURN cannot be represented; the following rules of the chain are applied to all packets")
+		case "", "LOG", "NFLOG", "ULOG", "AUDIT":
+			// Non-terminating
+		default:
+			sub, exists := rs.Chains[rule.Target]
+			if !exists {
+				report("target %s is not supported", rule.Target)
+				continue
+			}
+			if contains(stack, rule.Target) {
+				report("loop to chain %s is ignored", rule.Target)
+				continue
+			}
+			terminated := rs.flatten(sub, matched, direction, append(stack, rule.Target), out, unsupported)
+			if unconditional && (terminated || rule.Goto) {
+				return terminated
+			}
+			if rule.Goto && !terminated {
+				report("conditional goto to chain %s without a final verdict cannot be represented; the following rules of the chain are applied to its packets", rule.Target)
+			}
+		}
+	}
+	return false
+}
+
+// stateReason returns the reason why a rule with the conntrack states is not represented.
+// Rules matching NEW connections (possibly with other states) are represented without the state match.
+func stateReason(states []string) (string, bool) {
+	if len(states) == 0 {
+		return "", false
+	}
+	var reason string
+	for _, state := range states {
+		r, implicit := implicitStates[strings.ToUpper(state)]
+		if !implicit {
+			return "", false
+		}
+		reason = r
+	}
+	return reason, true
+}
+
+func isUnconditional(r filterRule) bool {
+	return r.Src == "" && r.Dst == "" && r.Protocol == "" && r.SrcPorts == "" && r.DstPorts == "" && len(r.States) == 0
+}
+
+// intersectRules returns the rule matching packets matched by both rules, or false if no packet can match both.
+func intersectRules(a, b filterRule) (filterRule, bool) {
+	result := b
+	var ok bool
+	if result.Src, ok = intersectCidr(a.Src, b.Src); !ok {
+		return filterRule{}, false
+	}
+	if result.Dst, ok = intersectCidr(a.Dst, b.Dst); !ok {
+		return filterRule{}, false
+	}
+	if result.Protocol, ok = intersectProtocol(a.Protocol, b.Protocol); !ok {
+		return filterRule{}, false
+	}
+	if result.SrcPorts, ok = intersectPorts(a.SrcPorts, b.SrcPorts); !ok {
+		return filterRule{}, false
+	}
+	if result.DstPorts, ok = intersectPorts(a.DstPorts, b.DstPorts); !ok {
+		return filterRule{}, false
+	}
+	return result, true
+}
+
+func intersectCidr(a, b string) (string, bool) {
+	if a == "" || b == "" {
+		return a + b, true
+	}
+	pa, errA := netip.ParsePrefix(normalizeCidr(a, ""))
+	pb, errB := netip.ParsePrefix(normalizeCidr(b, ""))
+	switch {
+	case errA != nil || errB != nil || pa.Addr().Is4() != pb.Addr().Is4():
+		return "", false
+	case pa.Bits() <= pb.Bits() && pa.Contains(pb.Addr()):
+		return b, true
+	case pb.Bits() <= pa.Bits() && pb.Contains(pa.Addr()):
+		return a, true
+	}
+	return "", false
+}
+
+func intersectProtocol(a, b string) (string, bool) {
+	switch {
+	case normalizeProtocol(a) == "*":
+		return b, true
+	case normalizeProtocol(b) == "*", normalizeProtocol(a) == normalizeProtocol(b):
+		return a, true
+	}
+	return "", false
+}
+
+func intersectPorts(a, b string) (string, bool) {
+	if a == "" || b == "" {
+		return a + b, true
+	}
+	ra, errA := onpremisemodel.ParsePorts(a)
+	rb, errB := onpremisemodel.ParsePorts(b)
+	if errA != nil || errB != nil {
+		return "", false
+	}
+	var result []onpremisemodel.PortRange
+	for _, x := range ra {
+		for _, y := range rb {
+			from, to := max(x.From, y.From), min(x.To, y.To)
+			if from <= to {
+				result = append(result, onpremisemodel.PortRange{From: from, To: to})
+			}
+		}
+	}
+	if len(result) == 0 {
+		return "", false
+	}
+	return onpremisemodel.FormatPorts(result), true
+}
+
+// minimizeRules removes the rules which do not change the outcome of first-match evaluation:
+// rules covered by an earlier rule (unreachable), and rules whose packets would get the same action
+// from the later rules anyway (redundant, e.g., allow rules followed by the allow-all default policy).
+func minimizeRules(rules []onpremisemodel.FirewallRuleProperty) []onpremisemodel.FirewallRuleProperty {
+	var reachable []onpremisemodel.FirewallRuleProperty
+	for _, rule := range rules {
+		shadowed := false
+		for _, earlier := range reachable {
+			if coversRule(earlier, rule) {
+				shadowed = true
+				break
+			}
+		}
+		if !shadowed {
+			reachable = append(reachable, rule)
+		}
+	}
+
+	minimized := make([]onpremisemodel.FirewallRuleProperty, 0, len(reachable))
+	for i := len(reachable) - 1; i >= 0; i-- {
+		if !isRedundant(reachable[i], minimized) {
+			minimized = append([]onpremisemodel.FirewallRuleProperty{reachable[i]}, minimized...)
+		}
+	}
+	return minimized
+}
+
+// isRedundant returns true if the first later rules matching any packet of the rule have the same action.
+func isRedundant(rule onpremisemodel.FirewallRuleProperty, later []onpremisemodel.FirewallRuleProperty) bool {
+	for _, next := range later {
+		if !overlapsRule(rule, next) {
+			continue
+		}
+		if next.Action != rule.Action {
+			return false
+		}
+		if coversRule(next, rule) {
+			return true
+		}
+	}
+	return false
+}
+
+func coversRule(a, b onpremisemodel.FirewallRuleProperty) bool {
+	return a.Direction == b.Direction &&
+		coversCidr(a.SrcCIDR, b.SrcCIDR) && coversCidr(a.DstCIDR, b.DstCIDR) &&
+		(a.Protocol == "*" || a.Protocol == b.Protocol) &&
+		coversPorts(a.SrcPorts, b.SrcPorts) && coversPorts(a.DstPorts, b.DstPorts)
+}
+
+func overlapsRule(a, b onpremisemodel.FirewallRuleProperty) bool {
+	_, srcOk := intersectCidr(a.SrcCIDR, b.SrcCIDR)
+	_, dstOk := intersectCidr(a.DstCIDR, b.DstCIDR)
+	_, protoOk := intersectProtocol(a.Protocol, b.Protocol)
+	_, srcPortsOk := intersectPorts(a.SrcPorts, b.SrcPorts)
+	_, dstPortsOk := intersectPorts(a.DstPorts, b.DstPorts)
+	return a.Direction == b.Direction && srcOk && dstOk && protoOk && srcPortsOk && dstPortsOk
+}
+
+func coversCidr(a, b string) bool {
+	pa, errA := netip.ParsePrefix(a)
+	pb, errB := netip.ParsePrefix(b)
+	if errA != nil || errB != nil {
+		return a == b
+	}
+	return pa.Addr().Is4() == pb.Addr().Is4() && pa.Bits() <= pb.Bits() && pa.Contains(pb.Addr())
+}
+
+func coversPorts(a, b string) bool {
+	intersection, ok := intersectPorts(a, b)
+	if !ok {
+		return false
+	}
+	rb, _ := onpremisemodel.ParsePorts(b)
+	return intersection == onpremisemodel.FormatPorts(rb)
+}
+
+func contains(list []string, s string) bool {
+	for _, item := range list {
+		if item == s {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,313 @@
+package parser
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	onpremisemodel "github.com/cloud-barista/cm-model/infra/on-premise-model"
+)
+
+// fwRule returns a normalized rule of the given source, destination ports, protocol and "direction action"
+// (e.g., "inbound allow"), matching any destination address and source port.
+func fwRule(src, dstPorts, protocol, directionAction string) onpremisemodel.FirewallRuleProperty {
+	direction, action, _ := strings.Cut(directionAction, " ")
+	dst := "0.0.0.0/0"
+	if strings.Contains(src, ":") {
+		dst = "::/0"
+	}
+	return onpremisemodel.FirewallRuleProperty{
+		SrcCIDR: src, SrcPorts: "*", DstCIDR: dst, DstPorts: dstPorts, Protocol: protocol, Direction: direction, Action: action,
+	}
+}
+
+// unsupportedReasons returns the chain and the beginning of the reason of each unsupported rule.
+func unsupportedReasons(unsupported []UnsupportedRule) []string {
+	var reasons []string
+	for _, u := range unsupported {
+		reason, _, _ := strings.Cut(u.Reason, ";")
+		reasons = append(reasons, u.Chain+": "+reason)
+	}
+	return reasons
+}
+
+func TestNormalizeIptables(t *testing.T) {
+	tests := []struct {
+		name            string
+		dump            string
+		want            []onpremisemodel.FirewallRuleProperty
+		wantUnsupported []string
+	}{
+		{
+			name: "default policies",
+			dump: `*filter
+:INPUT DROP [0:0]
+:FORWARD DROP [0:0]
+:OUTPUT ACCEPT [0:0]
+-A INPUT -p tcp -m tcp --dport 22 -j ACCEPT
+-A OUTPUT -p tcp -m tcp --dport 443 -j ACCEPT
+COMMIT`,
+			want: []onpremisemodel.FirewallRuleProperty{
+				fwRule("0.0.0.0/0", "22", "TCP", "inbound allow"),
+				fwRule("0.0.0.0/0", "*", "*", "inbound deny"),
+				fwRule("0.0.0.0/0", "*", "*", "outbound allow"), // The allow rule of 443 is redundant
+			},
+		},
+		{
+			name: "unknown and missing policies",
+			dump: `-P INPUT QUEUE
+-A OUTPUT -d 10.0.0.0/8 -j DROP`,
+			want: []onpremisemodel.FirewallRuleProperty{
+				fwRule("0.0.0.0/0", "*", "*", "inbound allow"),
+				{SrcCIDR: "0.0.0.0/0", SrcPorts: "*", DstCIDR: "10.0.0.0/8", DstPorts: "*", Protocol: "*", Direction: "outbound", Action: "deny"},
+				fwRule("0.0.0.0/0", "*", "*", "outbound allow"),
+			},
+			wantUnsupported: []string{"INPUT: unknown default policy"},
+		},
+		{
+			name: "jump and return",
+			dump: `*filter
+:INPUT DROP [0:0]
+:OUTPUT ACCEPT [0:0]
+:ADMIN - [0:0]
+-A INPUT -s 10.0.0.0/8 -j ADMIN
+-A INPUT -p tcp -m tcp --dport 80 -j ACCEPT
+-A ADMIN -s 10.1.0.0/16 -j RETURN
+-A ADMIN -p tcp -m tcp --dport 22 -j ACCEPT
+-A ADMIN -j RETURN
+-A ADMIN -j DROP
+COMMIT`,
+			want: []onpremisemodel.FirewallRuleProperty{
+				// The conditional RETURN is reported, and the rules after the unconditional one are unreachable
+				fwRule("10.0.0.0/8", "22", "TCP", "inbound allow"),
+				fwRule("0.0.0.0/0", "80", "TCP", "inbound allow"),
+				fwRule("0.0.0.0/0", "*", "*", "inbound deny"),
+				fwRule("0.0.0.0/0", "*", "*", "outbound allow"),
+			},
+			wantUnsupported: []string{"ADMIN: conditional RETURN cannot be represented"},
+		},
+		{
+			name: "goto and terminating chain",
+			dump: `-P INPUT ACCEPT
+-N BLOCK
+-N LOOP
+-A INPUT -s 192.0.2.0/24 -g BLOCK
+-A INPUT -j LOOP
+-A INPUT -j BLOCK
+-A INPUT -p udp --dport 53 -j ACCEPT
+-A BLOCK -j DROP
+-A LOOP -j LOOP`,
+			want: []onpremisemodel.FirewallRuleProperty{
+				// BLOCK always drops, so the rules after the unconditional jump are unreachable
+				fwRule("0.0.0.0/0", "*", "*", "inbound deny"),
+			},
+			wantUnsupported: []string{"LOOP: loop to chain LOOP is ignored"},
+		},
+		{
+			name: "port ranges",
+			dump: `-P INPUT DROP
+-A INPUT -p tcp -m tcp --dport 1500:1600 -j DROP
+-A INPUT -p tcp -m multiport --dports 1000:2000,80 -j ACCEPT
+-A INPUT -p tcp -m tcp --dport 1200:1300 -j ACCEPT
+-A INPUT -p udp -m udp --sport 123 -j ACCEPT`,
+			want: []onpremisemodel.FirewallRuleProperty{
+				fwRule("0.0.0.0/0", "1500-1600", "TCP", "inbound deny"),
+				fwRule("0.0.0.0/0", "1000-2000,80", "TCP", "inbound allow"), // Covering the later allow rule of 1200-1300
+				{SrcCIDR: "0.0.0.0/0", SrcPorts: "123", DstCIDR: "0.0.0.0/0", DstPorts: "*", Protocol: "UDP", Direction: "inbound", Action: "allow"},
+				fwRule("0.0.0.0/0", "*", "*", "inbound deny"),
+			},
+		},
+		{
+			name: "states, negations and other tables",
+			dump: `*nat
+:PREROUTING ACCEPT [0:0]
+-A PREROUTING -p tcp --dport 8080 -j DNAT --to-destination 10.0.0.5:80
+COMMIT
+*filter
+:INPUT DROP [0:0]
+-A INPUT -m conntrack --ctstate RELATED,ESTABLISHED -j ACCEPT
+-A INPUT -m conntrack --ctstate NEW -p tcp --dport 22 -j ACCEPT
+-A INPUT ! -s 10.0.0.0/8 -p tcp --dport 3306 -j DROP
+-A INPUT -i lo -j ACCEPT
+COMMIT`,
+			want: []onpremisemodel.FirewallRuleProperty{
+				fwRule("0.0.0.0/0", "22", "TCP", "inbound allow"),
+				fwRule("0.0.0.0/0", "*", "*", "inbound deny"),
+			},
+			wantUnsupported: []string{
+				"INPUT: return traffic of allowed connections is implicitly allowed by stateful firewalls",
+				"INPUT: negated match (-s) cannot be represented",
+				"INPUT: match (-i) cannot be represented",
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := NormalizeIptables(tt.dump)
+			if !reflect.DeepEqual(got.Rules, tt.want) {
+				t.Errorf("rules = %+v\nwant %+v", got.Rules, tt.want)
+			}
+			if reasons := unsupportedReasons(got.Unsupported); !reflect.DeepEqual(reasons, tt.wantUnsupported) {
+				t.Errorf("unsupported = %q, want %q", reasons, tt.wantUnsupported)
+			}
+		})
+	}
+}
+
+func TestNormalizeNftables(t *testing.T) {
+	tests := []struct {
+		name            string
+		ruleset         string
+		want            []onpremisemodel.FirewallRuleProperty
+		wantUnsupported []string
+	}{
+		{
+			name: "default policies and sets",
+			ruleset: `table inet filter {
+	set blocked {
+		type ipv4_addr
+		elements = { 192.0.2.1 }
+	}
+	chain input {
+		type filter hook input priority filter; policy drop;
+		ct state established,related accept
+		tcp dport { 22, 80, 443 } counter packets 10 bytes 600 accept
+		udp dport 60000-61000 accept comment "mosh"
+		ip saddr @blocked drop
+	}
+	chain output {
+		type filter hook output priority filter; policy accept;
+	}
+}`,
+			want: []onpremisemodel.FirewallRuleProperty{
+				fwRule("0.0.0.0/0", "22,80,443", "TCP", "inbound allow"),
+				fwRule("0.0.0.0/0", "60000-61000", "UDP", "inbound allow"),
+				fwRule("0.0.0.0/0", "*", "*", "inbound deny"),
+				fwRule("0.0.0.0/0", "*", "*", "outbound allow"),
+			},
+			wantUnsupported: []string{
+				"inet/filter/input: return traffic of allowed connections is implicitly allowed by stateful firewalls",
+				"inet/filter/input: match (ip saddr @blocked) cannot be represented",
+			},
+		},
+		{
+			name: "jump and goto",
+			ruleset: `table ip filter {
+	chain input {
+		type filter hook input priority 0; policy drop;
+		ip saddr 10.0.0.0/8 jump admin
+		ip saddr 192.0.2.0/24 goto web
+		ip saddr 198.51.100.0/24 goto api
+		tcp dport 8080 accept
+	}
+	chain admin {
+		ip saddr 10.9.0.0/16 return
+		tcp dport 22 accept
+	}
+	chain web {
+		tcp dport 80 accept
+		drop
+	}
+	chain api {
+		tcp dport 8443 accept
+	}
+}`,
+			want: []onpremisemodel.FirewallRuleProperty{
+				fwRule("10.0.0.0/8", "22", "TCP", "inbound allow"),
+				fwRule("192.0.2.0/24", "80", "TCP", "inbound allow"),
+				fwRule("192.0.2.0/24", "*", "*", "inbound deny"),
+				fwRule("198.51.100.0/24", "8443", "TCP", "inbound allow"),
+				fwRule("0.0.0.0/0", "8080", "TCP", "inbound allow"),
+				fwRule("0.0.0.0/0", "*", "*", "inbound deny"),
+			},
+			wantUnsupported: []string{
+				"ip/filter/admin: conditional RETURN cannot be represented",
+				"ip/filter/input: conditional goto to chain ip/filter/api without a final verdict cannot be represented",
+			},
+		},
+		{
+			name: "base chains of the same hook are intersected",
+			ruleset: `table inet filter {
+	chain input {
+		type filter hook input priority filter; policy drop;
+		tcp dport { 22, 80 } accept
+		ip6 saddr 2001:db8::/32 accept
+	}
+}
+table inet guard {
+	chain input {
+		type filter hook input priority filter - 10; policy accept;
+		ip saddr 10.0.0.0/8 tcp dport 22 drop
+		tcp dport 80 drop
+	}
+}`,
+			want: []onpremisemodel.FirewallRuleProperty{
+				// 22 is allowed except from 10.0.0.0/8, 80 is dropped by guard, and 2001:db8::/32 is allowed except to 80
+				fwRule("10.0.0.0/8", "22", "TCP", "inbound deny"),
+				fwRule("0.0.0.0/0", "80", "TCP", "inbound deny"),
+				fwRule("0.0.0.0/0", "22,80", "TCP", "inbound allow"),
+				fwRule("2001:db8::/32", "80", "TCP", "inbound deny"),
+				fwRule("2001:db8::/32", "*", "*", "inbound allow"),
+				fwRule("0.0.0.0/0", "*", "*", "inbound deny"),
+			},
+		},
+		{
+			name: "accept-all base chain of another family",
+			ruleset: `table ip filter {
+	chain INPUT {
+		type filter hook input priority filter; policy accept;
+	}
+}
+table inet firewalld {
+	chain filter_INPUT {
+		type filter hook input priority filter + 10; policy drop;
+		tcp dport 9090 accept
+	}
+}`,
+			want: []onpremisemodel.FirewallRuleProperty{
+				fwRule("0.0.0.0/0", "9090", "TCP", "inbound allow"),
+				fwRule("0.0.0.0/0", "*", "*", "inbound deny"),
+			},
+		},
+		{
+			name: "base chains of different families",
+			ruleset: `table ip filter4 {
+	chain input {
+		type filter hook input priority filter; policy drop;
+		tcp dport 22 accept
+	}
+}
+table ip6 filter6 {
+	chain input {
+		type filter hook input priority filter; policy accept;
+		ip6 saddr 2001:db8::/32 drop
+	}
+}
+table arp filter {
+	chain input {
+		type filter hook input priority filter; policy drop;
+	}
+}`,
+			want: []onpremisemodel.FirewallRuleProperty{
+				fwRule("0.0.0.0/0", "22", "TCP", "inbound allow"),
+				fwRule("0.0.0.0/0", "*", "*", "inbound deny"),
+				fwRule("2001:db8::/32", "*", "*", "inbound deny"),
+				fwRule("0.0.0.0/0", "*", "*", "inbound allow"),
+			},
+			wantUnsupported: []string{"ip6/filter6/input: base chains of tables of different families on the same hook cannot be combined"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := NormalizeNftables(tt.ruleset)
+			if !reflect.DeepEqual(got.Rules, tt.want) {
+				t.Errorf("rules = %+v\nwant %+v", got.Rules, tt.want)
+			}
+			if reasons := unsupportedReasons(got.Unsupported); !reflect.DeepEqual(reasons, tt.wantUnsupported) {
+				t.Errorf("unsupported = %q, want %q", reasons, tt.wantUnsupported)
+			}
+		})
+	}
+}
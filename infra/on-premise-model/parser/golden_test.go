@@ -0,0 +1,70 @@
+package parser
+
+import (
+	"bytes"
+	"encoding/json"
+	"flag"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+var update = flag.Bool("update", false, "update the golden files of testdata")
+
+// goldenParsers are the parsers of the captured outputs of testdata/<distro>/<command>.txt,
+// whose results are compared with testdata/<distro>/<command>.golden.json.
+var goldenParsers = map[string]func(string) any{
+	"ip-route":     func(s string) any { return ParseIPRoute(s) },
+	"ip-addr":      func(s string) any { return ParseIPAddr(s) },
+	"ifconfig":     func(s string) any { return ParseIfconfig(s) },
+	"df":           func(s string) any { return ParseDf(s) },
+	"lshw-network": func(s string) any { return ParseLshwNetwork(s) },
+	"iptables":     func(s string) any { return ParseIptables(s) },
+	"lscpu":        func(s string) any { return ParseLscpu(s) },
+	"os-release":   func(s string) any { return ParseOsRelease(s) },
+}
+
+func TestGolden(t *testing.T) {
+	inputs, err := filepath.Glob(filepath.Join("testdata", "*", "*.txt"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(inputs) == 0 {
+		t.Fatal("no captured output in testdata")
+	}
+	for _, input := range inputs {
+		command := strings.TrimSuffix(filepath.Base(input), ".txt")
+		parse, ok := goldenParsers[command]
+		if !ok {
+			t.Errorf("%s: unknown command %q", input, command)
+			continue
+		}
+		t.Run(filepath.ToSlash(strings.TrimSuffix(input, ".txt")), func(t *testing.T) {
+			output, err := os.ReadFile(input)
+			if err != nil {
+				t.Fatal(err)
+			}
+			got, err := json.MarshalIndent(parse(string(output)), "", "  ")
+			if err != nil {
+				t.Fatal(err)
+			}
+			got = append(got, '\n')
+
+			golden := strings.TrimSuffix(input, ".txt") + ".golden.json"
+			if *update {
+				if err := os.WriteFile(golden, got, 0644); err != nil {
+					t.Fatal(err)
+				}
+				return
+			}
+			want, err := os.ReadFile(golden)
+			if err != nil {
+				t.Fatalf("%v (run go test -update to create it)", err)
+			}
+			if !bytes.Equal(got, want) {
+				t.Errorf("result differs from %s:\n%s", golden, got)
+			}
+		})
+	}
+}
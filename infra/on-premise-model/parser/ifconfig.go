@@ -0,0 +1,133 @@
+package parser
+
+import (
+	"net/netip"
+	"strconv"
+	"strings"
+
+	onpremisemodel "github.com/cloud-barista/cm-model/infra/on-premise-model"
+)
+
+// ParseIfconfig parses the output of `ifconfig` (or `ifconfig -a`).
+// Both the net-tools 2.x format (Ubuntu 18.04+, RHEL 7+) and the legacy format (RHEL 6 and older) are supported.
+//
+//	enp0s3: flags=4163<UP,BROADCAST,RUNNING,MULTICAST>  mtu 1500
+//	        inet 10.0.2.15  netmask 255.255.255.0  broadcast 10.0.2.255
+//	        inet6 fe80::a00:27ff:fe4e:66a1  prefixlen 64  scopeid 0x20<link>
+//	        ether 08:00:27:4e:66:a1  txqueuelen 1000  (Ethernet)
+//
+//	eth0      Link encap:Ethernet  HWaddr 08:00:27:4E:66:A1
+//	          inet addr:10.0.2.15  Bcast:10.0.2.255  Mask:255.255.255.0
+//	          UP BROADCAST RUNNING MULTICAST  MTU:1500  Metric:1
+func ParseIfconfig(output string) []onpremisemodel.NetworkInterfaceProperty {
+	var ifaces []onpremisemodel.NetworkInterfaceProperty
+	var current *onpremisemodel.NetworkInterfaceProperty
+
+	for _, line := range lines(output) {
+		if line[0] != ' ' && line[0] != '\t' {
+			// Header line of an interface
+			fields := strings.Fields(line)
+			ifaces = append(ifaces, onpremisemodel.NetworkInterfaceProperty{Name: strings.TrimSuffix(fields[0], ":"), State: "DOWN"})
+			current = &ifaces[len(ifaces)-1]
+			if strings.Contains(line, "flags=") {
+				parseIfconfigFlags(current, line)
+				continue
+			}
+			// Legacy format has the hardware address in the header
+			for i, f := range fields {
+				if f == "HWaddr" && i+1 < len(fields) {
+					current.MacAddress = strings.ToLower(fields[i+1])
+				}
+			}
+			continue
+		}
+		if current == nil {
+			continue
+		}
+
+		fields := strings.Fields(line)
+		switch {
+		case fields[0] == "inet" && len(fields) > 1 && strings.HasPrefix(fields[1], "addr:"):
+			// Legacy: inet addr:10.0.2.15  Bcast:10.0.2.255  Mask:255.255.255.0
+			addr := strings.TrimPrefix(fields[1], "addr:")
+			mask := ""
+			for _, f := range fields[2:] {
+				if strings.HasPrefix(f, "Mask:") {
+					mask = strings.TrimPrefix(f, "Mask:")
+				}
+			}
+			if cidr, ok := toCidr(addr, mask); ok {
+				current.IPv4CidrBlocks = append(current.IPv4CidrBlocks, cidr)
+			}
+		case fields[0] == "inet" && len(fields) > 1:
+			// inet 10.0.2.15  netmask 255.255.255.0  broadcast 10.0.2.255
+			if cidr, ok := toCidr(fields[1], fieldAfter(fields, "netmask")); ok {
+				current.IPv4CidrBlocks = append(current.IPv4CidrBlocks, cidr)
+			}
+		case fields[0] == "inet6" && len(fields) > 2 && fields[1] == "addr:":
+			// Legacy: inet6 addr: fe80::a00:27ff:fe4e:66a1/64 Scope:Link
+			current.IPv6CidrBlocks = append(current.IPv6CidrBlocks, fields[2])
+		case fields[0] == "inet6" && len(fields) > 1:
+			// inet6 fe80::a00:27ff:fe4e:66a1  prefixlen 64  scopeid 0x20<link>
+			if prefixLen := fieldAfter(fields, "prefixlen"); prefixLen != "" {
+				current.IPv6CidrBlocks = append(current.IPv6CidrBlocks, fields[1]+"/"+prefixLen)
+			}
+		case fields[0] == "ether" && len(fields) > 1:
+			current.MacAddress = strings.ToLower(fields[1])
+		case strings.Contains(line, "MTU:"):
+			// Legacy: UP BROADCAST RUNNING MULTICAST  MTU:1500  Metric:1
+			for _, f := range fields {
+				if f == "UP" {
+					current.State = "UP"
+				}
+				if strings.HasPrefix(f, "MTU:") {
+					current.Mtu, _ = strconv.Atoi(strings.TrimPrefix(f, "MTU:"))
+				}
+			}
+		}
+	}
+	return ifaces
+}
+
+func parseIfconfigFlags(iface *onpremisemodel.NetworkInterfaceProperty, header string) {
+	_, flags, _ := strings.Cut(header, "<")
+	flags, _, _ = strings.Cut(flags, ">")
+	for _, flag := range strings.Split(flags, ",") {
+		if flag == "UP" {
+			iface.State = "UP"
+		}
+	}
+	iface.Mtu, _ = strconv.Atoi(fieldAfter(strings.Fields(header), "mtu"))
+}
+
+// toCidr converts an address and a dotted netmask into a CIDR block (e.g., 10.0.2.15/24).
+func toCidr(addr, mask string) (string, bool) {
+	ip, err := netip.ParseAddr(addr)
+	if err != nil {
+		return "", false
+	}
+	if mask == "" {
+		return netip.PrefixFrom(ip, ip.BitLen()).String(), true
+	}
+	m, err := netip.ParseAddr(mask)
+	if err != nil || !m.Is4() {
+		return "", false
+	}
+	bits := 0
+	for _, b := range m.As4() {
+		for ; b&0x80 != 0; b <<= 1 {
+			bits++
+		}
+	}
+	return netip.PrefixFrom(ip, bits).String(), true
+}
+
+// fieldAfter returns the field following the given keyword, or an empty string.
+func fieldAfter(fields []string, keyword string) string {
+	for i, f := range fields {
+		if f == keyword && i+1 < len(fields) {
+			return fields[i+1]
+		}
+	}
+	return ""
+}
@@ -0,0 +1,99 @@
+package parser
+
+import (
+	"reflect"
+	"testing"
+
+	onpremisemodel "github.com/cloud-barista/cm-model/infra/on-premise-model"
+)
+
+func TestParseIfconfig(t *testing.T) {
+	tests := []struct {
+		name   string
+		output string
+		want   []onpremisemodel.NetworkInterfaceProperty
+	}{
+		{
+			name: "net-tools 2.x",
+			output: `eth0: flags=4163<UP,BROADCAST,RUNNING,MULTICAST>  mtu 9001
+        inet 172.31.5.20  netmask 255.255.240.0  broadcast 172.31.15.255
+        inet6 fe80::8a5:c1ff:fe2e:3b11  prefixlen 64  scopeid 0x20<link>
+        ether 0A:A5:C1:2E:3B:11  txqueuelen 1000  (Ethernet)`,
+			want: []onpremisemodel.NetworkInterfaceProperty{{
+				Name: "eth0", MacAddress: "0a:a5:c1:2e:3b:11", IPv4CidrBlocks: []string{"172.31.5.20/20"},
+				IPv6CidrBlocks: []string{"fe80::8a5:c1ff:fe2e:3b11/64"}, Mtu: 9001, State: "UP",
+			}},
+		},
+		{
+			name: "legacy (RHEL 6)",
+			output: `eth0      Link encap:Ethernet  HWaddr 08:00:27:4E:66:A1
+          inet addr:10.0.2.15  Bcast:10.0.2.255  Mask:255.255.255.0
+          inet6 addr: fe80::a00:27ff:fe4e:66a1/64 Scope:Link
+          UP BROADCAST RUNNING MULTICAST  MTU:1500  Metric:1
+
+eth1      Link encap:Ethernet  HWaddr 08:00:27:4E:66:A2
+          BROADCAST MULTICAST  MTU:1500  Metric:1`,
+			want: []onpremisemodel.NetworkInterfaceProperty{
+				{
+					Name: "eth0", MacAddress: "08:00:27:4e:66:a1", IPv4CidrBlocks: []string{"10.0.2.15/24"},
+					IPv6CidrBlocks: []string{"fe80::a00:27ff:fe4e:66a1/64"}, Mtu: 1500, State: "UP",
+				},
+				{Name: "eth1", MacAddress: "08:00:27:4e:66:a2", Mtu: 1500, State: "DOWN"},
+			},
+		},
+		{
+			name:   "interface down",
+			output: "eth1: flags=4098<BROADCAST,MULTICAST>  mtu 1500\n        ether 0a:a5:c1:2e:3b:12  txqueuelen 1000  (Ethernet)",
+			want:   []onpremisemodel.NetworkInterfaceProperty{{Name: "eth1", MacAddress: "0a:a5:c1:2e:3b:12", Mtu: 1500, State: "DOWN"}},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := ParseIfconfig(tt.output); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("ParseIfconfig() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseIPAddr(t *testing.T) {
+	tests := []struct {
+		name   string
+		output string
+		want   []onpremisemodel.NetworkInterfaceProperty
+	}{
+		{
+			name: "loopback",
+			output: `1: lo: <LOOPBACK,UP,LOWER_UP> mtu 65536 qdisc noqueue state UNKNOWN group default qlen 1000
+    link/loopback 00:00:00:00:00:00 brd 00:00:00:00:00:00
+    inet 127.0.0.1/8 scope host lo`,
+			want: []onpremisemodel.NetworkInterfaceProperty{{Name: "lo", IPv4CidrBlocks: []string{"127.0.0.1/8"}, Mtu: 65536, State: "UP"}},
+		},
+		{
+			name: "veth with peer index",
+			output: `4: veth0@if2: <BROADCAST,MULTICAST,UP,LOWER_UP> mtu 1500 qdisc noqueue master podman0 state UP group default qlen 1000
+    link/ether 8A:0C:4B:7E:21:90 brd ff:ff:ff:ff:ff:ff link-netns netns-2a6f
+    inet6 fe80::880c:4bff:fe7e:2190/64 scope link`,
+			want: []onpremisemodel.NetworkInterfaceProperty{{
+				Name: "veth0", MacAddress: "8a:0c:4b:7e:21:90", IPv6CidrBlocks: []string{"fe80::880c:4bff:fe7e:2190/64"}, Mtu: 1500, State: "UP",
+			}},
+		},
+		{
+			name: "several addresses",
+			output: `2: enp1s0: <BROADCAST,MULTICAST,UP,LOWER_UP> mtu 1500 qdisc fq_codel state UP group default qlen 1000
+    inet 192.168.122.47/24 brd 192.168.122.255 scope global noprefixroute enp1s0
+    inet 192.168.122.48/24 brd 192.168.122.255 scope global secondary enp1s0
+       valid_lft forever preferred_lft forever`,
+			want: []onpremisemodel.NetworkInterfaceProperty{{
+				Name: "enp1s0", IPv4CidrBlocks: []string{"192.168.122.47/24", "192.168.122.48/24"}, Mtu: 1500, State: "UP",
+			}},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := ParseIPAddr(tt.output); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("ParseIPAddr() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
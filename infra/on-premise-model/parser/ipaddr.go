@@ -0,0 +1,51 @@
+package parser
+
+import (
+	"strconv"
+	"strings"
+
+	onpremisemodel "github.com/cloud-barista/cm-model/infra/on-premise-model"
+)
+
+// ParseIPAddr parses the output of `ip addr` (or `ip address show`),
+// which is the replacement of `ifconfig` on distributions without net-tools (e.g., RHEL 8+, Rocky).
+//
+//	2: enp0s3: <BROADCAST,MULTICAST,UP,LOWER_UP> mtu 1500 qdisc fq_codel state UP group default qlen 1000
+//	    link/ether 08:00:27:4e:66:a1 brd ff:ff:ff:ff:ff:ff
+//	    inet 10.0.2.15/24 brd 10.0.2.255 scope global dynamic noprefixroute enp0s3
+//	    inet6 fe80::a00:27ff:fe4e:66a1/64 scope link
+func ParseIPAddr(output string) []onpremisemodel.NetworkInterfaceProperty {
+	var ifaces []onpremisemodel.NetworkInterfaceProperty
+	var current *onpremisemodel.NetworkInterfaceProperty
+
+	for _, line := range lines(output) {
+		fields := strings.Fields(line)
+		if line[0] != ' ' && line[0] != '\t' {
+			if len(fields) < 2 {
+				continue
+			}
+			name := strings.TrimSuffix(fields[1], ":")
+			name, _, _ = strings.Cut(name, "@") // e.g., eth0@if12
+			ifaces = append(ifaces, onpremisemodel.NetworkInterfaceProperty{Name: name})
+			current = &ifaces[len(ifaces)-1]
+			current.Mtu, _ = strconv.Atoi(fieldAfter(fields, "mtu"))
+			current.State = fieldAfter(fields, "state")
+			if current.State == "UNKNOWN" && strings.Contains(line, ",UP") {
+				current.State = "UP" // e.g., loopback interface
+			}
+			continue
+		}
+		if current == nil || len(fields) < 2 {
+			continue
+		}
+		switch fields[0] {
+		case "link/ether":
+			current.MacAddress = strings.ToLower(fields[1])
+		case "inet":
+			current.IPv4CidrBlocks = append(current.IPv4CidrBlocks, fields[1])
+		case "inet6":
+			current.IPv6CidrBlocks = append(current.IPv6CidrBlocks, fields[1])
+		}
+	}
+	return ifaces
+}
@@ -0,0 +1,190 @@
+package parser
+
+import (
+	"net/netip"
+	"strings"
+
+	onpremisemodel "github.com/cloud-barista/cm-model/infra/on-premise-model"
+)
+
+// filterRule is a rule of a filter chain, parsed from `iptables -S`, `iptables-save` or `nft list ruleset`
+// (e.g., -A INPUT -p tcp -m tcp --dport 22 -j ACCEPT).
+type filterRule struct {
+	Chain    string
+	Src      string
+	Dst      string
+	Protocol string
+	SrcPorts string
+	DstPorts string
+	States   []string // conntrack states (e.g., RELATED,ESTABLISHED)
+	Target   string   // e.g., ACCEPT, DROP, REJECT, RETURN, or a user-defined chain
+	Goto     bool     // Whether the target is given by -g (goto) instead of -j (jump)
+	Negated  []string // Options negated by "!" (e.g., -s)
+	Unknown  []string // Options which cannot be represented (e.g., -i, --icmp-type)
+	Line     string
+}
+
+// Options without effect on the filtering policy.
+var ignoredIptablesOptions = map[string]int{
+	"-m": 1, "--comment": 1, "-c": 2, "--reject-with": 1, "--log-prefix": 1, "--log-level": 1,
+}
+
+// parseIptablesRule parses the arguments following "-A" (i.e., chain and matches).
+func parseIptablesRule(line string, args []string) filterRule {
+	rule := filterRule{Line: line}
+	if len(args) > 0 {
+		rule.Chain = args[0]
+		args = args[1:]
+	}
+
+	negate := false
+	for i := 0; i < len(args); i++ {
+		opt := args[i]
+		value := ""
+		if i+1 < len(args) {
+			value = args[i+1]
+		}
+		if opt == "!" {
+			negate = true
+			continue
+		}
+		if negate {
+			rule.Negated = append(rule.Negated, opt)
+			negate = false
+		}
+
+		switch opt {
+		case "-s", "--source", "--src":
+			rule.Src = value
+		case "-d", "--destination", "--dst":
+			rule.Dst = value
+		case "-p", "--protocol":
+			rule.Protocol = value
+		case "--sport", "--source-port", "--sports", "--source-ports":
+			rule.SrcPorts = strings.ReplaceAll(value, ":", "-")
+		case "--dport", "--destination-port", "--dports", "--destination-ports":
+			rule.DstPorts = strings.ReplaceAll(value, ":", "-")
+		case "--state", "--ctstate":
+			rule.States = strings.Split(value, ",")
+		case "-j", "--jump":
+			rule.Target = value
+		case "-g", "--goto":
+			rule.Target, rule.Goto = value, true
+		default:
+			if n, ok := ignoredIptablesOptions[opt]; ok {
+				i += n
+				continue
+			}
+			rule.Unknown = append(rule.Unknown, opt)
+			// Skip the value of the unknown option, if any
+			if value != "" && !strings.HasPrefix(value, "-") && value != "!" {
+				i++
+			}
+			continue
+		}
+		i++
+	}
+	return rule
+}
+
+// toFirewallRule converts a rule of the INPUT or OUTPUT chain with the given action.
+func (r filterRule) toFirewallRule(direction, action string) onpremisemodel.FirewallRuleProperty {
+	return onpremisemodel.FirewallRuleProperty{
+		SrcCIDR:   normalizeCidr(r.Src, r.Dst),
+		SrcPorts:  defaultIfEmpty(r.SrcPorts, onpremisemodel.AllPortsExpr),
+		DstCIDR:   normalizeCidr(r.Dst, r.Src),
+		DstPorts:  defaultIfEmpty(r.DstPorts, onpremisemodel.AllPortsExpr),
+		Protocol:  normalizeProtocol(r.Protocol),
+		Direction: direction,
+		Action:    action,
+	}
+}
+
+// ParseIptables parses the output of `iptables -S` (or `iptables -v -t filter -S`, `iptables-save -t filter`)
+// and returns the rules of the INPUT and OUTPUT chains, each followed by the default policy of the chain.
+// Only rules with ACCEPT, DROP or REJECT targets are returned;
+// use NormalizeIptables to follow user-defined chains and to get the unrepresentable rules.
+func ParseIptables(output string) []onpremisemodel.FirewallRuleProperty {
+	rulesByChain := make(map[string][]onpremisemodel.FirewallRuleProperty)
+	policies := make(map[string]string)
+
+	for _, line := range lines(output) {
+		args := splitArgs(strings.TrimSpace(line))
+		if len(args) < 2 {
+			continue
+		}
+		switch args[0] {
+		case "-P":
+			if len(args) >= 3 {
+				policies[args[1]] = args[2]
+			}
+		case "-A":
+			rule := parseIptablesRule(line, args[1:])
+			direction, ok := chainDirections[rule.Chain]
+			action, known := targetActions[rule.Target]
+			if !ok || !known || len(rule.Negated) > 0 || len(rule.Unknown) > 0 {
+				continue
+			}
+			if _, implicit := stateReason(rule.States); implicit {
+				continue // e.g., RELATED,ESTABLISHED; a NEW state is the rule itself
+			}
+			rulesByChain[rule.Chain] = append(rulesByChain[rule.Chain], rule.toFirewallRule(direction, action))
+		default:
+			// iptables-save: :INPUT ACCEPT [0:0]
+			if strings.HasPrefix(args[0], ":") {
+				policies[strings.TrimPrefix(args[0], ":")] = args[1]
+			}
+		}
+	}
+
+	var rules []onpremisemodel.FirewallRuleProperty
+	for _, chain := range []string{"INPUT", "OUTPUT"} {
+		rules = append(rules, rulesByChain[chain]...)
+		if action, ok := targetActions[policies[chain]]; ok {
+			rules = append(rules, filterRule{}.toFirewallRule(chainDirections[chain], action))
+		}
+	}
+	return rules
+}
+
+// Directions of the built-in chains of the filter table.
+var chainDirections = map[string]string{"INPUT": "inbound", "OUTPUT": "outbound"}
+
+// Actions of the terminating targets.
+var targetActions = map[string]string{"ACCEPT": "allow", "DROP": "deny", "REJECT": "deny"}
+
+// normalizeCidr returns the CIDR block of an address (e.g., 10.0.0.1 -> 10.0.0.1/32),
+// or the "any" block of the address family of the peer if the address is empty.
+func normalizeCidr(addr, peer string) string {
+	if addr == "" {
+		if strings.Contains(peer, ":") {
+			return "::/0"
+		}
+		return "0.0.0.0/0"
+	}
+	if ip, err := netip.ParseAddr(addr); err == nil {
+		return netip.PrefixFrom(ip, ip.BitLen()).String()
+	}
+	if prefix, err := netip.ParsePrefix(addr); err == nil {
+		return prefix.Masked().String()
+	}
+	return addr
+}
+
+func normalizeProtocol(protocol string) string {
+	switch strings.ToLower(protocol) {
+	case "", "all", "0":
+		return "*"
+	case "ipv6-icmp", "icmp6", "icmpv6":
+		return "ICMPv6"
+	default:
+		return strings.ToUpper(protocol)
+	}
+}
+
+func defaultIfEmpty(value, defaultValue string) string {
+	if value == "" {
+		return defaultValue
+	}
+	return value
+}
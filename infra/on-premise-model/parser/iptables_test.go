@@ -0,0 +1,70 @@
+package parser
+
+import (
+	"reflect"
+	"testing"
+
+	onpremisemodel "github.com/cloud-barista/cm-model/infra/on-premise-model"
+)
+
+func TestParseIptables(t *testing.T) {
+	rule := func(src, dstPorts, protocol, direction, action string) onpremisemodel.FirewallRuleProperty {
+		dst := "0.0.0.0/0"
+		if src == "::/0" {
+			dst = "::/0"
+		}
+		return onpremisemodel.FirewallRuleProperty{
+			SrcCIDR: src, SrcPorts: "*", DstCIDR: dst, DstPorts: dstPorts, Protocol: protocol, Direction: direction, Action: action,
+		}
+	}
+	tests := []struct {
+		name   string
+		output string
+		want   []onpremisemodel.FirewallRuleProperty
+	}{
+		{
+			name: "iptables -S",
+			output: `-P INPUT DROP
+-P FORWARD DROP
+-P OUTPUT ACCEPT
+-A INPUT -p tcp -m tcp --dport 22 -j ACCEPT
+-A INPUT -s 10.0.0.5 -p udp -m multiport --dports 5000:5010 -j REJECT --reject-with icmp-port-unreachable`,
+			want: []onpremisemodel.FirewallRuleProperty{
+				rule("0.0.0.0/0", "22", "TCP", "inbound", "allow"),
+				rule("10.0.0.5/32", "5000-5010", "UDP", "inbound", "deny"),
+				rule("0.0.0.0/0", "*", "*", "inbound", "deny"),
+				rule("0.0.0.0/0", "*", "*", "outbound", "allow"),
+			},
+		},
+		{
+			name: "iptables-save with states",
+			output: `*filter
+:INPUT ACCEPT [0:0]
+:OUTPUT ACCEPT [412:61023]
+-A INPUT -m state --state RELATED,ESTABLISHED -j ACCEPT
+-A INPUT -p tcp -m state --state NEW -m tcp --dport 8080 -j ACCEPT
+COMMIT`,
+			want: []onpremisemodel.FirewallRuleProperty{
+				rule("0.0.0.0/0", "8080", "TCP", "inbound", "allow"),
+				rule("0.0.0.0/0", "*", "*", "inbound", "allow"),
+				rule("0.0.0.0/0", "*", "*", "outbound", "allow"),
+			},
+		},
+		{
+			name: "skipped rules",
+			output: `-A INPUT -i lo -j ACCEPT
+-A INPUT ! -s 10.0.0.0/8 -j DROP
+-A INPUT -j ufw-before-input
+-A FORWARD -j DROP
+-A INPUT -p ipv6-icmp -j ACCEPT`,
+			want: []onpremisemodel.FirewallRuleProperty{rule("0.0.0.0/0", "*", "ICMPv6", "inbound", "allow")},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := ParseIptables(tt.output); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("ParseIptables() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
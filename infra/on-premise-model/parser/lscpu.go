@@ -0,0 +1,81 @@
+package parser
+
+import (
+	"strconv"
+	"strings"
+
+	onpremisemodel "github.com/cloud-barista/cm-model/infra/on-premise-model"
+)
+
+// ParseLscpu parses the output of `lscpu`.
+// Threads is the number of logical CPUs per socket (i.e., cores per socket × threads per core).
+// On ARM servers reporting no socket, clusters are used as sockets.
+//
+//	Architecture:            x86_64
+//	CPU(s):                  4
+//	Vendor ID:               GenuineIntel
+//	  Model name:            Intel(R) Xeon(R) Gold 6140 CPU @ 2.30GHz
+//	    Thread(s) per core:  2
+//	    Core(s) per socket:  2
+//	    Socket(s):           1
+//	CPU max MHz:             3700.0000
+func ParseLscpu(output string) onpremisemodel.CpuProperty {
+	var cpu onpremisemodel.CpuProperty
+	var logicalCpus, threadsPerCore, coresPerSocket, sockets, clusters uint32
+	var maxMHz, currentMHz float64
+
+	for _, line := range lines(output) {
+		key, value, ok := keyValue(line, ":")
+		if !ok {
+			continue
+		}
+		switch key {
+		case "Architecture":
+			cpu.Architecture = value
+		case "CPU(s)":
+			logicalCpus = parseUint32(value)
+		case "Thread(s) per core":
+			threadsPerCore = parseUint32(value)
+		case "Core(s) per socket", "Core(s) per cluster":
+			coresPerSocket = parseUint32(value)
+		case "Socket(s)":
+			sockets = parseUint32(value)
+		case "Cluster(s)":
+			clusters = parseUint32(value)
+		case "Vendor ID":
+			cpu.Vendor = value
+		case "Model name":
+			cpu.Model = value
+		case "CPU max MHz":
+			maxMHz, _ = strconv.ParseFloat(value, 64)
+		case "CPU MHz":
+			currentMHz, _ = strconv.ParseFloat(value, 64)
+		}
+	}
+
+	if sockets == 0 {
+		sockets = max(clusters, 1)
+	}
+	if threadsPerCore == 0 {
+		threadsPerCore = 1
+	}
+	if coresPerSocket == 0 && logicalCpus > 0 {
+		coresPerSocket = logicalCpus / sockets / threadsPerCore
+	}
+	cpu.Cpus = sockets
+	cpu.Cores = coresPerSocket
+	cpu.Threads = coresPerSocket * threadsPerCore
+	if maxMHz == 0 {
+		maxMHz = currentMHz
+	}
+	cpu.MaxSpeed = float32(maxMHz / 1000)
+	return cpu
+}
+
+func parseUint32(s string) uint32 {
+	v, err := strconv.ParseUint(strings.TrimSpace(s), 10, 32)
+	if err != nil {
+		return 0
+	}
+	return uint32(v)
+}
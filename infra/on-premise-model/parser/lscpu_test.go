@@ -0,0 +1,54 @@
+package parser
+
+import (
+	"testing"
+
+	onpremisemodel "github.com/cloud-barista/cm-model/infra/on-premise-model"
+)
+
+func TestParseLscpu(t *testing.T) {
+	tests := []struct {
+		name   string
+		output string
+		want   onpremisemodel.CpuProperty
+	}{
+		{
+			name: "two sockets with hyper-threading",
+			output: `Architecture:        x86_64
+CPU(s):              72
+Thread(s) per core:  2
+Core(s) per socket:  18
+Socket(s):           2
+Vendor ID:           GenuineIntel
+Model name:          Intel(R) Xeon(R) Gold 6140 CPU @ 2.30GHz
+CPU MHz:             1000.000
+CPU max MHz:         3700.0000`,
+			want: onpremisemodel.CpuProperty{
+				Architecture: "x86_64", Cpus: 2, Cores: 18, Threads: 36, MaxSpeed: 3.7,
+				Vendor: "GenuineIntel", Model: "Intel(R) Xeon(R) Gold 6140 CPU @ 2.30GHz",
+			},
+		},
+		{
+			name:   "current speed without max speed",
+			output: "CPU(s): 2\nThread(s) per core: 1\nCore(s) per socket: 2\nSocket(s): 1\nCPU MHz: 2499.998",
+			want:   onpremisemodel.CpuProperty{Cpus: 1, Cores: 2, Threads: 2, MaxSpeed: 2.499998},
+		},
+		{
+			name:   "ARM clusters",
+			output: "Architecture: aarch64\nCPU(s): 8\nThread(s) per core: 1\nCore(s) per cluster: 4\nSocket(s): -\nCluster(s): 2",
+			want:   onpremisemodel.CpuProperty{Architecture: "aarch64", Cpus: 2, Cores: 4, Threads: 4},
+		},
+		{
+			name:   "cores derived from CPUs",
+			output: "CPU(s): 4",
+			want:   onpremisemodel.CpuProperty{Cpus: 1, Cores: 4, Threads: 4},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := ParseLscpu(tt.output); got != tt.want {
+				t.Errorf("ParseLscpu() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
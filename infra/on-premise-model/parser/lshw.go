@@ -0,0 +1,94 @@
+package parser
+
+import (
+	"strings"
+
+	onpremisemodel "github.com/cloud-barista/cm-model/infra/on-premise-model"
+)
+
+// ParseLshwNetwork parses the output of `lshw -c network`.
+// Only devices with a logical name are returned, with their MAC address (serial) and link state.
+//
+//	*-network
+//	     description: Ethernet interface
+//	     logical name: enp0s3
+//	     serial: 08:00:27:4e:66:a1
+//	     configuration: autonegotiation=on broadcast=yes driver=e1000 ip=10.0.2.15 link=yes multicast=yes
+func ParseLshwNetwork(output string) []onpremisemodel.NetworkInterfaceProperty {
+	var ifaces []onpremisemodel.NetworkInterfaceProperty
+	var current onpremisemodel.NetworkInterfaceProperty
+
+	flush := func() {
+		if current.Name != "" {
+			ifaces = append(ifaces, current)
+		}
+		current = onpremisemodel.NetworkInterfaceProperty{}
+	}
+
+	for _, line := range lines(output) {
+		trimmed := strings.TrimSpace(line)
+		if strings.HasPrefix(trimmed, "*-") {
+			flush()
+			continue
+		}
+		key, value, ok := keyValue(trimmed, ":")
+		if !ok {
+			continue
+		}
+		switch key {
+		case "logical name":
+			// The first logical name is the interface name
+			if current.Name == "" {
+				current.Name = value
+			}
+		case "serial":
+			current.MacAddress = strings.ToLower(value)
+		case "configuration":
+			for _, kv := range strings.Fields(value) {
+				switch kv {
+				case "link=yes":
+					current.State = "UP"
+				case "link=no":
+					current.State = "DOWN"
+				}
+			}
+		}
+	}
+	flush()
+	return ifaces
+}
+
+// MergeInterfaces complements the interfaces with the properties of the same-named interfaces in others
+// (e.g., MAC addresses from `lshw -c network` into interfaces from `ip addr`). Non-empty properties are kept.
+func MergeInterfaces(ifaces []onpremisemodel.NetworkInterfaceProperty, others []onpremisemodel.NetworkInterfaceProperty) []onpremisemodel.NetworkInterfaceProperty {
+	merged := append([]onpremisemodel.NetworkInterfaceProperty(nil), ifaces...)
+	for _, other := range others {
+		found := false
+		for i := range merged {
+			iface := &merged[i]
+			if iface.Name != other.Name {
+				continue
+			}
+			found = true
+			if iface.MacAddress == "" {
+				iface.MacAddress = other.MacAddress
+			}
+			if len(iface.IPv4CidrBlocks) == 0 {
+				iface.IPv4CidrBlocks = other.IPv4CidrBlocks
+			}
+			if len(iface.IPv6CidrBlocks) == 0 {
+				iface.IPv6CidrBlocks = other.IPv6CidrBlocks
+			}
+			if iface.Mtu == 0 {
+				iface.Mtu = other.Mtu
+			}
+			if iface.State == "" {
+				iface.State = other.State
+			}
+		}
+		if !found {
+			merged = append(merged, other)
+		}
+	}
+	return merged
+}
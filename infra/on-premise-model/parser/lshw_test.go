@@ -0,0 +1,69 @@
+package parser
+
+import (
+	"reflect"
+	"testing"
+
+	onpremisemodel "github.com/cloud-barista/cm-model/infra/on-premise-model"
+)
+
+func TestParseLshwNetwork(t *testing.T) {
+	tests := []struct {
+		name   string
+		output string
+		want   []onpremisemodel.NetworkInterfaceProperty
+	}{
+		{
+			name: "link up and down",
+			output: `  *-network:0
+       description: Ethernet interface
+       logical name: eth0
+       serial: 0A:A5:C1:2E:3B:11
+       configuration: broadcast=yes driver=ena ip=172.31.5.20 link=yes multicast=yes
+  *-network:1
+       logical name: eth1
+       serial: 0a:a5:c1:2e:3b:12
+       configuration: broadcast=yes driver=ena link=no multicast=yes`,
+			want: []onpremisemodel.NetworkInterfaceProperty{
+				{Name: "eth0", MacAddress: "0a:a5:c1:2e:3b:11", State: "UP"},
+				{Name: "eth1", MacAddress: "0a:a5:c1:2e:3b:12", State: "DOWN"},
+			},
+		},
+		{
+			name: "device without logical name and several logical names",
+			output: `  *-network UNCLAIMED
+       description: Network controller
+       serial: 00:11:22:33:44:55
+  *-network
+       logical name: br0
+       logical name: /dev/br0
+       serial: 52:54:00:9a:3c:71`,
+			want: []onpremisemodel.NetworkInterfaceProperty{{Name: "br0", MacAddress: "52:54:00:9a:3c:71"}},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := ParseLshwNetwork(tt.output); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("ParseLshwNetwork() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestMergeInterfaces(t *testing.T) {
+	ifaces := []onpremisemodel.NetworkInterfaceProperty{{Name: "eth0", IPv4CidrBlocks: []string{"10.0.0.5/24"}, Mtu: 1500, State: "UP"}}
+	others := []onpremisemodel.NetworkInterfaceProperty{
+		{Name: "eth0", MacAddress: "0a:a5:c1:2e:3b:11", State: "DOWN"},
+		{Name: "eth1", MacAddress: "0a:a5:c1:2e:3b:12", State: "DOWN"},
+	}
+	want := []onpremisemodel.NetworkInterfaceProperty{
+		{Name: "eth0", MacAddress: "0a:a5:c1:2e:3b:11", IPv4CidrBlocks: []string{"10.0.0.5/24"}, Mtu: 1500, State: "UP"},
+		{Name: "eth1", MacAddress: "0a:a5:c1:2e:3b:12", State: "DOWN"},
+	}
+	if got := MergeInterfaces(ifaces, others); !reflect.DeepEqual(got, want) {
+		t.Errorf("MergeInterfaces() = %+v, want %+v", got, want)
+	}
+	if ifaces[0].MacAddress != "" {
+		t.Error("MergeInterfaces() modified its argument")
+	}
+}
@@ -0,0 +1,197 @@
+package parser
+
+import (
+	"regexp"
+	"strings"
+)
+
+// Table families of nftables containing IP filter chains.
+var nftFamilies = map[string]bool{"ip": true, "ip6": true, "inet": true}
+
+// Verdicts of nftables and the corresponding iptables targets.
+var nftVerdicts = map[string]string{"accept": "ACCEPT", "drop": "DROP", "reject": "REJECT", "return": "RETURN", "continue": ""}
+
+// nftAnonymousSet matches an anonymous set (e.g., { 80, 443 }).
+var nftAnonymousSet = regexp.MustCompile(`\{\s*([^{}]*?)\s*\}`)
+
+// NormalizeNftables flattens the `nft list ruleset` output into the effective policy of
+// the filter chains hooked to input and output in ip, ip6 and inet tables.
+// Chains reached by jump and goto are inlined with the matches of the jumping rule,
+// and unreachable or redundant rules are removed. Base chains hooked to the same direction in tables of
+// the same family are intersected, since a packet must be accepted by each of them.
+func NormalizeNftables(ruleset string) NormalizedFirewall {
+	rs := newFilterRuleset()
+	var family, table string
+	var current *filterChain
+	skipDepth := 0 // Depth of a skipped block (e.g., set, map, flowtable)
+
+	for _, line := range lines(ruleset) {
+		line = strings.TrimSpace(line)
+		if skipDepth > 0 {
+			skipDepth += strings.Count(line, "{") - strings.Count(line, "}")
+			continue
+		}
+		fields := strings.Fields(line)
+
+		switch {
+		case fields[0] == "table" && len(fields) >= 3:
+			family, table = fields[1], fields[2]
+		case fields[0] == "chain" && len(fields) >= 2:
+			if !nftFamilies[family] {
+				skipDepth = strings.Count(line, "{") - strings.Count(line, "}")
+				continue
+			}
+			current = rs.chain(nftChainName(family, table, fields[1]))
+		case line == "}":
+			if current != nil {
+				current = nil
+			} else {
+				family, table = "", ""
+			}
+		case strings.HasSuffix(line, "{"):
+			// set, map, flowtable, counter, quota, etc.
+			skipDepth = strings.Count(line, "{") - strings.Count(line, "}")
+		case current == nil:
+			continue
+		case fields[0] == "type":
+			// type filter hook input priority filter; policy drop;
+			hook := fieldAfter(fields, "hook")
+			direction, ok := map[string]string{"input": "inbound", "output": "outbound"}[hook]
+			if fieldAfter(fields, "type") != "filter" || !ok {
+				continue
+			}
+			current.Direction = direction
+			current.Family = family
+			current.Policy = "ACCEPT"
+			if policy := strings.TrimSuffix(fieldAfter(fields, "policy"), ";"); policy != "" {
+				current.Policy = strings.ToUpper(policy)
+			}
+			rs.Base = append(rs.Base, current.Name)
+		default:
+			rule := parseNftRule(line, family, table)
+			rule.Chain = current.Name
+			current.Rules = append(current.Rules, rule)
+		}
+	}
+	return rs.normalize()
+}
+
+func nftChainName(family, table, chain string) string {
+	return family + "/" + table + "/" + chain
+}
+
+// parseNftRule parses a rule statement of nftables
+// (e.g., ip saddr 10.0.0.0/8 tcp dport { 22, 80 } counter packets 0 bytes 0 accept).
+func parseNftRule(line, family, table string) filterRule {
+	rule := filterRule{Line: line}
+
+	// Flatten anonymous sets into comma-separated values (e.g., { 80, 443 } -> 80,443)
+	flattened := nftAnonymousSet.ReplaceAllStringFunc(line, func(set string) string {
+		inner := nftAnonymousSet.FindStringSubmatch(set)[1]
+		return strings.Join(strings.Fields(strings.ReplaceAll(inner, ",", " ")), ",")
+	})
+	tokens := splitArgs(flattened)
+
+	for i := 0; i < len(tokens); i++ {
+		token := tokens[i]
+		next := func() string {
+			if i+1 < len(tokens) {
+				i++
+				return tokens[i]
+			}
+			return ""
+		}
+		// value returns the next value, recording the negation of the match, if any
+		value := func(match string) string {
+			v := next()
+			if v == "!=" {
+				rule.Negated = append(rule.Negated, match)
+				v = next()
+			}
+			if strings.HasPrefix(v, "@") {
+				rule.Unknown = append(rule.Unknown, match+" "+v) // Named set
+			}
+			return v
+		}
+
+		switch token {
+		case "ip", "ip6":
+			switch key := next(); key {
+			case "saddr":
+				rule.Src = value(token + " saddr")
+			case "daddr":
+				rule.Dst = value(token + " daddr")
+			case "protocol", "nexthdr":
+				rule.Protocol = value(token + " " + key)
+			default:
+				rule.Unknown = append(rule.Unknown, token+" "+key)
+			}
+		case "tcp", "udp", "sctp", "th":
+			if token != "th" {
+				rule.Protocol = token
+			}
+			switch key := next(); key {
+			case "dport":
+				rule.DstPorts = value(token + " dport")
+			case "sport":
+				rule.SrcPorts = value(token + " sport")
+			default:
+				rule.Unknown = append(rule.Unknown, token+" "+key)
+			}
+		case "icmp", "icmpv6":
+			rule.Protocol = token
+			key := token + " " + next()
+			value(key)
+			rule.Unknown = append(rule.Unknown, key)
+		case "meta":
+			switch key := next(); key {
+			case "l4proto":
+				rule.Protocol = value("meta l4proto")
+			case "nfproto":
+				next()
+			default:
+				rule.Unknown = append(rule.Unknown, "meta "+key)
+			}
+		case "ct":
+			switch key := next(); key {
+			case "state":
+				rule.States = strings.Split(strings.ToUpper(value("ct state")), ",")
+			default:
+				rule.Unknown = append(rule.Unknown, "ct "+key)
+			}
+		case "iifname", "oifname", "iif", "oif":
+			value(token)
+			rule.Unknown = append(rule.Unknown, token)
+		case "counter":
+			for i+2 < len(tokens) && (tokens[i+1] == "packets" || tokens[i+1] == "bytes") {
+				i += 2
+			}
+		case "log":
+			for i+2 < len(tokens) && contains([]string{"prefix", "level", "flags", "group", "snaplen", "queue-threshold"}, tokens[i+1]) {
+				i += 2
+			}
+		case "comment":
+			next()
+		case "jump", "goto":
+			rule.Target = nftChainName(family, table, next())
+			rule.Goto = token == "goto"
+		case "reject":
+			rule.Target = "REJECT"
+			i = len(tokens) // Ignore "with icmp type ..." and "with tcp reset"
+		default:
+			if target, ok := nftVerdicts[token]; ok {
+				rule.Target = target
+				continue
+			}
+			rule.Unknown = append(rule.Unknown, token)
+			// Skip the arguments of the unknown statement until the next verdict
+			for i+1 < len(tokens) {
+				if _, ok := nftVerdicts[tokens[i+1]]; ok || tokens[i+1] == "jump" || tokens[i+1] == "goto" {
+					break
+				}
+				i++
+			}
+		}
+	}
+	return rule
+}
@@ -0,0 +1,49 @@
+package parser
+
+import (
+	"strings"
+
+	onpremisemodel "github.com/cloud-barista/cm-model/infra/on-premise-model"
+)
+
+// ParseOsRelease parses the content of `/etc/os-release`.
+//
+//	PRETTY_NAME="Ubuntu 22.04.3 LTS"
+//	NAME="Ubuntu"
+//	VERSION_ID="22.04"
+//	ID=ubuntu
+//	ID_LIKE=debian
+func ParseOsRelease(content string) onpremisemodel.OsProperty {
+	var os onpremisemodel.OsProperty
+	for _, line := range lines(content) {
+		line = strings.TrimSpace(line)
+		if strings.HasPrefix(line, "#") {
+			continue
+		}
+		key, value, ok := keyValue(line, "=")
+		if !ok {
+			continue
+		}
+		value = strings.Trim(value, `"'`)
+		switch key {
+		case "PRETTY_NAME":
+			os.PrettyName = value
+		case "NAME":
+			os.Name = value
+		case "VERSION":
+			os.Version = value
+		case "VERSION_ID":
+			os.VersionID = value
+		case "VERSION_CODENAME":
+			os.VersionCodename = value
+		case "ID":
+			os.ID = value
+		case "ID_LIKE":
+			os.IDLike = value
+		}
+	}
+	if os.PrettyName == "" {
+		os.PrettyName = strings.TrimSpace(os.Name + " " + os.Version)
+	}
+	return os
+}
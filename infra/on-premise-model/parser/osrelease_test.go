@@ -0,0 +1,48 @@
+package parser
+
+import (
+	"testing"
+
+	onpremisemodel "github.com/cloud-barista/cm-model/infra/on-premise-model"
+)
+
+func TestParseOsRelease(t *testing.T) {
+	tests := []struct {
+		name    string
+		content string
+		want    onpremisemodel.OsProperty
+	}{
+		{
+			name: "quoted and unquoted values",
+			content: `# comment
+PRETTY_NAME="Ubuntu 22.04.3 LTS"
+NAME="Ubuntu"
+VERSION_ID="22.04"
+VERSION="22.04.3 LTS (Jammy Jellyfish)"
+VERSION_CODENAME=jammy
+ID=ubuntu
+ID_LIKE=debian`,
+			want: onpremisemodel.OsProperty{
+				PrettyName: "Ubuntu 22.04.3 LTS", Name: "Ubuntu", VersionID: "22.04", Version: "22.04.3 LTS (Jammy Jellyfish)",
+				VersionCodename: "jammy", ID: "ubuntu", IDLike: "debian",
+			},
+		},
+		{
+			name:    "single quotes",
+			content: "NAME='Rocky Linux'\nID='rocky'\nID_LIKE='rhel centos fedora'\nVERSION_ID='9.3'",
+			want:    onpremisemodel.OsProperty{PrettyName: "Rocky Linux", Name: "Rocky Linux", ID: "rocky", IDLike: "rhel centos fedora", VersionID: "9.3"},
+		},
+		{
+			name:    "pretty name from name and version",
+			content: "NAME=\"CentOS Linux\"\nVERSION=\"7 (Core)\"\nID=\"centos\"",
+			want:    onpremisemodel.OsProperty{PrettyName: "CentOS Linux 7 (Core)", Name: "CentOS Linux", Version: "7 (Core)", ID: "centos"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := ParseOsRelease(tt.content); got != tt.want {
+				t.Errorf("ParseOsRelease() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,67 @@
+// Package parser builds on-premise model properties from the captured text output of Linux commands
+// (e.g., `ip route`, `ifconfig`, `df -h`, `lshw -c network`, `iptables -S`, `lscpu` and `/etc/os-release`),
+// so collector agents do not need to implement their own parsing.
+// The parsers are lenient: lines that cannot be recognized are skipped.
+package parser
+
+import (
+	"bufio"
+	"strings"
+)
+
+// lines returns the non-empty lines of the text with trailing spaces removed.
+func lines(text string) []string {
+	var result []string
+	scanner := bufio.NewScanner(strings.NewReader(text))
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+	for scanner.Scan() {
+		line := strings.TrimRight(scanner.Text(), " \t\r")
+		if strings.TrimSpace(line) != "" {
+			result = append(result, line)
+		}
+	}
+	return result
+}
+
+// keyValue splits a "Key: Value" line.
+func keyValue(line, sep string) (string, string, bool) {
+	key, value, ok := strings.Cut(line, sep)
+	if !ok {
+		return "", "", false
+	}
+	return strings.TrimSpace(key), strings.TrimSpace(value), true
+}
+
+// splitArgs splits a command line into arguments, honoring single and double quotes.
+func splitArgs(line string) []string {
+	var args []string
+	var current strings.Builder
+	var quote rune
+	inArg := false
+	for _, r := range line {
+		switch {
+		case quote != 0:
+			if r == quote {
+				quote = 0
+			} else {
+				current.WriteRune(r)
+			}
+		case r == '"' || r == '\'':
+			quote = r
+			inArg = true
+		case r == ' ' || r == '\t':
+			if inArg {
+				args = append(args, current.String())
+				current.Reset()
+				inArg = false
+			}
+		default:
+			current.WriteRune(r)
+			inArg = true
+		}
+	}
+	if inArg {
+		args = append(args, current.String())
+	}
+	return args
+}
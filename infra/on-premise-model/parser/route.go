@@ -0,0 +1,120 @@
+package parser
+
+import (
+	"net/netip"
+	"strconv"
+	"strings"
+
+	onpremisemodel "github.com/cloud-barista/cm-model/infra/on-premise-model"
+)
+
+// Route types of `ip route` which may precede the destination.
+var routeTypes = map[string]bool{
+	"unicast": true, "local": true, "broadcast": true, "multicast": true,
+	"throw": true, "unreachable": true, "prohibit": true, "blackhole": true, "nat": true, "anycast": true,
+}
+
+// ParseIPRoute parses the output of `ip route` (or `ip -6 route`, `ip route show table all`).
+//
+//	default via 10.0.2.2 dev enp0s3 proto dhcp src 10.0.2.15 metric 100
+//	10.0.2.0/24 dev enp0s3 proto kernel scope link src 10.0.2.15 metric 100 linkdown
+//	default proto static metric 100
+//		nexthop via 10.0.0.1 dev eth0 weight 1
+//		nexthop via 10.0.1.1 dev eth1 weight 1
+//
+// A host destination without prefix length is converted to a CIDR block (e.g., 10.0.0.5 -> 10.0.0.5/32).
+// Each nexthop of a multipath route, on its own line or on the line of the route (`ip -o route`),
+// becomes a route with the destination and attributes of the multipath route.
+func ParseIPRoute(output string) []onpremisemodel.RouteProperty {
+	var routes []onpremisemodel.RouteProperty
+	var multipath onpremisemodel.RouteProperty // The last route, shared by its nexthops
+	pending := false                           // Whether the last route is waiting for its first nexthop
+	for _, line := range lines(output) {
+		fields := strings.Fields(line)
+		if len(fields) > 0 && routeTypes[fields[0]] {
+			fields = fields[1:]
+		}
+		head, nexthops := splitNexthops(fields)
+		if len(head) > 0 {
+			multipath = onpremisemodel.RouteProperty{Destination: normalizeDestination(head[0]), LinkState: "UP"}
+			parseRouteFields(&multipath, head[1:])
+			if multipath.Scope == "" {
+				multipath.Scope = "global"
+			}
+			routes = append(routes, multipath)
+			pending = true
+		} else if len(routes) == 0 {
+			continue // A nexthop without route
+		}
+
+		for _, nexthop := range nexthops {
+			route := multipath
+			parseRouteFields(&route, nexthop)
+			if pending {
+				routes[len(routes)-1] = route
+				pending = false
+			} else {
+				routes = append(routes, route)
+			}
+		}
+	}
+	return routes
+}
+
+// splitNexthops splits the fields of a route line at each "nexthop" keyword.
+func splitNexthops(fields []string) ([]string, [][]string) {
+	var nexthops [][]string
+	for i := len(fields) - 1; i >= 0; i-- {
+		if fields[i] == "nexthop" {
+			nexthops = append([][]string{fields[i+1:]}, nexthops...)
+			fields = fields[:i]
+		}
+	}
+	return fields, nexthops
+}
+
+// parseRouteFields sets the attributes of a route (or a nexthop) given after its destination.
+func parseRouteFields(route *onpremisemodel.RouteProperty, fields []string) {
+	for i := 0; i < len(fields); i++ {
+		next := func() string {
+			if i+1 < len(fields) {
+				i++
+				return fields[i]
+			}
+			return ""
+		}
+		switch fields[i] {
+		case "via":
+			// An address family may precede the gateway (e.g., via inet6 fe80::1)
+			gateway := next()
+			if gateway == "inet" || gateway == "inet6" {
+				gateway = next()
+			}
+			route.Gateway = gateway
+		case "dev":
+			route.Interface = next()
+		case "proto":
+			route.Protocol = next()
+		case "scope":
+			route.Scope = next()
+		case "src":
+			route.Source = next()
+		case "metric":
+			route.Metric, _ = strconv.Atoi(next())
+		case "linkdown", "dead":
+			route.LinkState = "DOWN"
+		}
+	}
+}
+
+func normalizeDestination(destination string) string {
+	if destination == "default" {
+		return destination
+	}
+	if !strings.Contains(destination, "/") {
+		if addr, err := netip.ParseAddr(destination); err == nil {
+			return netip.PrefixFrom(addr, addr.BitLen()).String()
+		}
+	}
+	return destination
+}
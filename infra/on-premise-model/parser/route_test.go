@@ -0,0 +1,95 @@
+package parser
+
+import (
+	"reflect"
+	"testing"
+
+	onpremisemodel "github.com/cloud-barista/cm-model/infra/on-premise-model"
+)
+
+func TestParseIPRoute(t *testing.T) {
+	tests := []struct {
+		name   string
+		output string
+		want   []onpremisemodel.RouteProperty
+	}{
+		{
+			name:   "default route",
+			output: "default via 10.0.2.2 dev enp0s3 proto dhcp src 10.0.2.15 metric 100",
+			want: []onpremisemodel.RouteProperty{{
+				Destination: "default", Gateway: "10.0.2.2", Interface: "enp0s3", Protocol: "dhcp",
+				Source: "10.0.2.15", Metric: 100, Scope: "global", LinkState: "UP",
+			}},
+		},
+		{
+			name:   "gateway of another address family",
+			output: "default via inet6 fe80::1 dev eth0 proto static",
+			want: []onpremisemodel.RouteProperty{{
+				Destination: "default", Gateway: "fe80::1", Interface: "eth0", Protocol: "static", Scope: "global", LinkState: "UP",
+			}},
+		},
+		{
+			name:   "IPv4 gateway with family",
+			output: "10.1.0.0/16 via inet 10.0.0.1 dev eth0",
+			want: []onpremisemodel.RouteProperty{{
+				Destination: "10.1.0.0/16", Gateway: "10.0.0.1", Interface: "eth0", Scope: "global", LinkState: "UP",
+			}},
+		},
+		{
+			name:   "host destination and link down",
+			output: "10.0.0.5 dev docker0 proto kernel scope link src 10.0.0.1 linkdown",
+			want: []onpremisemodel.RouteProperty{{
+				Destination: "10.0.0.5/32", Interface: "docker0", Protocol: "kernel", Scope: "link", Source: "10.0.0.1", LinkState: "DOWN",
+			}},
+		},
+		{
+			name:   "route type",
+			output: "unreachable 10.99.0.0/16 proto static",
+			want: []onpremisemodel.RouteProperty{{
+				Destination: "10.99.0.0/16", Protocol: "static", Scope: "global", LinkState: "UP",
+			}},
+		},
+		{
+			name:   "truncated via",
+			output: "default via",
+			want:   []onpremisemodel.RouteProperty{{Destination: "default", Scope: "global", LinkState: "UP"}},
+		},
+		{
+			name: "multipath",
+			output: "default proto static metric 100\n" +
+				"\tnexthop via 10.0.0.1 dev eth0 weight 1\n" +
+				"\tnexthop via 10.0.1.1 dev eth1 weight 1 linkdown\n" +
+				"10.0.0.0/24 dev eth0 proto kernel scope link src 10.0.0.21",
+			want: []onpremisemodel.RouteProperty{
+				{Destination: "default", Gateway: "10.0.0.1", Interface: "eth0", Protocol: "static", Metric: 100, Scope: "global", LinkState: "UP"},
+				{Destination: "default", Gateway: "10.0.1.1", Interface: "eth1", Protocol: "static", Metric: 100, Scope: "global", LinkState: "DOWN"},
+				{Destination: "10.0.0.0/24", Interface: "eth0", Protocol: "kernel", Scope: "link", Source: "10.0.0.21", LinkState: "UP"},
+			},
+		},
+		{
+			name:   "multipath on one line",
+			output: "10.8.0.0/16 proto bird metric 32 nexthop via inet6 fe80::1 dev eth0 weight 1 nexthop via 10.0.1.1 dev eth1 weight 2",
+			want: []onpremisemodel.RouteProperty{
+				{Destination: "10.8.0.0/16", Gateway: "fe80::1", Interface: "eth0", Protocol: "bird", Metric: 32, Scope: "global", LinkState: "UP"},
+				{Destination: "10.8.0.0/16", Gateway: "10.0.1.1", Interface: "eth1", Protocol: "bird", Metric: 32, Scope: "global", LinkState: "UP"},
+			},
+		},
+		{
+			name:   "nexthop without route",
+			output: "\tnexthop via 10.0.0.1 dev eth0 weight 1",
+			want:   nil,
+		},
+		{
+			name:   "empty",
+			output: "\n  \n",
+			want:   nil,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := ParseIPRoute(tt.output); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("ParseIPRoute() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,210 @@
+package onpremisemodel
+
+import (
+	"net"
+	"net/netip"
+	"strings"
+
+	"github.com/cloud-barista/cm-model/validation"
+)
+
+// Semantic validation rules in addition to the `validate` tags.
+const (
+	RuleCIDR        = "cidr"        // A CIDR block (e.g., 192.168.0.21/24)
+	RuleIP          = "ip"          // An IP address (e.g., 192.168.0.1)
+	RuleMAC         = "mac"         // A MAC address (e.g., 00:1a:2b:3c:4d:5e)
+	RulePorts       = "ports"       // A port expression (e.g., 80,443 or 1024-65535 or *)
+	RuleOneOf       = "oneof"       // One of the allowed keywords
+	RuleConsistency = "consistency" // Cross-field consistency (e.g., a gateway must be reachable from an interface)
+)
+
+// Allowed keywords of FirewallRuleProperty (compared case-insensitively).
+var (
+	firewallProtocols  = []string{"tcp", "udp", "icmp", "icmpv6", "*"}
+	firewallDirections = []string{"inbound", "outbound"}
+	firewallActions    = []string{"allow", "deny"}
+)
+
+// Validate checks the `validate` tags of the whole on-premise infrastructure model
+// as well as the formats and consistency of the network data (see OnpremInfra.ValidateNetwork).
+// It returns validation.Errors listing every failure with its JSON path, or nil.
+func (m OnpremiseInfraModel) Validate() error {
+	errs := validation.Check(m)
+	errs = append(errs, m.OnpremiseInfraModel.checkNetwork("onpremiseInfraModel")...)
+	return errs.Err()
+}
+
+// ValidateNetwork checks the network data of the servers and the network:
+// CIDR blocks, IP and MAC addresses, port expressions, firewall keywords,
+// and whether each gateway is reachable from an interface subnet of the same machine.
+// It returns validation.Errors listing every failure with its JSON path, or nil.
+func (i OnpremInfra) ValidateNetwork() error {
+	return i.checkNetwork("").Err()
+}
+
+func (i OnpremInfra) checkNetwork(path string) validation.Errors {
+	var errs validation.Errors
+
+	// Interface subnets per machine, used to check the reachability of gateways
+	subnets := make(map[string][]netip.Prefix, len(i.Servers))
+
+	serversPath := validation.JoinPath(path, "servers")
+	for si, server := range i.Servers {
+		serverPath := validation.IndexPath(serversPath, si)
+
+		ifacesPath := validation.JoinPath(serverPath, "interfaces")
+		for ii, iface := range server.Interfaces {
+			ifacePath := validation.IndexPath(ifacesPath, ii)
+			if iface.MacAddress != "" {
+				if _, err := net.ParseMAC(iface.MacAddress); err != nil {
+					errs.Add(validation.JoinPath(ifacePath, "macAddress"), RuleMAC, "%q is not a valid MAC address", iface.MacAddress)
+				}
+			}
+			for bi, block := range iface.IPv4CidrBlocks {
+				if prefix, ok := checkPrefix(&errs, validation.IndexPath(validation.JoinPath(ifacePath, "ipv4CidrBlocks"), bi), block, true); ok {
+					subnets[server.MachineId] = append(subnets[server.MachineId], prefix.Masked())
+				}
+			}
+			for bi, block := range iface.IPv6CidrBlocks {
+				if prefix, ok := checkPrefix(&errs, validation.IndexPath(validation.JoinPath(ifacePath, "ipv6CidrBlocks"), bi), block, false); ok {
+					subnets[server.MachineId] = append(subnets[server.MachineId], prefix.Masked())
+				}
+			}
+		}
+
+		routesPath := validation.JoinPath(serverPath, "routingTable")
+		for ri, route := range server.RoutingTable {
+			routePath := validation.IndexPath(routesPath, ri)
+			if route.Destination != "" && route.Destination != "default" {
+				if _, err := netip.ParsePrefix(route.Destination); err != nil {
+					errs.Add(validation.JoinPath(routePath, "destination"), RuleCIDR, "%q is neither a CIDR block nor \"default\"", route.Destination)
+				}
+			}
+			if route.Source != "" {
+				checkAddr(&errs, validation.JoinPath(routePath, "source"), route.Source)
+			}
+			if route.Gateway != "" {
+				if gw, ok := checkAddr(&errs, validation.JoinPath(routePath, "gateway"), route.Gateway); ok && !gw.IsUnspecified() {
+					checkReachable(&errs, validation.JoinPath(routePath, "gateway"), gw, server.MachineId, subnets[server.MachineId])
+				}
+			}
+		}
+
+		rulesPath := validation.JoinPath(serverPath, "firewallTable")
+		for fi, rule := range server.FirewallTable {
+			checkFirewallRule(&errs, validation.IndexPath(rulesPath, fi), rule)
+		}
+	}
+
+	networkPath := validation.JoinPath(path, "network")
+	checkNetworkDetail(&errs, validation.JoinPath(networkPath, "ipv4Networks"), i.Network.IPv4Networks, true, subnets)
+	checkNetworkDetail(&errs, validation.JoinPath(networkPath, "ipv6Networks"), i.Network.IPv6Networks, false, subnets)
+
+	return errs
+}
+
+func checkNetworkDetail(errs *validation.Errors, path string, detail NetworkDetail, isIPv4 bool, subnets map[string][]netip.Prefix) {
+	for ci, block := range detail.CidrBlocks {
+		checkPrefix(errs, validation.IndexPath(validation.JoinPath(path, "cidrBlocks"), ci), block, isIPv4)
+	}
+
+	gatewaysPath := validation.JoinPath(path, "defaultGateways")
+	for gi, gateway := range detail.DefaultGateways {
+		ipPath := validation.JoinPath(validation.IndexPath(gatewaysPath, gi), "ip")
+		gw, ok := checkAddr(errs, ipPath, gateway.IP)
+		if !ok {
+			continue
+		}
+		if gw.Is4() != isIPv4 {
+			errs.Add(ipPath, RuleIP, "%q is not an IPv%s address", gateway.IP, ipVersion(isIPv4))
+			continue
+		}
+		if gateway.MachineId == "" {
+			continue
+		}
+		if _, exists := subnets[gateway.MachineId]; !exists {
+			errs.Add(validation.JoinPath(validation.IndexPath(gatewaysPath, gi), "machineId"), RuleConsistency, "no server with machine ID %q has an interface address", gateway.MachineId)
+			continue
+		}
+		checkReachable(errs, ipPath, gw, gateway.MachineId, subnets[gateway.MachineId])
+	}
+}
+
+func checkFirewallRule(errs *validation.Errors, path string, rule FirewallRuleProperty) {
+	for _, cidr := range []struct{ name, value string }{{"srcCIDR", rule.SrcCIDR}, {"dstCIDR", rule.DstCIDR}} {
+		if cidr.value != "" {
+			if _, err := netip.ParsePrefix(cidr.value); err != nil {
+				errs.Add(validation.JoinPath(path, cidr.name), RuleCIDR, "%q is not a valid CIDR block", cidr.value)
+			}
+		}
+	}
+	for _, ports := range []struct{ name, value string }{{"srcPorts", rule.SrcPorts}, {"dstPorts", rule.DstPorts}} {
+		if _, err := ParsePorts(ports.value); err != nil {
+			errs.Add(validation.JoinPath(path, ports.name), RulePorts, "%v", err)
+		}
+	}
+	checkOneOf(errs, validation.JoinPath(path, "protocol"), rule.Protocol, firewallProtocols)
+	checkOneOf(errs, validation.JoinPath(path, "direction"), rule.Direction, firewallDirections)
+	checkOneOf(errs, validation.JoinPath(path, "action"), rule.Action, firewallActions)
+}
+
+func checkPrefix(errs *validation.Errors, path, block string, isIPv4 bool) (netip.Prefix, bool) {
+	prefix, err := netip.ParsePrefix(block)
+	if err != nil {
+		errs.Add(path, RuleCIDR, "%q is not a valid CIDR block", block)
+		return netip.Prefix{}, false
+	}
+	if prefix.Addr().Is4() != isIPv4 {
+		errs.Add(path, RuleCIDR, "%q is not an IPv%s CIDR block", block, ipVersion(isIPv4))
+		return netip.Prefix{}, false
+	}
+	return prefix, true
+}
+
+func checkAddr(errs *validation.Errors, path, addr string) (netip.Addr, bool) {
+	ip, err := netip.ParseAddr(addr)
+	if err != nil {
+		errs.Add(path, RuleIP, "%q is not a valid IP address", addr)
+		return netip.Addr{}, false
+	}
+	return ip, true
+}
+
+func checkReachable(errs *validation.Errors, path string, gw netip.Addr, machineId string, subnets []netip.Prefix) {
+	// Link-local gateways (e.g., fe80::1) are reachable from any interface
+	if gw.IsLinkLocalUnicast() {
+		return
+	}
+	sameFamily := false
+	for _, subnet := range subnets {
+		if subnet.Addr().Is4() != gw.Is4() {
+			continue
+		}
+		sameFamily = true
+		if subnet.Contains(gw) {
+			return
+		}
+	}
+	if sameFamily {
+		errs.Add(path, RuleConsistency, "gateway %s is outside every interface subnet of machine %q", gw, machineId)
+	}
+}
+
+func checkOneOf(errs *validation.Errors, path, value string, allowed []string) {
+	if value == "" {
+		return
+	}
+	for _, a := range allowed {
+		if strings.EqualFold(value, a) {
+			return
+		}
+	}
+	errs.Add(path, RuleOneOf, "%q is not one of %s", value, strings.Join(allowed, ", "))
+}
+
+func ipVersion(isIPv4 bool) string {
+	if isIPv4 {
+		return "4"
+	}
+	return "6"
+}
@@ -0,0 +1,190 @@
+package onpremisemodel
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+
+	"github.com/cloud-barista/cm-model/validation"
+)
+
+// validInfra is an on-premise infrastructure passing every check: two servers with IPv4 and IPv6 interfaces.
+func validInfra() OnpremInfra {
+	return OnpremInfra{
+		Network: NetworkProperty{
+			IPv4Networks: NetworkDetail{
+				CidrBlocks:      []string{"10.0.0.0/16"},
+				DefaultGateways: []GatewayProperty{{IP: "10.0.1.1", InterfaceName: "eth0", MachineId: "m-1"}},
+			},
+			IPv6Networks: NetworkDetail{
+				CidrBlocks:      []string{"2001:db8::/56"},
+				DefaultGateways: []GatewayProperty{{IP: "fe80::1", InterfaceName: "eth0", MachineId: "m-2"}},
+			},
+		},
+		Servers: []ServerProperty{
+			{
+				Hostname:  "web01",
+				MachineId: "m-1",
+				Interfaces: []NetworkInterfaceProperty{
+					{Name: "lo", IPv4CidrBlocks: []string{"127.0.0.1/8"}, IPv6CidrBlocks: []string{"::1/128"}},
+					{Name: "eth0", MacAddress: "00:1a:2b:3c:4d:5e", IPv4CidrBlocks: []string{"10.0.1.21/24"}},
+				},
+				RoutingTable: []RouteProperty{
+					{Destination: "default", Gateway: "10.0.1.1", Interface: "eth0"},
+					{Destination: "10.0.1.0/24", Interface: "eth0", Source: "10.0.1.21"},
+					{Destination: "0.0.0.0/0", Gateway: "0.0.0.0", Interface: "eth0"},
+				},
+				FirewallTable: []FirewallRuleProperty{
+					{SrcCIDR: "0.0.0.0/0", DstPorts: "22,80-81", Protocol: "TCP", Direction: "inbound", Action: "allow"},
+					{SrcPorts: "*", DstPorts: "*", Protocol: "*", Direction: "Outbound", Action: "ALLOW"},
+				},
+			},
+			{
+				Hostname:   "db01",
+				MachineId:  "m-2",
+				Interfaces: []NetworkInterfaceProperty{{Name: "eth0", IPv4CidrBlocks: []string{"10.0.2.21/24"}, IPv6CidrBlocks: []string{"2001:db8:0:2::21/64"}}},
+				RoutingTable: []RouteProperty{
+					{Destination: "default", Gateway: "fe80::1", Interface: "eth0"}, // Link-local: reachable from any interface
+				},
+			},
+		},
+	}
+}
+
+func TestValidateNetwork(t *testing.T) {
+	tests := []struct {
+		name   string
+		modify func(i *OnpremInfra)
+		want   []validation.FieldError // Paths and rules only
+	}{
+		{
+			name:   "valid",
+			modify: func(i *OnpremInfra) {},
+		},
+		{
+			name: "interface addresses",
+			modify: func(i *OnpremInfra) {
+				eth0 := &i.Servers[0].Interfaces[1]
+				eth0.MacAddress = "00:1a:2b:3c:4d"
+				eth0.IPv4CidrBlocks = []string{"10.0.1.21", "2001:db8::21/64", "10.0.1.300/24"}
+				i.Servers[1].Interfaces[0].IPv6CidrBlocks = []string{"10.0.2.21/24"}
+			},
+			want: []validation.FieldError{
+				{Path: "servers[0].interfaces[1].macAddress", Rule: RuleMAC},
+				{Path: "servers[0].interfaces[1].ipv4CidrBlocks[0]", Rule: RuleCIDR},
+				{Path: "servers[0].interfaces[1].ipv4CidrBlocks[1]", Rule: RuleCIDR},
+				{Path: "servers[0].interfaces[1].ipv4CidrBlocks[2]", Rule: RuleCIDR},
+				// eth0 has no valid IPv4 block left, so 10.0.1.1 is outside the only subnet (127.0.0.0/8 of lo)
+				{Path: "servers[0].routingTable[0].gateway", Rule: RuleConsistency},
+				{Path: "servers[1].interfaces[0].ipv6CidrBlocks[0]", Rule: RuleCIDR},
+				{Path: "network.ipv4Networks.defaultGateways[0].ip", Rule: RuleConsistency},
+			},
+		},
+		{
+			name: "routes",
+			modify: func(i *OnpremInfra) {
+				i.Servers[0].RoutingTable = []RouteProperty{
+					{Destination: "10.0.1.0", Gateway: "10.0.1.1"},
+					{Destination: "default", Gateway: "10.0.9.1"},
+					{Destination: "default", Gateway: "gateway"},
+					{Destination: "default", Gateway: "2001:db8::1"}, // Outside ::1/128 of lo
+					{Source: "10.0.1"},
+				}
+			},
+			want: []validation.FieldError{
+				{Path: "servers[0].routingTable[0].destination", Rule: RuleCIDR},
+				{Path: "servers[0].routingTable[1].gateway", Rule: RuleConsistency},
+				{Path: "servers[0].routingTable[2].gateway", Rule: RuleIP},
+				{Path: "servers[0].routingTable[3].gateway", Rule: RuleConsistency},
+				{Path: "servers[0].routingTable[4].source", Rule: RuleIP},
+			},
+		},
+		{
+			name: "firewall rules",
+			modify: func(i *OnpremInfra) {
+				i.Servers[0].FirewallTable = []FirewallRuleProperty{
+					{SrcCIDR: "0.0.0.0", DstCIDR: "10.0.0.0/33", Protocol: "sctp", Direction: "in", Action: "reject"},
+					{SrcPorts: "443-80", DstPorts: "0", Protocol: "icmpv6", Direction: "INBOUND", Action: "deny"},
+				}
+			},
+			want: []validation.FieldError{
+				{Path: "servers[0].firewallTable[0].srcCIDR", Rule: RuleCIDR},
+				{Path: "servers[0].firewallTable[0].dstCIDR", Rule: RuleCIDR},
+				{Path: "servers[0].firewallTable[0].protocol", Rule: RuleOneOf},
+				{Path: "servers[0].firewallTable[0].direction", Rule: RuleOneOf},
+				{Path: "servers[0].firewallTable[0].action", Rule: RuleOneOf},
+				{Path: "servers[0].firewallTable[1].srcPorts", Rule: RulePorts},
+				{Path: "servers[0].firewallTable[1].dstPorts", Rule: RulePorts},
+			},
+		},
+		{
+			name: "network",
+			modify: func(i *OnpremInfra) {
+				i.Network.IPv4Networks.CidrBlocks = []string{"10.0.0.0/16", "2001:db8::/56", "10.0.0.0"}
+				i.Network.IPv4Networks.DefaultGateways = []GatewayProperty{
+					{IP: "10.0.1.1"}, // Without a machine: not checked for reachability
+					{IP: "2001:db8::1", MachineId: "m-1"},
+					{IP: "10.0.1.1", MachineId: "m-9"},
+					{IP: "10.0.7.1", MachineId: "m-2"},
+					{IP: ""},
+				}
+				i.Network.IPv6Networks.CidrBlocks = []string{"10.0.0.0/16"}
+			},
+			want: []validation.FieldError{
+				{Path: "network.ipv4Networks.cidrBlocks[1]", Rule: RuleCIDR},
+				{Path: "network.ipv4Networks.cidrBlocks[2]", Rule: RuleCIDR},
+				{Path: "network.ipv4Networks.defaultGateways[1].ip", Rule: RuleIP},
+				{Path: "network.ipv4Networks.defaultGateways[2].machineId", Rule: RuleConsistency},
+				{Path: "network.ipv4Networks.defaultGateways[3].ip", Rule: RuleConsistency},
+				{Path: "network.ipv4Networks.defaultGateways[4].ip", Rule: RuleIP},
+				{Path: "network.ipv6Networks.cidrBlocks[0]", Rule: RuleCIDR},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			infra := validInfra()
+			tt.modify(&infra)
+
+			err := infra.ValidateNetwork()
+			var got []validation.FieldError
+			var errs validation.Errors
+			if errors.As(err, &errs) {
+				for _, e := range errs {
+					got = append(got, validation.FieldError{Path: e.Path, Rule: e.Rule})
+				}
+			} else if err != nil {
+				t.Fatalf("ValidateNetwork() = %v, want validation.Errors", err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("ValidateNetwork() = %v\nwant %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestValidate(t *testing.T) {
+	model := OnpremiseInfraModel{OnpremiseInfraModel: validInfra()}
+	model.OnpremiseInfraModel.Servers[1].Interfaces[0].MacAddress = "invalid"
+
+	err := model.Validate()
+	var errs validation.Errors
+	if !errors.As(err, &errs) {
+		t.Fatalf("Validate() = %v, want validation.Errors", err)
+	}
+	paths := map[string]string{}
+	for _, e := range errs {
+		paths[e.Path] = e.Rule
+	}
+	// The `validate` tags and the network checks are reported together, with the paths of the model
+	for path, rule := range map[string]string{
+		"onpremiseInfraModel.servers[0].cpu.cpus":                 validation.RuleRequired,
+		"onpremiseInfraModel.servers[1].os.prettyName":            validation.RuleRequired,
+		"onpremiseInfraModel.servers[1].interfaces[0].macAddress": RuleMAC,
+	} {
+		if paths[path] != rule {
+			t.Errorf("%s: rule %q, want %q", path, paths[path], rule)
+		}
+	}
+}
@@ -0,0 +1,127 @@
+package softwaremodel
+
+import (
+	"fmt"
+
+	cloudmodel "github.com/cloud-barista/cm-model/infra/cloud-model"
+)
+
+// softwareToOSArchitecture maps SoftwareArchitecture to the vocabulary of CB-Tumblebug.
+var softwareToOSArchitecture = map[SoftwareArchitecture]cloudmodel.OSArchitecture{
+	SoftwareArchitectureX8664:   cloudmodel.X86_64,
+	SoftwareArchitectureX86:     cloudmodel.X86_32,
+	SoftwareArchitectureARMv5:   cloudmodel.ARM32,
+	SoftwareArchitectureARMv6:   cloudmodel.ARM32,
+	SoftwareArchitectureARMv7:   cloudmodel.ARM32,
+	SoftwareArchitectureARM64v8: cloudmodel.ARM64,
+}
+
+// osToSoftwareArchitecture maps OSArchitecture to SoftwareArchitecture (ARM32 to the most common ARMv7).
+var osToSoftwareArchitecture = map[cloudmodel.OSArchitecture]SoftwareArchitecture{
+	cloudmodel.X86_64:     SoftwareArchitectureX8664,
+	cloudmodel.X86_64_MAC: SoftwareArchitectureX8664,
+	cloudmodel.X86_32:     SoftwareArchitectureX86,
+	cloudmodel.X86_32_MAC: SoftwareArchitectureX86,
+	cloudmodel.ARM32:      SoftwareArchitectureARMv7,
+	cloudmodel.ARM64:      SoftwareArchitectureARM64v8,
+	cloudmodel.ARM64_MAC:  SoftwareArchitectureARM64v8,
+}
+
+// ToOSArchitecture converts the architecture to cloudmodel.OSArchitecture (e.g., arm64v8 to arm64).
+// SoftwareArchitectureCommon and unknown architectures are cloudmodel.ArchitectureUnknown.
+func (a SoftwareArchitecture) ToOSArchitecture() cloudmodel.OSArchitecture {
+	return softwareToOSArchitecture[a]
+}
+
+// SoftwareArchitectureOf converts cloudmodel.OSArchitecture to SoftwareArchitecture (e.g., arm64 to arm64v8).
+// It returns false for an architecture without a counterpart (e.g., s390x, NA).
+func SoftwareArchitectureOf(arch cloudmodel.OSArchitecture) (SoftwareArchitecture, bool) {
+	a, ok := osToSoftwareArchitecture[arch]
+	return a, ok
+}
+
+// ParseSoftwareArchitecture parses a SoftwareArchitecture, or any architecture name known to
+// cloudmodel.NormalizeArchitecture (e.g., amd64 of a container registry or aarch64 of `lscpu`).
+func ParseSoftwareArchitecture(s string) (SoftwareArchitecture, error) {
+	if CheckArchitecture(s) == nil {
+		return SoftwareArchitecture(s), nil
+	}
+	if a, ok := SoftwareArchitectureOf(cloudmodel.NormalizeArchitecture(s)); ok {
+		return a, nil
+	}
+	return "", fmt.Errorf("unknown architecture %q", s)
+}
+
+// IsArchitectureCompatible returns true if software built for the architecture runs on the target architecture.
+// Common software runs anywhere; other architectures follow cloudmodel.IsCompatibleArchitecture
+// (e.g., 32-bit x86 software runs on x86_64, and arm64v8 software runs on arm64_mac).
+func IsArchitectureCompatible(arch SoftwareArchitecture, target cloudmodel.OSArchitecture) bool {
+	if arch == SoftwareArchitectureCommon {
+		return true
+	}
+	return cloudmodel.IsCompatibleArchitecture(arch.ToOSArchitecture(), target)
+}
+
+// ArchitectureIssue is an item of a MigrationList that cannot (or may not) run on the target architecture.
+type ArchitectureIssue struct {
+	Kind         SoftwareType              `json:"kind"`
+	Name         string                    `json:"name"`
+	Architecture SoftwareArchitecture      `json:"architecture"`
+	Target       cloudmodel.OSArchitecture `json:"target"`
+	Reason       string                    `json:"reason"`
+}
+
+func (i ArchitectureIssue) String() string {
+	return fmt.Sprintf("%s %s (%s) on %s: %s", i.Kind, i.Name, i.Architecture, i.Target, i.Reason)
+}
+
+// CheckArchitectureCompatibility flags the items of the list that do not run on the architecture of the target spec.
+// The architecture of binaries is the one of the source server (e.g., CpuProperty.Architecture), and containers have
+// the architecture of their image. Packages are installed for the target from the OS repositories, so only the packages
+// from a custom repository (RepoURL) are flagged when the architectures differ, as the repository may not provide them.
+// Wine binaries are flagged on non-x86 targets.
+// It returns an error if the architecture of the spec or of the source is unknown.
+func CheckArchitectureCompatibility(list MigrationList, sourceArchitecture string, spec cloudmodel.SpecInfo) ([]ArchitectureIssue, error) {
+	target := cloudmodel.NormalizeArchitecture(spec.Architecture)
+	if _, ok := SoftwareArchitectureOf(target); !ok {
+		return nil, fmt.Errorf("unsupported architecture %q of spec %s", spec.Architecture, spec.Id)
+	}
+	source, err := ParseSoftwareArchitecture(sourceArchitecture)
+	if err != nil {
+		return nil, fmt.Errorf("invalid source architecture: %w", err)
+	}
+
+	issues := []ArchitectureIssue{}
+	flag := func(kind SoftwareType, name string, arch SoftwareArchitecture, reason string) {
+		issues = append(issues, ArchitectureIssue{Kind: kind, Name: name, Architecture: arch, Target: target, Reason: reason})
+	}
+
+	for _, p := range list.Packages {
+		if p.RepoURL != "" && !IsArchitectureCompatible(source, target) {
+			flag(SoftwareTypePackage, p.Name, source, "the custom repository "+p.RepoURL+" may not provide the package for the target architecture")
+		}
+	}
+	for _, b := range list.Binaries {
+		switch {
+		case !IsArchitectureCompatible(source, target):
+			flag(SoftwareTypeBinary, b.Name, source, "the binary is built for the source architecture")
+		case b.IsWine && !cloudmodel.IsCompatibleArchitecture(cloudmodel.X86_32, target): // Any x86 target
+			flag(SoftwareTypeBinary, b.Name, source, "Wine requires an x86 target")
+		}
+	}
+	for _, c := range list.Containers {
+		arch := c.ContainerImage.ImageArchitecture
+		if arch == "" {
+			continue // Unknown
+		}
+		parsed, err := ParseSoftwareArchitecture(string(arch))
+		if err != nil {
+			flag(SoftwareTypeContainer, c.Name, arch, "unknown image architecture")
+			continue
+		}
+		if !IsArchitectureCompatible(parsed, target) {
+			flag(SoftwareTypeContainer, c.Name, parsed, "the image "+c.ContainerImage.ImageName+" is built for another architecture")
+		}
+	}
+	return issues, nil
+}
@@ -0,0 +1,99 @@
+package softwaremodel
+
+import (
+	"testing"
+
+	cloudmodel "github.com/cloud-barista/cm-model/infra/cloud-model"
+)
+
+func TestIsArchitectureCompatible(t *testing.T) {
+	tests := []struct {
+		arch   SoftwareArchitecture
+		target cloudmodel.OSArchitecture
+		want   bool
+	}{
+		{SoftwareArchitectureCommon, cloudmodel.S390X, true},
+		{SoftwareArchitectureX8664, cloudmodel.X86_64, true},
+		{SoftwareArchitectureX8664, cloudmodel.X86_64_MAC, true},
+		{SoftwareArchitectureX8664, cloudmodel.X86_32, false},
+		{SoftwareArchitectureX86, cloudmodel.X86_64, true},
+		{SoftwareArchitectureX86, cloudmodel.X86_64_MAC, true},
+		{SoftwareArchitectureARM64v8, cloudmodel.ARM64_MAC, true},
+		{SoftwareArchitectureARM64v8, cloudmodel.X86_64, false},
+		{SoftwareArchitectureARMv7, cloudmodel.ARM64, false},
+		{SoftwareArchitectureARMv6, cloudmodel.ARM32, true},
+		{SoftwareArchitectureX8664, cloudmodel.ArchitectureUnknown, true},
+		{SoftwareArchitectureX8664, cloudmodel.ArchitectureNA, true},
+	}
+
+	for _, tt := range tests {
+		got := IsArchitectureCompatible(tt.arch, tt.target)
+		if got != tt.want {
+			t.Errorf("IsArchitectureCompatible(%q, %q) = %v, want %v", tt.arch, tt.target, got, tt.want)
+		}
+		// The same rule as the spec and image matching of cloudmodel
+		if tt.arch != SoftwareArchitectureCommon {
+			if cm := cloudmodel.IsCompatibleArchitecture(tt.arch.ToOSArchitecture(), tt.target); cm != got {
+				t.Errorf("cloudmodel.IsCompatibleArchitecture(%q, %q) = %v, differs from %v", tt.arch.ToOSArchitecture(), tt.target, cm, got)
+			}
+		}
+	}
+}
+
+func TestCheckArchitectureCompatibility(t *testing.T) {
+	list := MigrationList{
+		Packages: []PackageMigrationInfo{
+			{Name: "nginx"},
+			{Name: "docker-ce", RepoURL: "https://download.docker.com/linux/ubuntu"},
+		},
+		Binaries: []BinaryMigrationInfo{{Name: "app"}, {Name: "setup.exe", IsWine: true}},
+		Containers: []ContainerMigrationInfo{
+			{Name: "db", ContainerImage: ContainerImage{ImageName: "mysql", ImageArchitecture: SoftwareArchitectureX8664}},
+			{Name: "cache", ContainerImage: ContainerImage{ImageName: "redis", ImageArchitecture: "aarch64"}},
+			{Name: "web", ContainerImage: ContainerImage{ImageName: "nginx"}},
+		},
+	}
+
+	tests := []struct {
+		name   string
+		source string
+		spec   string
+		want   []string
+	}{
+		{"same architecture", "x86_64", "x86_64", []string{"container cache (arm64v8) on x86_64: the image redis is built for another architecture"}},
+		{"mac variant", "amd64", "x86_64_mac", []string{"container cache (arm64v8) on x86_64_mac: the image redis is built for another architecture"}},
+		{"32-bit source", "i686", "x86_64", []string{"container cache (arm64v8) on x86_64: the image redis is built for another architecture"}},
+		{
+			name:   "arm target",
+			source: "x86_64",
+			spec:   "arm64",
+			want: []string{
+				"package docker-ce (x86_64) on arm64: the custom repository https://download.docker.com/linux/ubuntu may not provide the package for the target architecture",
+				"binary app (x86_64) on arm64: the binary is built for the source architecture",
+				"binary setup.exe (x86_64) on arm64: the binary is built for the source architecture",
+				"container db (x86_64) on arm64: the image mysql is built for another architecture",
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			issues, err := CheckArchitectureCompatibility(list, tt.source, cloudmodel.SpecInfo{Id: "spec", Architecture: tt.spec})
+			if err != nil {
+				t.Fatal(err)
+			}
+			var got []string
+			for _, issue := range issues {
+				got = append(got, issue.String())
+			}
+			if len(got) != len(tt.want) {
+				t.Fatalf("issues = %q, want %q", got, tt.want)
+			}
+			for i := range got {
+				if got[i] != tt.want[i] {
+					t.Errorf("issue %d = %q, want %q", i, got[i], tt.want[i])
+				}
+			}
+		})
+	}
+}
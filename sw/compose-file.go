@@ -0,0 +1,719 @@
+package softwaremodel
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// ParseComposeFile parses a compose file (file format v2 or v3, or the Compose Specification).
+func ParseComposeFile(path string) (ComposeProject, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return ComposeProject{}, err
+	}
+	return ParseCompose(data, path)
+}
+
+// ParseCompose parses the content of the compose file at path (on the source).
+// Short and long syntaxes of environment, ports, volumes, networks and depends_on are normalized;
+// the keys not modeled (e.g., build, command, healthcheck, or tmpfs.size of a volume) are kept in Extra as they are.
+// Variables such as ${TAG} are not interpolated.
+func ParseCompose(data []byte, path string) (ComposeProject, error) {
+	var doc map[string]any
+	if err := yaml.Unmarshal(data, &doc); err != nil {
+		return ComposeProject{}, fmt.Errorf("invalid compose file %s: %w", path, err)
+	}
+
+	p := ComposeProject{
+		Path:     path,
+		Services: []ComposeService{},
+		Networks: []ComposeNetwork{},
+		Volumes:  []ComposeVolume{},
+	}
+	for key, value := range doc {
+		var err error
+		switch key {
+		case "version":
+			p.Version = scalarString(value)
+		case "name":
+			p.Name = scalarString(value)
+		case "services":
+			err = forEachEntry(value, func(name string, v any) error {
+				s, err := parseComposeService(name, v)
+				p.Services = append(p.Services, s)
+				return err
+			})
+		case "networks":
+			err = forEachEntry(value, func(name string, v any) error {
+				n := ComposeNetwork{Name: name}
+				n.Driver, n.External, n.Extra = parseComposeResource(v)
+				p.Networks = append(p.Networks, n)
+				return nil
+			})
+		case "volumes":
+			err = forEachEntry(value, func(name string, v any) error {
+				vol := ComposeVolume{Name: name}
+				vol.Driver, vol.External, vol.Extra = parseComposeResource(v)
+				p.Volumes = append(p.Volumes, vol)
+				return nil
+			})
+		default:
+			p.Extra = setExtra(p.Extra, key, value)
+		}
+		if err != nil {
+			return ComposeProject{}, fmt.Errorf("invalid compose file %s: %s: %w", path, key, err)
+		}
+	}
+	if p.Name == "" {
+		p.Name = composeProjectName(filepath.Base(filepath.Dir(path)))
+	}
+	if _, ok := doc["services"]; !ok {
+		return ComposeProject{}, fmt.Errorf("invalid compose file %s: no services (the file format v1 is not supported)", path)
+	}
+
+	sort.Slice(p.Services, func(i, j int) bool { return p.Services[i].Name < p.Services[j].Name })
+	sort.Slice(p.Networks, func(i, j int) bool { return p.Networks[i].Name < p.Networks[j].Name })
+	sort.Slice(p.Volumes, func(i, j int) bool { return p.Volumes[i].Name < p.Volumes[j].Name })
+	return p, nil
+}
+
+func parseComposeService(name string, value any) (ComposeService, error) {
+	s := ComposeService{
+		Name:        name,
+		Environment: []Env{},
+		Ports:       []ComposePort{},
+		Volumes:     []ComposeMount{},
+		Networks:    map[string]ComposeServiceNetwork{},
+		DependsOn:   []ComposeDependency{},
+	}
+	m, ok := value.(map[string]any)
+	if !ok && value != nil {
+		return s, fmt.Errorf("service %s is not a mapping", name)
+	}
+
+	for key, v := range m {
+		var err error
+		switch key {
+		case "container_name":
+			s.ContainerName = scalarString(v)
+		case "image":
+			s.Image = scalarString(v)
+		case "platform":
+			s.Platform = scalarString(v)
+		case "restart":
+			s.Restart = scalarString(v)
+		case "network_mode":
+			s.NetworkMode = scalarString(v)
+		case "environment":
+			s.Environment, err = parseKeyValues(v)
+			sort.Slice(s.Environment, func(i, j int) bool { return s.Environment[i].Name < s.Environment[j].Name })
+		case "labels":
+			var labels []Env
+			labels, err = parseKeyValues(v)
+			for _, l := range labels {
+				if s.Labels == nil {
+					s.Labels = make(map[string]string)
+				}
+				s.Labels[l.Name] = l.Value
+			}
+		case "ports":
+			err = forEachItem(v, func(item any) error {
+				ports, err := parseComposePort(item)
+				s.Ports = append(s.Ports, ports...)
+				return err
+			})
+		case "volumes":
+			err = forEachItem(v, func(item any) error {
+				mount, err := parseComposeMount(item)
+				s.Volumes = append(s.Volumes, mount)
+				return err
+			})
+		case "networks":
+			err = forEachEntry(v, func(name string, settings any) error {
+				network, err := parseComposeServiceNetwork(settings)
+				s.Networks[name] = network
+				return err
+			})
+		case "depends_on":
+			err = forEachEntry(v, func(service string, opts any) error {
+				dep := ComposeDependency{Service: service}
+				if o, ok := opts.(map[string]any); ok {
+					dep.Condition = scalarString(o["condition"])
+					dep.Restart, _ = o["restart"].(bool)
+					if required, ok := o["required"].(bool); ok {
+						dep.Required = &required
+					}
+				}
+				s.DependsOn = append(s.DependsOn, dep)
+				return nil
+			})
+			sort.Slice(s.DependsOn, func(i, j int) bool { return s.DependsOn[i].Service < s.DependsOn[j].Service })
+		default:
+			s.Extra = setExtra(s.Extra, key, v)
+		}
+		if err != nil {
+			return s, fmt.Errorf("service %s: %s: %w", name, key, err)
+		}
+	}
+	return s, nil
+}
+
+// parseComposeResource parses a top-level network or volume (null or a mapping).
+func parseComposeResource(value any) (driver string, external bool, extra map[string]any) {
+	m, _ := value.(map[string]any)
+	for key, v := range m {
+		switch key {
+		case "driver":
+			driver = scalarString(v)
+		case "external":
+			switch ext := v.(type) {
+			case bool:
+				external = ext
+			case map[string]any: // external: {name: ...} of the file format v3
+				external = true
+				if name, ok := ext["name"]; ok {
+					extra = setExtra(extra, "name", name)
+				}
+			}
+		default:
+			extra = setExtra(extra, key, v)
+		}
+	}
+	return driver, external, extra
+}
+
+// parseComposeServiceNetwork parses the settings of a service on a network (null or a mapping).
+func parseComposeServiceNetwork(value any) (ComposeServiceNetwork, error) {
+	var network ComposeServiceNetwork
+	m, ok := value.(map[string]any)
+	if !ok && value != nil {
+		return network, fmt.Errorf("settings are not a mapping")
+	}
+	for key, v := range m {
+		switch key {
+		case "aliases":
+			err := forEachItem(v, func(item any) error {
+				network.Aliases = append(network.Aliases, scalarString(item))
+				return nil
+			})
+			if err != nil {
+				return network, fmt.Errorf("aliases: %w", err)
+			}
+		case "ipv4_address":
+			network.IPv4Address = scalarString(v)
+		case "ipv6_address":
+			network.IPv6Address = scalarString(v)
+		default:
+			network.Extra = setExtra(network.Extra, key, v)
+		}
+	}
+	return network, nil
+}
+
+// parseComposePort parses a port in the short syntax ([host_ip:][host_port:]container_port[/protocol],
+// with port ranges) or in the long syntax (target, published, protocol, host_ip, mode, name, app_protocol).
+func parseComposePort(value any) ([]ComposePort, error) {
+	if m, ok := value.(map[string]any); ok {
+		target, err := strconv.Atoi(scalarString(m["target"]))
+		if err != nil {
+			return nil, fmt.Errorf("invalid target port %v", m["target"])
+		}
+		port := ComposePort{
+			ContainerPort: ContainerPort{ContainerPort: target, Protocol: "tcp", HostIP: scalarString(m["host_ip"])},
+			Mode:          scalarString(m["mode"]),
+			Name:          scalarString(m["name"]),
+			AppProtocol:   scalarString(m["app_protocol"]),
+		}
+		if protocol := scalarString(m["protocol"]); protocol != "" {
+			port.Protocol = protocol
+		}
+		if published := scalarString(m["published"]); published != "" {
+			if port.HostPort, err = strconv.Atoi(published); err != nil {
+				return nil, fmt.Errorf("invalid published port %q", published)
+			}
+		}
+		return []ComposePort{port}, nil
+	}
+
+	spec := scalarString(value)
+	protocol := "tcp"
+	if i := strings.LastIndex(spec, "/"); i >= 0 {
+		spec, protocol = spec[:i], spec[i+1:]
+	}
+	var hostIP, hostPorts, containerPorts string
+	if strings.HasPrefix(spec, "[") { // [IPv6]:host:container
+		end := strings.Index(spec, "]")
+		if end < 0 {
+			return nil, fmt.Errorf("invalid port %q", scalarString(value))
+		}
+		hostIP, spec = spec[1:end], strings.TrimPrefix(spec[end+1:], ":")
+	}
+	parts := strings.Split(spec, ":")
+	switch {
+	case len(parts) == 1:
+		containerPorts = parts[0]
+	case len(parts) == 2:
+		hostPorts, containerPorts = parts[0], parts[1]
+	case len(parts) == 3 && hostIP == "":
+		hostIP, hostPorts, containerPorts = parts[0], parts[1], parts[2]
+	default:
+		return nil, fmt.Errorf("invalid port %q", scalarString(value))
+	}
+
+	cFrom, cTo, err := parsePortRange(containerPorts)
+	if err != nil {
+		return nil, err
+	}
+	hFrom, hTo := 0, 0
+	if hostPorts != "" {
+		if hFrom, hTo, err = parsePortRange(hostPorts); err != nil {
+			return nil, err
+		}
+		if hTo-hFrom != cTo-cFrom && hFrom != hTo {
+			return nil, fmt.Errorf("invalid port %q: ranges of different sizes", scalarString(value))
+		}
+	}
+	var ports []ComposePort
+	for i := 0; i <= cTo-cFrom; i++ {
+		port := ComposePort{ContainerPort: ContainerPort{ContainerPort: cFrom + i, Protocol: protocol, HostIP: hostIP}}
+		if hFrom != 0 {
+			port.HostPort = hFrom
+			if hFrom != hTo {
+				port.HostPort += i
+			}
+		}
+		ports = append(ports, port)
+	}
+	return ports, nil
+}
+
+func parsePortRange(s string) (int, int, error) {
+	from, to, isRange := strings.Cut(s, "-")
+	f, err := strconv.Atoi(from)
+	if err != nil {
+		return 0, 0, fmt.Errorf("invalid port %q", s)
+	}
+	if !isRange {
+		return f, f, nil
+	}
+	t, err := strconv.Atoi(to)
+	if err != nil || t < f {
+		return 0, 0, fmt.Errorf("invalid port range %q", s)
+	}
+	return f, t, nil
+}
+
+// parseComposeMount parses a volume in the short syntax ([source:]target[:mode]) or in the long syntax,
+// whose options other than read_only (e.g., tmpfs.size, bind.propagation) are kept in Extra.
+func parseComposeMount(value any) (ComposeMount, error) {
+	if m, ok := value.(map[string]any); ok {
+		var mount ComposeMount
+		for key, v := range m {
+			switch key {
+			case "type":
+				mount.Type = scalarString(v)
+			case "source":
+				mount.Source = scalarString(v)
+			case "target":
+				mount.Target = scalarString(v)
+			case "read_only":
+				if readOnly, _ := v.(bool); readOnly {
+					mount.Mode = "ro"
+				}
+			default:
+				mount.Extra = setExtra(mount.Extra, key, v)
+			}
+		}
+		if mount.Target == "" {
+			return mount, fmt.Errorf("volume without target")
+		}
+		if mount.Type == "" {
+			mount.Type = mountType(mount.Source)
+		}
+		return mount, nil
+	}
+
+	parts := strings.Split(scalarString(value), ":")
+	var mount ComposeMount
+	switch len(parts) {
+	case 1:
+		mount.Target = parts[0]
+	case 2:
+		mount.Source, mount.Target = parts[0], parts[1]
+	case 3:
+		mount.Source, mount.Target, mount.Mode = parts[0], parts[1], parts[2]
+	default:
+		return mount, fmt.Errorf("invalid volume %q", scalarString(value))
+	}
+	if mount.Target == "" {
+		return mount, fmt.Errorf("invalid volume %q", scalarString(value))
+	}
+	mount.Type = mountType(mount.Source)
+	return mount, nil
+}
+
+// mountType returns bind for a host path and volume for a named (or anonymous) volume.
+func mountType(source string) string {
+	if strings.HasPrefix(source, "/") || strings.HasPrefix(source, ".") || strings.HasPrefix(source, "~") {
+		return "bind"
+	}
+	return "volume"
+}
+
+// parseKeyValues parses a mapping or a list of KEY=VALUE (environment, labels).
+// Entries without value (KEY of a list, or KEY: null of a mapping) are marked with NoValue.
+func parseKeyValues(value any) ([]Env, error) {
+	entries := []Env{}
+	switch v := value.(type) {
+	case nil:
+	case map[string]any:
+		for key, val := range v {
+			entries = append(entries, Env{Name: key, Value: scalarString(val), NoValue: val == nil})
+		}
+	case []any:
+		for _, item := range v {
+			key, val, hasValue := strings.Cut(scalarString(item), "=")
+			entries = append(entries, Env{Name: key, Value: val, NoValue: !hasValue})
+		}
+	default:
+		return nil, fmt.Errorf("neither a mapping nor a list")
+	}
+	return entries, nil
+}
+
+// forEachEntry calls f for each entry of a mapping, or for each name of a list (with a nil value).
+func forEachEntry(value any, f func(name string, v any) error) error {
+	switch v := value.(type) {
+	case nil:
+	case map[string]any:
+		keys := make([]string, 0, len(v))
+		for key := range v {
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+		for _, key := range keys {
+			if err := f(key, v[key]); err != nil {
+				return err
+			}
+		}
+	case []any:
+		for _, item := range v {
+			if err := f(scalarString(item), nil); err != nil {
+				return err
+			}
+		}
+	default:
+		return fmt.Errorf("neither a mapping nor a list")
+	}
+	return nil
+}
+
+// forEachItem calls f for each item of a list.
+func forEachItem(value any, f func(item any) error) error {
+	switch v := value.(type) {
+	case nil:
+	case []any:
+		for _, item := range v {
+			if err := f(item); err != nil {
+				return err
+			}
+		}
+	default:
+		return fmt.Errorf("not a list")
+	}
+	return nil
+}
+
+func scalarString(v any) string {
+	switch v := v.(type) {
+	case nil:
+		return ""
+	case string:
+		return v
+	default:
+		return fmt.Sprint(v)
+	}
+}
+
+func setExtra(extra map[string]any, key string, value any) map[string]any {
+	if extra == nil {
+		extra = make(map[string]any)
+	}
+	extra[key] = value
+	return extra
+}
+
+// ComposeTargetOptions are the adjustments of a compose file for the target (see RenderCompose).
+type ComposeTargetOptions struct {
+	Images       map[string]string `json:"images,omitempty"`        // Images by service (e.g., mirrored to a registry of the target)
+	HostPorts    map[int]int       `json:"host_ports,omitempty"`    // Published ports of the source to the ones of the target
+	PathMappings map[string]string `json:"path_mappings,omitempty"` // Prefixes of bind mount sources to the ones on the target
+	Platform     string            `json:"platform,omitempty"`      // Platform of all services (e.g., linux/arm64 for an ARM target)
+	DropVersion  bool              `json:"drop_version,omitempty"`  // Omits the obsolete top-level version
+}
+
+type composeFileYAML struct {
+	Version  string                        `yaml:"version,omitempty"`
+	Name     string                        `yaml:"name,omitempty"`
+	Services map[string]composeServiceYAML `yaml:"services"`
+	Networks map[string]composeObjectYAML  `yaml:"networks,omitempty"`
+	Volumes  map[string]composeObjectYAML  `yaml:"volumes,omitempty"`
+	Extra    map[string]any                `yaml:",inline"`
+}
+
+type composeServiceYAML struct {
+	ContainerName string            `yaml:"container_name,omitempty"`
+	Image         string            `yaml:"image,omitempty"`
+	Platform      string            `yaml:"platform,omitempty"`
+	Restart       string            `yaml:"restart,omitempty"`
+	NetworkMode   string            `yaml:"network_mode,omitempty"`
+	Environment   []string          `yaml:"environment,omitempty"`
+	Ports         []any             `yaml:"ports,omitempty"`   // Short syntax (string) or long syntax (composePortYAML)
+	Volumes       []any             `yaml:"volumes,omitempty"` // Short syntax (string) or long syntax (composeMountYAML)
+	Networks      any               `yaml:"networks,omitempty"`
+	DependsOn     any               `yaml:"depends_on,omitempty"`
+	Labels        map[string]string `yaml:"labels,omitempty"`
+	Extra         map[string]any    `yaml:",inline"`
+}
+
+type composePortYAML struct {
+	Target      int    `yaml:"target"`
+	Published   int    `yaml:"published,omitempty"`
+	HostIP      string `yaml:"host_ip,omitempty"`
+	Protocol    string `yaml:"protocol,omitempty"`
+	Mode        string `yaml:"mode,omitempty"`
+	Name        string `yaml:"name,omitempty"`
+	AppProtocol string `yaml:"app_protocol,omitempty"`
+}
+
+type composeMountYAML struct {
+	Type     string         `yaml:"type"`
+	Source   string         `yaml:"source,omitempty"`
+	Target   string         `yaml:"target"`
+	ReadOnly bool           `yaml:"read_only,omitempty"`
+	Extra    map[string]any `yaml:",inline"`
+}
+
+type composeServiceNetworkYAML struct {
+	Aliases     []string       `yaml:"aliases,omitempty"`
+	IPv4Address string         `yaml:"ipv4_address,omitempty"`
+	IPv6Address string         `yaml:"ipv6_address,omitempty"`
+	Extra       map[string]any `yaml:",inline"`
+}
+
+type composeDependencyYAML struct {
+	Condition string `yaml:"condition"`
+	Restart   bool   `yaml:"restart,omitempty"`
+	Required  *bool  `yaml:"required,omitempty"`
+}
+
+type composeObjectYAML struct {
+	Driver   string         `yaml:"driver,omitempty"`
+	External bool           `yaml:"external,omitempty"`
+	Extra    map[string]any `yaml:",inline"`
+}
+
+// RenderCompose regenerates the compose file of the project for the target with the adjustments of opts.
+// Ports, volumes, networks and depends_on are written in the short syntax, or in the long syntax when the short
+// syntax cannot express them (e.g., a tmpfs mount, the mode of a port, the aliases of a network or a required: false
+// dependency). The Compose project name is written only if the file format is the Compose Specification (no version).
+func RenderCompose(p ComposeProject, opts ComposeTargetOptions) ([]byte, error) {
+	file := composeFileYAML{Services: make(map[string]composeServiceYAML), Extra: p.Extra}
+	if !opts.DropVersion {
+		file.Version = p.Version
+	}
+	if file.Version == "" {
+		file.Name = p.Name
+	}
+
+	for _, s := range p.Services {
+		out := composeServiceYAML{
+			ContainerName: s.ContainerName,
+			Image:         s.Image,
+			Platform:      s.Platform,
+			Restart:       s.Restart,
+			NetworkMode:   s.NetworkMode,
+			Networks:      renderServiceNetworks(s.Networks),
+			Labels:        s.Labels,
+			Extra:         s.Extra,
+		}
+		if image, ok := opts.Images[s.Name]; ok {
+			out.Image = image
+		}
+		if opts.Platform != "" {
+			out.Platform = opts.Platform
+		}
+		for _, env := range s.Environment {
+			if env.NoValue {
+				out.Environment = append(out.Environment, env.Name)
+			} else {
+				out.Environment = append(out.Environment, env.Name+"="+env.Value)
+			}
+		}
+		for _, port := range s.Ports {
+			out.Ports = append(out.Ports, renderComposePort(port, opts.HostPorts))
+		}
+		for _, mount := range s.Volumes {
+			out.Volumes = append(out.Volumes, renderComposeMount(mount, opts.PathMappings))
+		}
+		out.DependsOn = renderDependsOn(s.DependsOn)
+		file.Services[s.Name] = out
+	}
+	for _, n := range p.Networks {
+		if file.Networks == nil {
+			file.Networks = make(map[string]composeObjectYAML)
+		}
+		file.Networks[n.Name] = composeObjectYAML{Driver: n.Driver, External: n.External, Extra: n.Extra}
+	}
+	for _, v := range p.Volumes {
+		if file.Volumes == nil {
+			file.Volumes = make(map[string]composeObjectYAML)
+		}
+		file.Volumes[v.Name] = composeObjectYAML{Driver: v.Driver, External: v.External, Extra: v.Extra}
+	}
+
+	var b strings.Builder
+	enc := yaml.NewEncoder(&b)
+	enc.SetIndent(2)
+	if err := enc.Encode(file); err != nil {
+		return nil, fmt.Errorf("failed to render the compose file of %s: %w", p.Name, err)
+	}
+	if err := enc.Close(); err != nil {
+		return nil, err
+	}
+	return []byte(b.String()), nil
+}
+
+// WriteComposeFile writes the compose file of the project for the target (see RenderCompose).
+func WriteComposeFile(path string, p ComposeProject, opts ComposeTargetOptions) error {
+	data, err := RenderCompose(p, opts)
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(path, data, 0o644)
+}
+
+// renderComposePort renders a port in the short syntax (quoted), or in the long syntax if it has options.
+func renderComposePort(port ComposePort, hostPorts map[int]int) any {
+	hostPort := port.HostPort
+	if mapped, ok := hostPorts[hostPort]; ok && hostPort != 0 {
+		hostPort = mapped
+	}
+	if port.Mode != "" || port.Name != "" || port.AppProtocol != "" {
+		return composePortYAML{
+			Target:      port.ContainerPort.ContainerPort,
+			Published:   hostPort,
+			HostIP:      port.HostIP,
+			Protocol:    port.Protocol,
+			Mode:        port.Mode,
+			Name:        port.Name,
+			AppProtocol: port.AppProtocol,
+		}
+	}
+
+	s := strconv.Itoa(port.ContainerPort.ContainerPort)
+	if hostPort != 0 {
+		s = strconv.Itoa(hostPort) + ":" + s
+		if port.HostIP != "" {
+			hostIP := port.HostIP
+			if strings.Contains(hostIP, ":") {
+				hostIP = "[" + hostIP + "]"
+			}
+			s = hostIP + ":" + s
+		}
+	}
+	if port.Protocol != "" && port.Protocol != "tcp" {
+		s += "/" + port.Protocol
+	}
+	// Quoted, as YAML 1.1 parsers read HOST:CONTAINER with numbers below 60 (e.g., 22:22) as a base-60 number
+	return &yaml.Node{Kind: yaml.ScalarNode, Style: yaml.DoubleQuotedStyle, Value: s}
+}
+
+// renderComposeMount renders a mount in the short syntax, or in the long syntax if the short syntax cannot express it
+// (a type other than bind and volume, options of the long syntax, or a source from which the short syntax infers another type).
+func renderComposeMount(mount ComposeMount, pathMappings map[string]string) any {
+	source := mount.Source
+	if mount.Type == "bind" {
+		// The longest matching prefix wins.
+		best := ""
+		for from := range pathMappings {
+			if (source == from || strings.HasPrefix(source, strings.TrimSuffix(from, "/")+"/")) && len(from) > len(best) {
+				best = from
+			}
+		}
+		if best != "" {
+			source = pathMappings[best] + strings.TrimPrefix(source, best)
+		}
+	}
+
+	if len(mount.Extra) > 0 || mount.Type != mountType(source) {
+		return composeMountYAML{
+			Type:     mount.Type,
+			Source:   source,
+			Target:   mount.Target,
+			ReadOnly: mount.Mode == "ro",
+			Extra:    mount.Extra,
+		}
+	}
+	s := mount.Target
+	if source != "" {
+		s = source + ":" + s
+	}
+	if mount.Mode != "" {
+		s += ":" + mount.Mode
+	}
+	return s
+}
+
+// renderServiceNetworks renders the networks of a service as a list, or as a mapping if a network has settings.
+func renderServiceNetworks(networks map[string]ComposeServiceNetwork) any {
+	if len(networks) == 0 {
+		return nil
+	}
+	names := make([]string, 0, len(networks))
+	withSettings := false
+	for name, n := range networks {
+		names = append(names, name)
+		withSettings = withSettings || len(n.Aliases) > 0 || n.IPv4Address != "" || n.IPv6Address != "" || len(n.Extra) > 0
+	}
+	sort.Strings(names)
+	if !withSettings {
+		return names
+	}
+	m := make(map[string]composeServiceNetworkYAML, len(networks))
+	for name, n := range networks {
+		m[name] = composeServiceNetworkYAML{Aliases: n.Aliases, IPv4Address: n.IPv4Address, IPv6Address: n.IPv6Address, Extra: n.Extra}
+	}
+	return m
+}
+
+// renderDependsOn renders depends_on as a list, or as a mapping if a dependency has a condition or options.
+func renderDependsOn(deps []ComposeDependency) any {
+	if len(deps) == 0 {
+		return nil
+	}
+	withOptions := false
+	names := make([]string, 0, len(deps))
+	for _, d := range deps {
+		names = append(names, d.Service)
+		withOptions = withOptions || (d.Condition != "" && d.Condition != "service_started") || d.Restart || d.Required != nil
+	}
+	if !withOptions {
+		return names
+	}
+	m := make(map[string]composeDependencyYAML, len(deps))
+	for _, d := range deps {
+		condition := d.Condition
+		if condition == "" {
+			condition = "service_started"
+		}
+		m[d.Service] = composeDependencyYAML{Condition: condition, Restart: d.Restart, Required: d.Required}
+	}
+	return m
+}
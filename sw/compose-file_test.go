@@ -0,0 +1,177 @@
+package softwaremodel
+
+import (
+	"bytes"
+	"flag"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+var update = flag.Bool("update", false, "update the golden files of testdata")
+
+// TestComposeRoundTrip parses the compose files of testdata/compose/<format>/, renders them without adjustment and
+// checks that the rendered file (testdata/compose/<format>/rendered.golden.yaml) parses into the same project.
+func TestComposeRoundTrip(t *testing.T) {
+	inputs, err := filepath.Glob(filepath.Join("testdata", "compose", "*", "*compose.y*ml"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(inputs) == 0 {
+		t.Fatal("no compose file in testdata")
+	}
+	for _, input := range inputs {
+		t.Run(filepath.Base(filepath.Dir(input)), func(t *testing.T) {
+			project, err := ParseComposeFile(input)
+			if err != nil {
+				t.Fatal(err)
+			}
+			rendered, err := RenderCompose(project, ComposeTargetOptions{})
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			golden := filepath.Join(filepath.Dir(input), "rendered.golden.yaml")
+			if *update {
+				if err := os.WriteFile(golden, rendered, 0644); err != nil {
+					t.Fatal(err)
+				}
+			} else {
+				want, err := os.ReadFile(golden)
+				if err != nil {
+					t.Fatal(err)
+				}
+				if !bytes.Equal(rendered, want) {
+					t.Errorf("rendered file differs from %s (run with -update to regenerate):\n%s", golden, rendered)
+				}
+			}
+
+			reparsed, err := ParseCompose(rendered, input)
+			if err != nil {
+				t.Fatalf("rendered file: %v", err)
+			}
+			if !reflect.DeepEqual(reparsed, project) {
+				t.Errorf("round trip changed the project:\n got %+v\nwant %+v", reparsed, project)
+			}
+		})
+	}
+}
+
+func TestParseComposeEntries(t *testing.T) {
+	parse := func(path string) ComposeProject {
+		t.Helper()
+		project, err := ParseComposeFile(filepath.Join("testdata", "compose", path))
+		if err != nil {
+			t.Fatal(err)
+		}
+		return project
+	}
+	service := func(p ComposeProject, name string) ComposeService {
+		t.Helper()
+		s, ok := p.Service(name)
+		if !ok {
+			t.Fatalf("no service %s", name)
+		}
+		return s
+	}
+	f := false
+
+	v2 := parse(filepath.Join("v2", "docker-compose.yml"))
+	if got, want := service(v2, "web").Environment, []Env{
+		{Name: "APP_ENV", Value: "production"},
+		{Name: "EMPTY"},
+		{Name: "PASSTHRU", NoValue: true},
+	}; !reflect.DeepEqual(got, want) {
+		t.Errorf("web environment = %+v, want %+v", got, want)
+	}
+	if got, want := service(v2, "db").Environment, []Env{
+		{Name: "MYSQL_PASSWORD", NoValue: true},
+		{Name: "MYSQL_PORT", Value: "3306"},
+		{Name: "MYSQL_ROOT_PASSWORD", Value: "secret"},
+	}; !reflect.DeepEqual(got, want) {
+		t.Errorf("db environment = %+v, want %+v", got, want)
+	}
+	if got, want := service(v2, "web").Networks, map[string]ComposeServiceNetwork{
+		"front": {Aliases: []string{"www", "shop"}},
+		"back":  {IPv4Address: "172.28.0.10"},
+	}; !reflect.DeepEqual(got, want) {
+		t.Errorf("web networks = %+v, want %+v", got, want)
+	}
+	if got, want := service(v2, "db").Volumes[0], (ComposeMount{
+		Type:   "tmpfs",
+		Target: "/tmp",
+		Extra:  map[string]any{"tmpfs": map[string]any{"size": 104857600}},
+	}); !reflect.DeepEqual(got, want) {
+		t.Errorf("db tmpfs = %+v, want %+v", got, want)
+	}
+	if got := len(service(v2, "web").Ports); got != 4 {
+		t.Errorf("web has %d ports, want 4 (with the expanded range)", got)
+	}
+
+	v3 := parse(filepath.Join("v3", "docker-compose.yml"))
+	if got, want := service(v3, "api").Ports[0], (ComposePort{
+		ContainerPort: ContainerPort{ContainerPort: 8080, Protocol: "tcp", HostPort: 80},
+		Mode:          "host",
+	}); !reflect.DeepEqual(got, want) {
+		t.Errorf("api port = %+v, want %+v", got, want)
+	}
+
+	spec := parse(filepath.Join("spec", "compose.yaml"))
+	if got, want := service(spec, "worker").DependsOn, []ComposeDependency{
+		{Service: "migrate", Condition: "service_completed_successfully", Restart: true},
+		{Service: "queue", Condition: "service_started", Required: &f},
+	}; !reflect.DeepEqual(got, want) {
+		t.Errorf("worker depends_on = %+v, want %+v", got, want)
+	}
+	if got, want := service(spec, "worker").Networks["backend"], (ComposeServiceNetwork{
+		Aliases: []string{"jobs"},
+		Extra:   map[string]any{"priority": 100},
+	}); !reflect.DeepEqual(got, want) {
+		t.Errorf("worker backend network = %+v, want %+v", got, want)
+	}
+	if got, want := service(spec, "queue").Labels, map[string]string{"com.example.team": "platform", "com.example.flag": ""}; !reflect.DeepEqual(got, want) {
+		t.Errorf("queue labels = %v, want %v", got, want)
+	}
+}
+
+func TestRenderComposeAdjustments(t *testing.T) {
+	project, err := ParseComposeFile(filepath.Join("testdata", "compose", "v3", "docker-compose.yml"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	rendered, err := RenderCompose(project, ComposeTargetOptions{
+		Images:       map[string]string{"api": "registry.target.example.com/shop/api:2.3.1"},
+		HostPorts:    map[int]int{80: 8080},
+		PathMappings: map[string]string{"/srv/api": "/data/api"},
+		Platform:     "linux/arm64",
+		DropVersion:  true,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	adjusted, err := ParseCompose(rendered, project.Path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if adjusted.Version != "" {
+		t.Errorf("version = %q, want it dropped", adjusted.Version)
+	}
+	api, _ := adjusted.Service("api")
+	if api.Image != "registry.target.example.com/shop/api:2.3.1" || api.Platform != "linux/arm64" {
+		t.Errorf("api image and platform = %s, %s", api.Image, api.Platform)
+	}
+	if port := api.Ports[0]; port.HostPort != 8080 || port.Mode != "host" {
+		t.Errorf("api port = %+v, want 8080 in the host mode", port)
+	}
+	for _, source := range []string{api.Volumes[0].Source, api.Volumes[2].Source} {
+		if !strings.HasPrefix(source, "/data/api/") {
+			t.Errorf("bind mount source %s is not mapped", source)
+		}
+	}
+	if api.Volumes[0].Mode != "ro" || !reflect.DeepEqual(api.Volumes[0].Extra, map[string]any{"bind": map[string]any{"propagation": "rslave"}}) {
+		t.Errorf("api config mount = %+v, want read-only with the bind propagation", api.Volumes[0])
+	}
+}
@@ -0,0 +1,180 @@
+package softwaremodel
+
+import (
+	"regexp"
+	"strings"
+)
+
+// ComposeProject is a Docker Compose project parsed from a compose file (see ParseComposeFile).
+// Containers belong to the project with the same DockerComposePath and to the service named by ComposeService.
+type ComposeProject struct {
+	Name     string           `json:"name" validate:"required"`
+	Path     string           `json:"path" validate:"required"` // Path of the compose file on the source (Container.DockerComposePath)
+	Version  string           `json:"version,omitempty"`        // Version of the file format (e.g., 2.4, 3.8); empty for the Compose Specification
+	Services []ComposeService `json:"services" validate:"required"`
+	Networks []ComposeNetwork `json:"networks"`
+	Volumes  []ComposeVolume  `json:"volumes"`
+	Extra    map[string]any   `json:"extra,omitempty"` // Other top-level keys (e.g., x-* extensions, secrets, configs)
+}
+
+// ComposeService is a service of a compose project.
+type ComposeService struct {
+	Name          string                           `json:"name" validate:"required"`
+	ContainerName string                           `json:"container_name,omitempty"`
+	Image         string                           `json:"image,omitempty"`
+	Platform      string                           `json:"platform,omitempty"` // e.g., linux/amd64
+	Restart       string                           `json:"restart,omitempty"`
+	NetworkMode   string                           `json:"network_mode,omitempty"`
+	Environment   []Env                            `json:"environment"`
+	Ports         []ComposePort                    `json:"ports"`
+	Volumes       []ComposeMount                   `json:"volumes"`
+	Networks      map[string]ComposeServiceNetwork `json:"networks"` // Settings by network name
+	DependsOn     []ComposeDependency              `json:"depends_on"`
+	Labels        map[string]string                `json:"labels,omitempty"`
+	Extra         map[string]any                   `json:"extra,omitempty"` // Other keys (e.g., build, command, healthcheck)
+}
+
+// ComposePort is a port of a service.
+type ComposePort struct {
+	ContainerPort        // HostPort is 0 for unpublished ports
+	Mode          string `json:"mode,omitempty" enums:"host,ingress"` // Options of the long syntax
+	Name          string `json:"name,omitempty"`
+	AppProtocol   string `json:"app_protocol,omitempty"`
+}
+
+// ComposeMount is a volume or bind mount of a service.
+type ComposeMount struct {
+	Type   string         `json:"type" enums:"bind,volume,tmpfs,npipe,cluster"`
+	Source string         `json:"source,omitempty"` // Host path of a bind mount or name of a volume; empty for an anonymous volume or tmpfs
+	Target string         `json:"target" validate:"required"`
+	Mode   string         `json:"mode,omitempty"`  // e.g., ro, rw, z
+	Extra  map[string]any `json:"extra,omitempty"` // Other keys of the long syntax (e.g., bind, volume, tmpfs, consistency)
+}
+
+// ComposeServiceNetwork is the settings of a service on a network.
+type ComposeServiceNetwork struct {
+	Aliases     []string       `json:"aliases,omitempty"`
+	IPv4Address string         `json:"ipv4_address,omitempty"`
+	IPv6Address string         `json:"ipv6_address,omitempty"`
+	Extra       map[string]any `json:"extra,omitempty"` // Other keys (e.g., priority, link_local_ips, mac_address)
+}
+
+// ComposeDependency is an entry of depends_on.
+type ComposeDependency struct {
+	Service   string `json:"service" validate:"required"`
+	Condition string `json:"condition,omitempty" enums:"service_started,service_healthy,service_completed_successfully"`
+	Restart   bool   `json:"restart,omitempty"`  // The service is restarted after the dependency is updated
+	Required  *bool  `json:"required,omitempty"` // false if the service starts without the dependency; true if nil
+}
+
+// ComposeNetwork is a top-level network of a compose project.
+type ComposeNetwork struct {
+	Name     string         `json:"name" validate:"required"` // Key in the compose file
+	Driver   string         `json:"driver,omitempty"`
+	External bool           `json:"external,omitempty"`
+	Extra    map[string]any `json:"extra,omitempty"` // Other keys (e.g., name, ipam, driver_opts)
+}
+
+// ComposeVolume is a top-level named volume of a compose project.
+type ComposeVolume struct {
+	Name     string         `json:"name" validate:"required"` // Key in the compose file
+	Driver   string         `json:"driver,omitempty"`
+	External bool           `json:"external,omitempty"`
+	Extra    map[string]any `json:"extra,omitempty"` // Other keys (e.g., name, driver_opts)
+}
+
+// Service returns the service with the name.
+func (p ComposeProject) Service(name string) (ComposeService, bool) {
+	for _, s := range p.Services {
+		if s.Name == name {
+			return s, true
+		}
+	}
+	return ComposeService{}, false
+}
+
+// ServiceOfContainer returns the service a container belongs to, by its container_name or
+// by the name Docker Compose gives to the containers of a service ({project}-{service}-{n} or {project}_{service}_{n}).
+func (p ComposeProject) ServiceOfContainer(containerName string) (ComposeService, bool) {
+	containerName = strings.TrimPrefix(containerName, "/")
+	for _, s := range p.Services {
+		if s.ContainerName != "" && s.ContainerName == containerName {
+			return s, true
+		}
+	}
+	for _, s := range p.Services {
+		if s.ContainerName != "" {
+			continue
+		}
+		for _, sep := range []string{"-", "_"} {
+			prefix := p.Name + sep + s.Name + sep
+			if strings.HasPrefix(containerName, prefix) && isDigits(strings.TrimPrefix(containerName, prefix)) {
+				return s, true
+			}
+		}
+	}
+	return ComposeService{}, false
+}
+
+// LinkComposeServices sets the ComposeService of the containers of the list from their compose projects.
+// It returns the names of the containers with a DockerComposePath whose project or service is not found.
+func LinkComposeServices(list *SoftwareList) []string {
+	unlinked := []string{}
+	for i := range list.Containers {
+		c := &list.Containers[i]
+		if c.DockerComposePath == "" || c.ComposeService != "" {
+			continue
+		}
+		project, ok := list.ComposeProject(c.DockerComposePath)
+		if !ok {
+			unlinked = append(unlinked, c.Name)
+			continue
+		}
+		service, ok := project.ServiceOfContainer(c.Name)
+		if !ok {
+			unlinked = append(unlinked, c.Name)
+			continue
+		}
+		c.ComposeService = service.Name
+	}
+	return unlinked
+}
+
+// ComposeProject returns the compose project of the file at the path.
+func (l SoftwareList) ComposeProject(path string) (ComposeProject, bool) {
+	return findComposeProject(l.ComposeProjects, path)
+}
+
+// ComposeProject returns the compose project of the file at the path.
+func (l MigrationList) ComposeProject(path string) (ComposeProject, bool) {
+	return findComposeProject(l.ComposeProjects, path)
+}
+
+func findComposeProject(projects []ComposeProject, path string) (ComposeProject, bool) {
+	for _, p := range projects {
+		if p.Path == path {
+			return p, true
+		}
+	}
+	return ComposeProject{}, false
+}
+
+var invalidProjectNameChars = regexp.MustCompile(`[^a-z0-9_-]+`)
+
+// composeProjectName returns the default project name of a compose file (the name of its directory),
+// normalized as Docker Compose does.
+func composeProjectName(dir string) string {
+	return strings.TrimLeft(invalidProjectNameChars.ReplaceAllString(strings.ToLower(dir), ""), "_-")
+}
+
+func isDigits(s string) bool {
+	if s == "" {
+		return false
+	}
+	for _, r := range s {
+		if r < '0' || r > '9' {
+			return false
+		}
+	}
+	return true
+}
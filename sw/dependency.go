@@ -0,0 +1,399 @@
+package softwaremodel
+
+import (
+	"path"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Reasons of the dependency edges.
+const (
+	DependencyNeededPackage = "needed-package" // The package is in NeededPackages
+	DependencyLibrary       = "library"        // The package or binary provides a library in NeededLibraries
+	DependencyDataPath      = "data-path"      // The software shares a data path (MountPaths, CustomDataPaths)
+	DependencyPort          = "port"           // The Envs reference a host port of the container
+)
+
+// DependencyNode is an item of a MigrationList.
+type DependencyNode struct {
+	Id    string       `json:"id"` // {kind}:{name}, or {kind}:{index} if the name is empty or duplicated
+	Kind  SoftwareType `json:"kind"`
+	Index int          `json:"index"`           // Index in the list of the kind
+	Group string       `json:"group,omitempty"` // Docker Compose file of a container; the members of a group share an order
+}
+
+// DependencyEdge means that From must be migrated before To.
+type DependencyEdge struct {
+	From   string `json:"from"`
+	To     string `json:"to"`
+	Reason string `json:"reason"`
+	Detail string `json:"detail,omitempty"` // e.g., the package, library, path or port
+}
+
+// DependencyGraph is the dependency graph of the items of a MigrationList.
+type DependencyGraph struct {
+	Nodes []DependencyNode `json:"nodes"`
+	Edges []DependencyEdge `json:"edges"`
+}
+
+// CycleError is returned when the dependencies of a MigrationList form a cycle.
+type CycleError struct {
+	Cycle []string // IDs of the nodes in the cycle; the first one is repeated at the end
+}
+
+func (e *CycleError) Error() string {
+	return "dependency cycle: " + strings.Join(e.Cycle, " -> ")
+}
+
+// kindRank is the default order of the kinds; it also decides which side owns a shared data path.
+var kindRank = map[SoftwareType]int{
+	SoftwareTypePackage:    0,
+	SoftwareTypeBinary:     1,
+	SoftwareTypeContainer:  2,
+	SoftwareTypeKubernetes: 3,
+}
+
+// BuildDependencyGraph infers the dependencies between the items of a migration list:
+//   - a package is needed by the packages listing it in NeededPackages
+//   - a package or binary is needed by the binaries listing its library in NeededLibraries
+//     (e.g., libssl.so.3 is provided by a package or binary named libssl or libssl3, see providesLibrary)
+//   - software with a CustomDataPaths is needed by the software of a later kind (package, binary, container)
+//     with the same or a nested path in CustomDataPaths or MountPaths
+//   - a container is needed by the software whose Envs reference one of its host ports
+//     (e.g., DB_PORT=5432 or DB_URL=postgres://db:5432/app)
+//
+// Containers with the same DockerComposePath form a group; dependencies within a group are left to Docker Compose.
+func BuildDependencyGraph(list MigrationList) DependencyGraph {
+	g := DependencyGraph{Nodes: []DependencyNode{}, Edges: []DependencyEdge{}}
+	nodes := make(map[SoftwareType][]DependencyNode)
+	used := make(map[string]bool)
+	add := func(kind SoftwareType, index int, name, group string) {
+		id := string(kind) + ":" + name
+		if name == "" || used[id] {
+			id = string(kind) + ":" + strconv.Itoa(index)
+		}
+		used[id] = true
+		node := DependencyNode{Id: id, Kind: kind, Index: index, Group: group}
+		g.Nodes = append(g.Nodes, node)
+		nodes[kind] = append(nodes[kind], node)
+	}
+	for i, p := range list.Packages {
+		add(SoftwareTypePackage, i, p.Name, "")
+	}
+	for i, b := range list.Binaries {
+		add(SoftwareTypeBinary, i, b.Name, "")
+	}
+	for i, c := range list.Containers {
+		add(SoftwareTypeContainer, i, c.Name, c.DockerComposePath)
+	}
+	for i := range list.Kubernetes {
+		add(SoftwareTypeKubernetes, i, "", "")
+	}
+
+	nodeOf := func(kind SoftwareType, index int) DependencyNode {
+		return nodes[kind][index]
+	}
+	seen := make(map[[2]string]bool)
+	addEdge := func(from, to DependencyNode, reason, detail string) {
+		if from.Id == to.Id || (from.Group != "" && from.Group == to.Group) || seen[[2]string{from.Id, to.Id}] {
+			return
+		}
+		seen[[2]string{from.Id, to.Id}] = true
+		g.Edges = append(g.Edges, DependencyEdge{From: from.Id, To: to.Id, Reason: reason, Detail: detail})
+	}
+
+	// Needed packages
+	for i, p := range list.Packages {
+		for _, needed := range p.NeededPackages {
+			for j, q := range list.Packages {
+				if q.Name == needed {
+					addEdge(nodeOf(SoftwareTypePackage, j), nodeOf(SoftwareTypePackage, i), DependencyNeededPackage, needed)
+				}
+			}
+		}
+	}
+
+	// Needed libraries
+	for i, b := range list.Binaries {
+		for _, lib := range b.NeededLibraries {
+			for j, p := range list.Packages {
+				if providesLibrary(p.Name, lib) {
+					addEdge(nodeOf(SoftwareTypePackage, j), nodeOf(SoftwareTypeBinary, i), DependencyLibrary, lib)
+				}
+			}
+			for j, other := range list.Binaries {
+				if providesLibrary(other.Name, lib) {
+					addEdge(nodeOf(SoftwareTypeBinary, j), nodeOf(SoftwareTypeBinary, i), DependencyLibrary, lib)
+				}
+			}
+		}
+	}
+
+	// Shared data paths
+	type pathUse struct {
+		node  DependencyNode
+		paths []string
+		owner bool // CustomDataPaths (owned) rather than MountPaths (used)
+	}
+	var uses []pathUse
+	for i, p := range list.Packages {
+		uses = append(uses, pathUse{nodeOf(SoftwareTypePackage, i), p.CustomDataPaths, true})
+	}
+	for i, b := range list.Binaries {
+		uses = append(uses, pathUse{nodeOf(SoftwareTypeBinary, i), b.CustomDataPaths, true})
+	}
+	for i, c := range list.Containers {
+		var hostPaths []string
+		for _, m := range c.MountPaths {
+			hostPaths = append(hostPaths, hostPath(m))
+		}
+		uses = append(uses, pathUse{nodeOf(SoftwareTypeContainer, i), hostPaths, false})
+	}
+	for _, owner := range uses {
+		if !owner.owner {
+			continue
+		}
+		for _, user := range uses {
+			if kindRank[user.node.Kind] <= kindRank[owner.node.Kind] {
+				continue
+			}
+			if p, ok := sharedPath(owner.paths, user.paths); ok {
+				addEdge(owner.node, user.node, DependencyDataPath, p)
+			}
+		}
+	}
+
+	// Ports referenced by Envs
+	envsOf := func(node DependencyNode) []string {
+		switch node.Kind {
+		case SoftwareTypeBinary:
+			return list.Binaries[node.Index].Envs
+		case SoftwareTypeContainer:
+			var envs []string
+			for _, env := range list.Containers[node.Index].Envs {
+				envs = append(envs, env.Name+"="+env.Value)
+			}
+			return envs
+		}
+		return nil
+	}
+	for i, c := range list.Containers {
+		provider := nodeOf(SoftwareTypeContainer, i)
+		for _, port := range c.ContainerPorts {
+			if port.HostPort == 0 {
+				continue
+			}
+			for _, consumer := range g.Nodes {
+				for _, env := range envsOf(consumer) {
+					if referencesPort(env, port.HostPort) {
+						addEdge(provider, consumer, DependencyPort, strconv.Itoa(port.HostPort))
+					}
+				}
+			}
+		}
+	}
+
+	return g
+}
+
+// providesLibrary reports whether software named name provides the library: the name is the base name of the library,
+// optionally followed by a version (e.g., libssl and libssl3 provide libssl.so.3, libc6 provides libc.so.6,
+// but libcap2 does not provide libc.so.6).
+func providesLibrary(name, lib string) bool {
+	if name == "" || lib == "" {
+		return false
+	}
+	if name == lib {
+		return true
+	}
+	base := path.Base(lib)
+	if i := strings.Index(base, ".so"); i > 0 {
+		base = base[:i]
+	}
+	if base == lib || !strings.HasPrefix(name, base) {
+		return false
+	}
+	version := strings.TrimPrefix(strings.TrimPrefix(name, base), "-")
+	if version == "" {
+		return true
+	}
+	if version[0] < '0' || version[0] > '9' {
+		return false
+	}
+	return strings.Trim(version, "0123456789.-") == ""
+}
+
+// hostPath returns the host side of a mount path (e.g., /data of /data:/var/lib/mysql:rw).
+func hostPath(mount string) string {
+	if i := strings.Index(mount, ":"); i >= 0 {
+		return mount[:i]
+	}
+	return mount
+}
+
+// sharedPath returns a path of owned that is equal to or contains a path of used.
+func sharedPath(owned, used []string) (string, bool) {
+	for _, o := range owned {
+		o = path.Clean(o)
+		if o == "." || o == "/" {
+			continue
+		}
+		for _, u := range used {
+			u = path.Clean(u)
+			if u == o || strings.HasPrefix(u, o+"/") || strings.HasPrefix(o, u+"/") {
+				return o, true
+			}
+		}
+	}
+	return "", false
+}
+
+// referencesPort reports whether an environment variable (NAME=VALUE) references the port,
+// as a *PORT* variable equal to the port or as a :port in the value.
+func referencesPort(env string, port int) bool {
+	name, value, _ := strings.Cut(env, "=")
+	p := strconv.Itoa(port)
+	if strings.Contains(strings.ToUpper(name), "PORT") && strings.TrimSpace(value) == p {
+		return true
+	}
+	for rest := value; ; {
+		i := strings.Index(rest, ":"+p)
+		if i < 0 {
+			return false
+		}
+		rest = rest[i+1+len(p):]
+		if rest == "" || rest[0] < '0' || rest[0] > '9' {
+			return true
+		}
+	}
+}
+
+// TopologicalOrder returns the order of each node (starting from 1) such that every node comes after its
+// dependencies. Nodes without dependencies between them keep the order of the kinds (packages, binaries, containers,
+// Kubernetes) and of the list, and the members of a group share an order.
+// It returns a *CycleError if the dependencies form a cycle.
+func (g DependencyGraph) TopologicalOrder() (map[string]int, error) {
+	// Contract the groups into single units.
+	unitOf := make(map[string]string)
+	rank := make(map[string][2]int)
+	var units []string
+	for _, n := range g.Nodes {
+		unit := n.Id
+		if n.Group != "" {
+			unit = "group:" + n.Group
+		}
+		unitOf[n.Id] = unit
+		if _, ok := rank[unit]; !ok {
+			rank[unit] = [2]int{kindRank[n.Kind], n.Index}
+			units = append(units, unit)
+		}
+	}
+	sort.SliceStable(units, func(i, j int) bool {
+		a, b := rank[units[i]], rank[units[j]]
+		return a[0] < b[0] || (a[0] == b[0] && a[1] < b[1])
+	})
+
+	next := make(map[string][]string)
+	indegree := make(map[string]int)
+	for _, e := range g.Edges {
+		from, to := unitOf[e.From], unitOf[e.To]
+		if from == to {
+			continue
+		}
+		next[from] = append(next[from], to)
+		indegree[to]++
+	}
+
+	unitOrder := make(map[string]int)
+	for len(unitOrder) < len(units) {
+		progressed := false
+		for _, u := range units {
+			if _, done := unitOrder[u]; done || indegree[u] > 0 {
+				continue
+			}
+			unitOrder[u] = len(unitOrder) + 1
+			for _, v := range next[u] {
+				indegree[v]--
+			}
+			progressed = true
+			break // Restart to keep the earliest ready unit first
+		}
+		if !progressed {
+			return nil, &CycleError{Cycle: findCycle(units, next, unitOrder)}
+		}
+	}
+
+	order := make(map[string]int, len(g.Nodes))
+	for _, n := range g.Nodes {
+		order[n.Id] = unitOrder[unitOf[n.Id]]
+	}
+	return order, nil
+}
+
+// findCycle returns a cycle among the units not ordered yet.
+func findCycle(units []string, next map[string][]string, ordered map[string]int) []string {
+	const (
+		unvisited = iota
+		visiting
+		visited
+	)
+	state := make(map[string]int)
+	var stack []string
+	var cycle []string
+	var visit func(u string) bool
+	visit = func(u string) bool {
+		state[u] = visiting
+		stack = append(stack, u)
+		for _, v := range next[u] {
+			if _, done := ordered[v]; done {
+				continue
+			}
+			switch state[v] {
+			case visiting:
+				for i, s := range stack {
+					if s == v {
+						cycle = append(append([]string{}, stack[i:]...), v)
+						return true
+					}
+				}
+			case unvisited:
+				if visit(v) {
+					return true
+				}
+			}
+		}
+		stack = stack[:len(stack)-1]
+		state[u] = visited
+		return false
+	}
+	for _, u := range units {
+		if _, done := ordered[u]; !done && state[u] == unvisited && visit(u) {
+			return cycle
+		}
+	}
+	return nil
+}
+
+// AssignMigrationOrder sets the Order of every item of the list from its dependency graph (see BuildDependencyGraph
+// and TopologicalOrder). If the dependencies form a cycle, it returns a *CycleError and leaves the list unchanged.
+func AssignMigrationOrder(list *MigrationList) (DependencyGraph, error) {
+	g := BuildDependencyGraph(*list)
+	order, err := g.TopologicalOrder()
+	if err != nil {
+		return g, err
+	}
+	for _, n := range g.Nodes {
+		switch n.Kind {
+		case SoftwareTypePackage:
+			list.Packages[n.Index].Order = order[n.Id]
+		case SoftwareTypeBinary:
+			list.Binaries[n.Index].Order = order[n.Id]
+		case SoftwareTypeContainer:
+			list.Containers[n.Index].Order = order[n.Id]
+		case SoftwareTypeKubernetes:
+			list.Kubernetes[n.Index].Order = order[n.Id]
+		}
+	}
+	return g, nil
+}
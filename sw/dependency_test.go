@@ -0,0 +1,160 @@
+package softwaremodel
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+)
+
+func TestProvidesLibrary(t *testing.T) {
+	tests := []struct {
+		name string
+		lib  string
+		want bool
+	}{
+		{"libssl", "libssl.so.3", true},
+		{"libssl3", "libssl.so.3", true},
+		{"libssl3", "/usr/lib/x86_64-linux-gnu/libssl.so.3", true},
+		{"libc6", "libc.so.6", true},
+		{"libpcre2-8-0", "libpcre2-8.so.0", true},
+		{"libcap2", "libc.so.6", false},
+		{"libcurl4", "libc.so.6", false},
+		{"libcrypt1", "libc.so.6", false},
+		{"libssl-dev", "libssl.so.3", false},
+		{"openssl", "libssl.so.3", false},
+		{"libfoo", "libfoo", true},
+		{"libfoo2", "libfoo", false},
+		{"", "libc.so.6", false},
+	}
+
+	for _, tt := range tests {
+		if got := providesLibrary(tt.name, tt.lib); got != tt.want {
+			t.Errorf("providesLibrary(%q, %q) = %v, want %v", tt.name, tt.lib, got, tt.want)
+		}
+	}
+}
+
+// shopList is a migration list with a dependency of every kind and a Docker Compose group (db and web).
+func shopList() MigrationList {
+	const compose = "/srv/shop/compose.yaml"
+	return MigrationList{
+		Packages: []PackageMigrationInfo{
+			{Name: "nginx", NeededPackages: []string{"openssl"}},
+			{Name: "openssl"},
+			{Name: "libc6"},
+			{Name: "libcap2"},
+			{Name: "libssl3"},
+			{Name: "mysql-server", CustomDataPaths: []string{"/var/lib/mysql"}},
+		},
+		Binaries: []BinaryMigrationInfo{
+			{
+				Name:            "app",
+				NeededLibraries: []string{"/lib/x86_64-linux-gnu/libc.so.6", "libssl.so.3"},
+				Envs:            []string{"DB_URL=mysql://127.0.0.1:3306/app"},
+			},
+		},
+		Containers: []ContainerMigrationInfo{
+			{
+				Name:              "db",
+				MountPaths:        []string{"/var/lib/mysql:/var/lib/mysql"},
+				ContainerPorts:    []ContainerPort{{ContainerPort: 3306, Protocol: "tcp", HostPort: 3306}},
+				DockerComposePath: compose,
+			},
+			{
+				Name:              "web",
+				Envs:              []Env{{Name: "DB_PORT", Value: "3306"}},
+				ContainerPorts:    []ContainerPort{{ContainerPort: 80, Protocol: "tcp", HostPort: 8080}},
+				DockerComposePath: compose,
+			},
+		},
+	}
+}
+
+func TestBuildDependencyGraph(t *testing.T) {
+	g := BuildDependencyGraph(shopList())
+
+	want := []DependencyEdge{
+		{From: "package:openssl", To: "package:nginx", Reason: DependencyNeededPackage, Detail: "openssl"},
+		{From: "package:libc6", To: "binary:app", Reason: DependencyLibrary, Detail: "/lib/x86_64-linux-gnu/libc.so.6"},
+		{From: "package:libssl3", To: "binary:app", Reason: DependencyLibrary, Detail: "libssl.so.3"},
+		{From: "package:mysql-server", To: "container:db", Reason: DependencyDataPath, Detail: "/var/lib/mysql"},
+		{From: "container:db", To: "binary:app", Reason: DependencyPort, Detail: "3306"},
+	}
+	if !reflect.DeepEqual(g.Edges, want) {
+		t.Errorf("edges = %+v, want %+v", g.Edges, want)
+	}
+	if len(g.Nodes) != 9 {
+		t.Errorf("%d nodes, want 9", len(g.Nodes))
+	}
+	for _, n := range g.Nodes {
+		if n.Kind == SoftwareTypeContainer && n.Group != "/srv/shop/compose.yaml" {
+			t.Errorf("node %s: group %q, want the compose file", n.Id, n.Group)
+		}
+	}
+}
+
+func TestTopologicalOrder(t *testing.T) {
+	order, err := BuildDependencyGraph(shopList()).TopologicalOrder()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// The compose group (db and web) shares an order, after mysql-server and before app which uses the port of db
+	want := map[string]int{
+		"package:openssl":      1,
+		"package:nginx":        2,
+		"package:libc6":        3,
+		"package:libcap2":      4,
+		"package:libssl3":      5,
+		"package:mysql-server": 6,
+		"container:db":         7,
+		"container:web":        7,
+		"binary:app":           8,
+	}
+	if !reflect.DeepEqual(order, want) {
+		t.Errorf("order = %v, want %v", order, want)
+	}
+}
+
+func TestAssignMigrationOrderCycle(t *testing.T) {
+	list := MigrationList{
+		Packages: []PackageMigrationInfo{
+			{Order: 1, Name: "a", NeededPackages: []string{"b"}},
+			{Order: 2, Name: "b", NeededPackages: []string{"a"}},
+			{Order: 3, Name: "c"},
+		},
+	}
+
+	_, err := AssignMigrationOrder(&list)
+	var cycleErr *CycleError
+	if !errors.As(err, &cycleErr) {
+		t.Fatalf("error = %v, want a *CycleError", err)
+	}
+	if want := []string{"package:a", "package:b", "package:a"}; !reflect.DeepEqual(cycleErr.Cycle, want) {
+		t.Errorf("cycle = %v, want %v", cycleErr.Cycle, want)
+	}
+	if want := "dependency cycle: package:a -> package:b -> package:a"; err.Error() != want {
+		t.Errorf("error = %q, want %q", err, want)
+	}
+	for i, p := range list.Packages {
+		if p.Order != i+1 {
+			t.Errorf("package %s: order %d changed on a cycle", p.Name, p.Order)
+		}
+	}
+}
+
+func TestAssignMigrationOrderGroupCycle(t *testing.T) {
+	// app needs the port of db, and db mounts the data path of app: the cycle goes through the compose group
+	list := shopList()
+	list.Binaries[0].CustomDataPaths = []string{"/srv/app"}
+	list.Containers[0].MountPaths = append(list.Containers[0].MountPaths, "/srv/app/uploads:/uploads")
+
+	_, err := AssignMigrationOrder(&list)
+	var cycleErr *CycleError
+	if !errors.As(err, &cycleErr) {
+		t.Fatalf("error = %v, want a *CycleError", err)
+	}
+	if want := []string{"binary:app", "group:/srv/shop/compose.yaml", "binary:app"}; !reflect.DeepEqual(cycleErr.Cycle, want) {
+		t.Errorf("cycle = %v, want %v", cycleErr.Cycle, want)
+	}
+}
@@ -0,0 +1,230 @@
+package softwaremodel
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// PlanOptions are the options of PlanSoftwareMigration.
+type PlanOptions struct {
+	// Velero is the Velero configuration of the Kubernetes migrations, which the source model does not have.
+	// Kubernetes clusters are not migrated without Provider and Bucket.
+	Velero KubernetesVelero `json:"velero"`
+}
+
+// PlanSoftwareMigration plans the migration of the software of a source group.
+// Each source connection becomes a MigrationServer with its software converted to migration infos:
+// names, versions and runtimes are trimmed (and runtimes lowercased), image architectures are normalized
+// (e.g., amd64 to x86_64, see ParseSoftwareArchitecture), and NeededPackages and NeedToDeletePackages
+// are split into lists of package names without duplicates (see SplitPackageList).
+// The items are ordered by their dependencies (see AssignMigrationOrder); if the dependencies of a server form a cycle,
+// the cycle is reported in its Errors and the items are ordered packages first, then binaries, containers and
+// Kubernetes clusters, in the order of the source.
+// Items that cannot be migrated (e.g., an unknown package type or an invalid image architecture) are left out
+// and reported in the Errors of their server.
+func PlanSoftwareMigration(source SourceGroupSoftwareProperty, opts PlanOptions) TargetGroupSoftwareProperty {
+	target := TargetGroupSoftwareProperty{Servers: []MigrationServer{}}
+	for i, conn := range source.ConnectionInfoList {
+		target.Servers = append(target.Servers, planServer(i, conn, opts))
+	}
+	return target
+}
+
+// planServer converts the software of a source connection into a MigrationServer.
+func planServer(index int, conn SourceConnectionInfoSoftwareProperty, opts PlanOptions) MigrationServer {
+	server := MigrationServer{
+		SourceConnectionInfoID: strings.TrimSpace(conn.ConnectionId),
+		MigrationList: MigrationList{
+			Binaries:   []BinaryMigrationInfo{},
+			Packages:   []PackageMigrationInfo{},
+			Containers: []ContainerMigrationInfo{},
+			Kubernetes: []KubernetesMigrationInfo{},
+		},
+		Errors: []string{},
+	}
+	if server.SourceConnectionInfoID == "" {
+		server.Errors = append(server.Errors, fmt.Sprintf("connection_info_list[%d]: empty connection ID", index))
+	}
+	addError := func(format string, args ...any) {
+		server.Errors = append(server.Errors, fmt.Sprintf(format, args...))
+	}
+	order := 0
+	next := func() int {
+		order++
+		return order
+	}
+	list := &server.MigrationList
+	softwares := conn.Softwares
+
+	packages := make(map[string]bool)
+	for i, p := range softwares.Packages {
+		name := strings.TrimSpace(p.Name)
+		switch {
+		case name == "":
+			addError("packages[%d]: empty name", i)
+			continue
+		case p.Type != SoftwarePackageTypeDEB && p.Type != SoftwarePackageTypeRPM:
+			addError("package %s: unsupported package type %q", name, p.Type)
+			continue
+		case packages[name]:
+			addError("package %s: duplicated", name)
+			continue
+		}
+		packages[name] = true
+		list.Packages = append(list.Packages, PackageMigrationInfo{
+			Order:                next(),
+			Name:                 name,
+			Version:              strings.TrimSpace(p.Version),
+			NeededPackages:       SplitPackageList(p.NeededPackages),
+			NeedToDeletePackages: SplitPackageList(p.NeedToDeletePackages),
+			CustomDataPaths:      nonNil(p.CustomDataPaths),
+			CustomConfigs:        nonNil(p.CustomConfigs),
+			RepoURL:              strings.TrimSpace(p.RepoURL),
+			GPGKeyURL:            strings.TrimSpace(p.GPGKeyURL),
+			RepoUseOSVersionCode: p.RepoUseOSVersionCode,
+		})
+	}
+
+	for i, b := range softwares.Binaries {
+		name := strings.TrimSpace(b.Name)
+		if name == "" {
+			addError("binaries[%d]: empty name", i)
+			continue
+		}
+		list.Binaries = append(list.Binaries, BinaryMigrationInfo{
+			Order:           next(),
+			Name:            name,
+			Version:         strings.TrimSpace(b.Version),
+			UIDs:            nonNil(b.UIDs),
+			GIDs:            nonNil(b.GIDs),
+			CmdlineSlice:    nonNil(b.CmdlineSlice),
+			Envs:            nonNil(b.Envs),
+			NeededLibraries: nonNil(b.NeededLibraries),
+			BinaryPath:      strings.TrimSpace(b.BinaryPath),
+			CustomDataPaths: nonNil(b.CustomDataPaths),
+			CustomConfigs:   nonNil(b.CustomConfigs),
+			IsWine:          b.IsWine,
+		})
+	}
+
+	for i, c := range softwares.Containers {
+		name := strings.TrimSpace(c.Name)
+		runtime := SoftwareContainerRuntimeType(strings.ToLower(strings.TrimSpace(string(c.Runtime))))
+		image := c.ContainerImage
+		image.ImageName = strings.TrimSpace(image.ImageName)
+		image.ImageVersion = strings.TrimSpace(image.ImageVersion)
+		switch {
+		case name == "":
+			addError("containers[%d]: empty name", i)
+			continue
+		case runtime != SoftwareContainerRuntimeTypeDocker && runtime != SoftwareContainerRuntimeTypePodman:
+			addError("container %s: unsupported runtime %q", name, c.Runtime)
+			continue
+		case image.ImageName == "":
+			addError("container %s: empty image name", name)
+			continue
+		}
+		if image.ImageArchitecture != "" {
+			arch, err := ParseSoftwareArchitecture(strings.TrimSpace(string(image.ImageArchitecture)))
+			if err != nil {
+				addError("container %s: unsupported image architecture %q", name, image.ImageArchitecture)
+				continue
+			}
+			image.ImageArchitecture = arch
+		}
+		list.Containers = append(list.Containers, ContainerMigrationInfo{
+			Order:             next(),
+			Name:              name,
+			Runtime:           string(runtime),
+			ContainerId:       strings.TrimSpace(c.ContainerId),
+			ContainerImage:    image,
+			ContainerPorts:    nonNil(c.ContainerPorts),
+			ContainerStatus:   strings.TrimSpace(c.ContainerStatus),
+			DockerComposePath: strings.TrimSpace(c.DockerComposePath),
+			ComposeService:    strings.TrimSpace(c.ComposeService),
+			MountPaths:        nonNil(c.MountPaths),
+			Envs:              nonNil(c.Envs),
+			NetworkMode:       strings.TrimSpace(c.NetworkMode),
+			RestartPolicy:     strings.TrimSpace(c.RestartPolicy),
+		})
+	}
+
+	// Compose projects of the migrated containers
+	for _, c := range list.Containers {
+		if c.DockerComposePath == "" {
+			continue
+		}
+		if _, ok := list.ComposeProject(c.DockerComposePath); ok {
+			continue
+		}
+		if project, ok := softwares.ComposeProject(c.DockerComposePath); ok {
+			list.ComposeProjects = append(list.ComposeProjects, project)
+		}
+	}
+
+	for i, k := range softwares.Kubernetes {
+		if opts.Velero.Provider == "" || opts.Velero.Bucket == "" {
+			addError("kubernetes[%d]: Velero provider and bucket are not configured", i)
+			continue
+		}
+		list.Kubernetes = append(list.Kubernetes, KubernetesMigrationInfo{
+			Order:      next(),
+			Version:    strings.TrimSpace(k.Version),
+			KubeConfig: k.KubeConfig,
+			Resources:  k.Resources,
+			Velero:     opts.Velero,
+		})
+	}
+
+	if _, err := AssignMigrationOrder(list); err != nil {
+		addError("%v", err)
+	}
+	return server
+}
+
+// packageQualifierPattern matches the version constraints and architecture restrictions of a dependency
+// (e.g., "(>= 2.34)" and "[amd64]" of dpkg, "(64bit)" of RPM).
+var packageQualifierPattern = regexp.MustCompile(`\([^)]*\)|\[[^\]]*\]`)
+
+// SplitPackageList splits a list of packages (e.g., Package.NeededPackages) into package names without empty entries
+// and duplicates. The list is space- or comma-separated (e.g., "curl wget"), or a dependency field of dpkg or RPM
+// such as "libc6 (>= 2.34), libssl3 (>= 3.0.0) | libssl1.1" or "glibc >= 2.34, python3:any":
+// version constraints and architecture qualifiers are removed, and only the first package of alternatives (a | b) is kept.
+func SplitPackageList(s string) []string {
+	packages := []string{}
+	seen := make(map[string]bool)
+	s = packageQualifierPattern.ReplaceAllString(s, " ")
+	for _, entry := range strings.Split(s, ",") {
+		if i := strings.IndexByte(entry, '|'); i >= 0 {
+			entry = entry[:i]
+		}
+		fields := strings.Fields(entry)
+		for i := 0; i < len(fields); i++ {
+			name := fields[i]
+			if strings.ContainsAny(name[:1], "<>=") {
+				// Version constraint of RPM (e.g., ">= 2.34"), whose version may be the next field
+				if strings.Trim(name, "<>=") == "" {
+					i++
+				}
+				continue
+			}
+			if j := strings.IndexByte(name, ':'); j > 0 {
+				name = name[:j] // Architecture qualifier (e.g., python3:any, libc6:amd64)
+			}
+			if !seen[name] {
+				seen[name] = true
+				packages = append(packages, name)
+			}
+		}
+	}
+	return packages
+}
+
+// nonNil returns an empty slice instead of nil, so that it is encoded as [] in JSON.
+func nonNil[T any](s []T) []T {
+	if s == nil {
+		return []T{}
+	}
+	return s
+}
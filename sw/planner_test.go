@@ -0,0 +1,249 @@
+package softwaremodel
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// migrationOrders returns the Order of the items of the list by {kind}:{name} ({kind}:{index} for Kubernetes).
+func migrationOrders(list MigrationList) map[string]int {
+	orders := make(map[string]int)
+	for _, p := range list.Packages {
+		orders["package:"+p.Name] = p.Order
+	}
+	for _, b := range list.Binaries {
+		orders["binary:"+b.Name] = b.Order
+	}
+	for _, c := range list.Containers {
+		orders["container:"+c.Name] = c.Order
+	}
+	for i, k := range list.Kubernetes {
+		orders[fmt.Sprintf("kubernetes:%d", i)] = k.Order
+	}
+	return orders
+}
+
+func TestPlanSoftwareMigration(t *testing.T) {
+	velero := KubernetesVelero{Provider: "aws", Bucket: "velero-backups", BackupLocationConfig: "region=ap-northeast-2"}
+	image := ContainerImage{ImageName: "nginx", ImageVersion: "1.25", ImageArchitecture: SoftwareArchitectureX8664}
+	cluster := Kubernetes{Version: "1.29", KubeConfig: "apiVersion: v1"}
+
+	tests := []struct {
+		name       string
+		connection string
+		softwares  SoftwareList
+		opts       PlanOptions
+		want       map[string]int // Order of the planned items
+		wantErrors []string       // Substrings of the errors of the server, in order
+	}{
+		{
+			name:       "ordered by dependencies",
+			connection: "conn-1",
+			softwares: SoftwareList{
+				Packages: []Package{
+					{Name: "nginx", Type: SoftwarePackageTypeDEB, NeededPackages: "nginx-common (= 1.18.0), libssl3"},
+					{Name: "nginx-common", Type: SoftwarePackageTypeDEB},
+				},
+			},
+			want: map[string]int{"package:nginx-common": 1, "package:nginx": 2},
+		},
+		{
+			name:       "unsupported package type and container runtime",
+			connection: "conn-1",
+			softwares: SoftwareList{
+				Packages: []Package{
+					{Name: "curl", Type: SoftwarePackageTypeDEB},
+					{Name: "nginx", Type: "apk"},
+				},
+				Containers: []Container{
+					{Name: "web", Runtime: " Docker ", ContainerImage: image},
+					{Name: "db", Runtime: "containerd", ContainerImage: image},
+				},
+			},
+			want: map[string]int{"package:curl": 1, "container:web": 2},
+			wantErrors: []string{
+				`package nginx: unsupported package type "apk"`,
+				`container db: unsupported runtime "containerd"`,
+			},
+		},
+		{
+			name:       "image architectures",
+			connection: "conn-1",
+			softwares: SoftwareList{
+				Containers: []Container{
+					{Name: "web", Runtime: SoftwareContainerRuntimeTypeDocker, ContainerImage: ContainerImage{ImageName: "nginx", ImageArchitecture: "amd64"}},
+					{Name: "cache", Runtime: SoftwareContainerRuntimeTypeDocker, ContainerImage: ContainerImage{ImageName: "redis", ImageArchitecture: "sparc"}},
+					{Name: "db", Runtime: SoftwareContainerRuntimeTypeDocker, ContainerImage: ContainerImage{ImageName: "postgres"}},
+				},
+			},
+			want:       map[string]int{"container:web": 1, "container:db": 2},
+			wantErrors: []string{`container cache: unsupported image architecture "sparc"`},
+		},
+		{
+			name:       "duplicated package",
+			connection: "conn-1",
+			softwares: SoftwareList{
+				Packages: []Package{
+					{Name: "curl", Type: SoftwarePackageTypeDEB, Version: "7.81"},
+					{Name: " curl ", Type: SoftwarePackageTypeRPM, Version: "7.76"},
+				},
+			},
+			want:       map[string]int{"package:curl": 1},
+			wantErrors: []string{"package curl: duplicated"},
+		},
+		{
+			name:       "Kubernetes without Velero",
+			connection: "conn-1",
+			softwares:  SoftwareList{Kubernetes: []Kubernetes{cluster}},
+			opts:       PlanOptions{Velero: KubernetesVelero{Provider: "aws"}},
+			want:       map[string]int{},
+			wantErrors: []string{"kubernetes[0]: Velero provider and bucket are not configured"},
+		},
+		{
+			name:       "Kubernetes with Velero",
+			connection: "conn-1",
+			softwares:  SoftwareList{Kubernetes: []Kubernetes{cluster}},
+			opts:       PlanOptions{Velero: velero},
+			want:       map[string]int{"kubernetes:0": 1},
+		},
+		{
+			name:       "cycle",
+			connection: "conn-1",
+			softwares: SoftwareList{
+				Binaries: []Binary{{Name: "app"}},
+				Packages: []Package{
+					{Name: "b", Type: SoftwarePackageTypeDEB, NeededPackages: "a"},
+					{Name: "a", Type: SoftwarePackageTypeDEB, NeededPackages: "b"},
+				},
+				Containers: []Container{{Name: "web", Runtime: SoftwareContainerRuntimeTypeDocker, ContainerImage: image}},
+			},
+			// Packages first, then binaries and containers, in the order of the source
+			want:       map[string]int{"package:b": 1, "package:a": 2, "binary:app": 3, "container:web": 4},
+			wantErrors: []string{"dependency cycle: package:"},
+		},
+		{
+			name:       "empty connection ID",
+			connection: "  ",
+			softwares:  SoftwareList{Packages: []Package{{Name: "curl", Type: SoftwarePackageTypeDEB}}},
+			want:       map[string]int{"package:curl": 1},
+			wantErrors: []string{"connection_info_list[0]: empty connection ID"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			source := SourceGroupSoftwareProperty{
+				SourceGroupId: "group-1",
+				ConnectionInfoList: []SourceConnectionInfoSoftwareProperty{
+					{ConnectionId: tt.connection, Softwares: tt.softwares},
+				},
+			}
+			target := PlanSoftwareMigration(source, tt.opts)
+			if len(target.Servers) != 1 {
+				t.Fatalf("%d servers, want 1", len(target.Servers))
+			}
+			server := target.Servers[0]
+			if want := strings.TrimSpace(tt.connection); server.SourceConnectionInfoID != want {
+				t.Errorf("connection ID %q, want %q", server.SourceConnectionInfoID, want)
+			}
+			if got := migrationOrders(server.MigrationList); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("orders = %v, want %v", got, tt.want)
+			}
+			if len(server.Errors) != len(tt.wantErrors) {
+				t.Fatalf("errors = %q, want %q", server.Errors, tt.wantErrors)
+			}
+			for i, want := range tt.wantErrors {
+				if !strings.Contains(server.Errors[i], want) {
+					t.Errorf("errors[%d] = %q, want %q", i, server.Errors[i], want)
+				}
+			}
+		})
+	}
+}
+
+func TestPlanSoftwareMigrationImageArchitecture(t *testing.T) {
+	tests := []struct {
+		arch SoftwareArchitecture
+		want SoftwareArchitecture
+	}{
+		{"amd64", SoftwareArchitectureX8664},
+		{" aarch64 ", SoftwareArchitectureARM64v8},
+		{"arm64v8", SoftwareArchitectureARM64v8},
+		{"", ""},
+	}
+	for _, tt := range tests {
+		source := SourceGroupSoftwareProperty{
+			ConnectionInfoList: []SourceConnectionInfoSoftwareProperty{{
+				ConnectionId: "conn-1",
+				Softwares: SoftwareList{Containers: []Container{{
+					Name: "web", Runtime: SoftwareContainerRuntimeTypeDocker,
+					ContainerImage: ContainerImage{ImageName: "nginx", ImageArchitecture: tt.arch},
+				}}},
+			}},
+		}
+		containers := PlanSoftwareMigration(source, PlanOptions{}).Servers[0].MigrationList.Containers
+		if len(containers) != 1 {
+			t.Errorf("architecture %q: %d containers, want 1", tt.arch, len(containers))
+			continue
+		}
+		if got := containers[0].ContainerImage.ImageArchitecture; got != tt.want {
+			t.Errorf("architecture %q planned as %q, want %q", tt.arch, got, tt.want)
+		}
+	}
+}
+
+func TestPlanSoftwareMigrationConnections(t *testing.T) {
+	source := SourceGroupSoftwareProperty{
+		SourceGroupId: "group-1",
+		ConnectionInfoList: []SourceConnectionInfoSoftwareProperty{
+			{ConnectionId: "conn-1"},
+			{ConnectionId: ""},
+			{ConnectionId: "conn-3"},
+		},
+	}
+	target := PlanSoftwareMigration(source, PlanOptions{})
+	var ids []string
+	for _, server := range target.Servers {
+		ids = append(ids, server.SourceConnectionInfoID)
+	}
+	if want := []string{"conn-1", "", "conn-3"}; !reflect.DeepEqual(ids, want) {
+		t.Errorf("connection IDs = %q, want %q", ids, want)
+	}
+	if errs := target.Servers[1].Errors; len(errs) != 1 || errs[0] != "connection_info_list[1]: empty connection ID" {
+		t.Errorf("errors of the server without connection ID = %q", errs)
+	}
+	if errs := target.Servers[0].Errors; len(errs) != 0 {
+		t.Errorf("errors of conn-1 = %q, want none", errs)
+	}
+}
+
+func TestSplitPackageList(t *testing.T) {
+	tests := []struct {
+		name string
+		list string
+		want []string
+	}{
+		{"empty", "", []string{}},
+		{"space-separated", "curl  wget\tgit\n", []string{"curl", "wget", "git"}},
+		{"comma-separated", "curl,wget, git,,", []string{"curl", "wget", "git"}},
+		{"duplicates", "curl wget, curl", []string{"curl", "wget"}},
+		{"dpkg versions and alternatives", "libc6 (>= 2.34), libssl3 (>= 3.0.0) | libssl1.1", []string{"libc6", "libssl3"}},
+		{"dpkg exact version", "nginx-common (= 1.18.0-6ubuntu14.4)", []string{"nginx-common"}},
+		{"dpkg architecture qualifiers", "python3:any (>= 3.10~), libc6:amd64", []string{"python3", "libc6"}},
+		{"dpkg architecture restriction", "libnuma1 [amd64 arm64], adduser", []string{"libnuma1", "adduser"}},
+		{"alternatives only", "default-mta | mail-transport-agent", []string{"default-mta"}},
+		{"rpm versions", "glibc >= 2.34, openssl-libs >= 1:3.0.7, bash", []string{"glibc", "openssl-libs", "bash"}},
+		{"rpm version without space", "glibc >=2.34, zlib", []string{"glibc", "zlib"}},
+		{"rpm capability", "libc.so.6()(64bit), libcrypto.so.3(OPENSSL_3.0.0)(64bit)", []string{"libc.so.6", "libcrypto.so.3"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := SplitPackageList(tt.list); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("SplitPackageList(%q) = %q, want %q", tt.list, got, tt.want)
+			}
+		})
+	}
+}
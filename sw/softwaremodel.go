@@ -75,8 +75,9 @@ type ContainerPort struct {
 }
 
 type Env struct {
-	Name  string `json:"name,omitempty" validate:"required"`
-	Value string `json:"value,omitempty"`
+	Name    string `json:"name,omitempty" validate:"required"`
+	Value   string `json:"value,omitempty"`
+	NoValue bool   `json:"no_value,omitempty"` // Variable of a compose file without value (e.g., "- KEY" or "KEY: null"), taken from the host
 }
 
 type Binary struct {
@@ -114,6 +115,7 @@ type Container struct {
 	ContainerPorts    []ContainerPort              `json:"container_ports"`
 	ContainerStatus   string                       `json:"container_status" validate:"required"`
 	DockerComposePath string                       `json:"docker_compose_path"`
+	ComposeService    string                       `json:"compose_service,omitempty"` // Service of the container in the project of DockerComposePath
 	MountPaths        []string                     `json:"mount_paths"`
 	Envs              []Env                        `json:"envs"`
 	NetworkMode       string                       `json:"network_mode,omitempty" validate:"required"`
@@ -127,10 +129,11 @@ type Kubernetes struct {
 }
 
 type SoftwareList struct {
-	Binaries   []Binary     `json:"binaries"`
-	Packages   []Package    `json:"packages"`
-	Containers []Container  `json:"containers"`
-	Kubernetes []Kubernetes `json:"kubernetes"`
+	Binaries        []Binary         `json:"binaries"`
+	Packages        []Package        `json:"packages"`
+	Containers      []Container      `json:"containers"`
+	Kubernetes      []Kubernetes     `json:"kubernetes"`
+	ComposeProjects []ComposeProject `json:"compose_projects,omitempty"` // Projects of the DockerComposePath of the containers
 }
 
 type SourceConnectionInfoSoftwareProperty struct {
@@ -184,6 +187,7 @@ type ContainerMigrationInfo struct {
 	ContainerPorts    []ContainerPort `json:"container_ports"`
 	ContainerStatus   string          `json:"container_status" validate:"required"`
 	DockerComposePath string          `json:"docker_compose_path"`
+	ComposeService    string          `json:"compose_service,omitempty"` // Service of the container in the project of DockerComposePath
 	MountPaths        []string        `json:"mount_paths"`
 	Envs              []Env           `json:"envs"`
 	NetworkMode       string          `json:"network_mode,omitempty" validate:"required"`
@@ -208,10 +212,11 @@ type KubernetesMigrationInfo struct {
 }
 
 type MigrationList struct {
-	Binaries   []BinaryMigrationInfo     `json:"binaries"`
-	Packages   []PackageMigrationInfo    `json:"packages"`
-	Containers []ContainerMigrationInfo  `json:"containers"`
-	Kubernetes []KubernetesMigrationInfo `json:"kubernetes"`
+	Binaries        []BinaryMigrationInfo     `json:"binaries"`
+	Packages        []PackageMigrationInfo    `json:"packages"`
+	Containers      []ContainerMigrationInfo  `json:"containers"`
+	Kubernetes      []KubernetesMigrationInfo `json:"kubernetes"`
+	ComposeProjects []ComposeProject          `json:"compose_projects,omitempty"` // Projects of the DockerComposePath of the containers
 }
 
 type MigrationServer struct {
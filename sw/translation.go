@@ -0,0 +1,268 @@
+package softwaremodel
+
+import (
+	_ "embed"
+	"encoding/json"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+
+	cloudmodel "github.com/cloud-barista/cm-model/infra/cloud-model"
+	onpremisemodel "github.com/cloud-barista/cm-model/infra/on-premise-model"
+)
+
+// PackageMapping lists the names of a package in each family (e.g., apache2 for deb and httpd for rpm).
+// A package is looked up by the first name of its family; the other names are installed with it
+// (e.g., build-essential for deb is gcc, gcc-c++ and make for rpm). An empty list means there is no equivalent.
+type PackageMapping map[SoftwarePackageType][]string
+
+// PackageSuffixMapping maps a suffix of package names between families (e.g., -dev for deb and -devel for rpm).
+// It applies to the packages without a mapping.
+type PackageSuffixMapping map[SoftwarePackageType]string
+
+// PackageMappingTable is the table translating package names between families.
+type PackageMappingTable struct {
+	Mappings []PackageMapping       `json:"mappings"`
+	Suffixes []PackageSuffixMapping `json:"suffixes"`
+}
+
+//go:embed package-mappings.json
+var defaultPackageMappings []byte
+
+// DefaultPackageMappingTable returns the built-in table of common packages (package-mappings.json).
+func DefaultPackageMappingTable() *PackageMappingTable {
+	var t PackageMappingTable
+	if err := json.Unmarshal(defaultPackageMappings, &t); err != nil {
+		panic("invalid package-mappings.json: " + err.Error())
+	}
+	return &t
+}
+
+// LoadPackageMappingTable loads a table from a JSON file in the format of package-mappings.json.
+func LoadPackageMappingTable(path string) (*PackageMappingTable, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var t PackageMappingTable
+	if err := json.Unmarshal(data, &t); err != nil {
+		return nil, fmt.Errorf("invalid package mapping table %s: %w", path, err)
+	}
+	return &t, nil
+}
+
+// With returns a table with the entries of other taking precedence over the ones of t
+// (e.g., DefaultPackageMappingTable().With(custom)).
+func (t *PackageMappingTable) With(other *PackageMappingTable) *PackageMappingTable {
+	return &PackageMappingTable{
+		Mappings: append(append([]PackageMapping{}, other.Mappings...), t.Mappings...),
+		Suffixes: append(append([]PackageSuffixMapping{}, other.Suffixes...), t.Suffixes...),
+	}
+}
+
+// Translate translates a package name from a family to another.
+// A package without a mapping keeps its name (after suffix translation), as most packages have the same name
+// in both families. It returns false if the table says the package has no equivalent in the target family.
+func (t *PackageMappingTable) Translate(name string, from, to SoftwarePackageType) ([]string, bool) {
+	if from == to {
+		return []string{name}, true
+	}
+	for _, m := range t.Mappings {
+		if names, ok := m[from]; ok && len(names) > 0 && names[0] == name {
+			translated := m[to]
+			return append([]string{}, translated...), len(translated) > 0
+		}
+	}
+	for _, s := range t.Suffixes {
+		suffix, target := s[from], s[to]
+		if suffix != "" && strings.HasSuffix(name, suffix) {
+			return []string{strings.TrimSuffix(name, suffix) + target}, true
+		}
+	}
+	return []string{name}, true
+}
+
+// packageFamilyKeywords maps keywords of OS IDs and distribution names to their package family.
+var packageFamilyKeywords = []struct {
+	keyword string
+	family  SoftwarePackageType
+}{
+	{"debian", SoftwarePackageTypeDEB}, {"ubuntu", SoftwarePackageTypeDEB}, {"mint", SoftwarePackageTypeDEB},
+	{"rhel", SoftwarePackageTypeRPM}, {"red hat", SoftwarePackageTypeRPM}, {"redhat", SoftwarePackageTypeRPM},
+	{"fedora", SoftwarePackageTypeRPM}, {"centos", SoftwarePackageTypeRPM}, {"rocky", SoftwarePackageTypeRPM},
+	{"alma", SoftwarePackageTypeRPM}, {"amzn", SoftwarePackageTypeRPM}, {"amazon linux", SoftwarePackageTypeRPM},
+	{"al2023", SoftwarePackageTypeRPM}, {"oracle linux", SoftwarePackageTypeRPM}, {"ol", SoftwarePackageTypeRPM},
+	{"suse", SoftwarePackageTypeRPM}, {"opensuse", SoftwarePackageTypeRPM}, {"sles", SoftwarePackageTypeRPM},
+}
+
+// packageFamilyOfWords returns the family of the first word (or phrase) of s matching a keyword
+// (e.g., rpm for "rhel-9-with-debian-tools"). Keywords longer than 3 letters also match the beginning of a word
+// (e.g., rocky of rockylinux).
+func packageFamilyOfWords(s string) (SoftwarePackageType, bool) {
+	s = " " + strings.Join(strings.FieldsFunc(strings.ToLower(s), func(r rune) bool {
+		return !(r >= 'a' && r <= 'z' || r >= '0' && r <= '9')
+	}), " ") + " "
+	first, family := -1, SoftwarePackageType("")
+	for _, k := range packageFamilyKeywords {
+		i := strings.Index(s, " "+k.keyword+" ")
+		if len(k.keyword) > 3 {
+			i = strings.Index(s, " "+k.keyword)
+		}
+		if i >= 0 && (first < 0 || i < first) {
+			first, family = i, k.family
+		}
+	}
+	return family, first >= 0
+}
+
+// PackageFamilyOfOS returns the package family of a source server from its `/etc/os-release` ID and ID_LIKE.
+func PackageFamilyOfOS(osProperty onpremisemodel.OsProperty) (SoftwarePackageType, bool) {
+	for _, s := range []string{osProperty.ID, osProperty.IDLike, osProperty.Name, osProperty.PrettyName} {
+		if family, ok := packageFamilyOfWords(s); ok {
+			return family, true
+		}
+	}
+	return "", false
+}
+
+// PackageFamilyOfImage returns the package family of a target image from its distribution
+// (e.g., ubuntu/images/hvm-ssd/ubuntu-jammy-22.04-amd64-server or Rocky Linux 9.3) or OS type.
+func PackageFamilyOfImage(image cloudmodel.ImageInfo) (SoftwarePackageType, bool) {
+	for _, s := range []string{image.OSDistribution, string(image.OSType)} {
+		if family, ok := packageFamilyOfWords(s); ok {
+			return family, true
+		}
+	}
+	return "", false
+}
+
+// UntranslatablePackage is a package that could not be translated to the target family.
+type UntranslatablePackage struct {
+	Package string `json:"package"` // Name of the PackageMigrationInfo
+	Name    string `json:"name"`    // Untranslatable name (the package itself, or one of its needed packages)
+	Reason  string `json:"reason"`
+}
+
+// TranslatePackages translates the packages of the list from the family of the source OS to the family of the target
+// image, using the table (DefaultPackageMappingTable if nil). Names and NeededPackages are translated; a package
+// translated into several packages keeps the first one as its name and needs the others.
+// Versions are cleared, as they are versions of the source distribution (e.g., 2.4.52-1ubuntu4.7 of apache2),
+// so that the target installs the version of its repositories.
+// Untranslatable names are reported and removed: packages without an equivalent (e.g., ufw for rpm) from the list,
+// and needed packages from NeededPackages and NeedToDeletePackages. Packages from a custom repository,
+// which is specific to the source family, are reported but kept.
+// The list is then ordered again (see AssignMigrationOrder), as the translated names and needed packages change
+// the dependencies; if they form a cycle, a *CycleError is returned with the translated list, whose orders are
+// renumbered without the gaps of the removed packages.
+// It returns an error if the family of the source or the target is unknown.
+func TranslatePackages(list *MigrationList, source onpremisemodel.OsProperty, target cloudmodel.ImageInfo, table *PackageMappingTable) ([]UntranslatablePackage, error) {
+	from, ok := PackageFamilyOfOS(source)
+	if !ok {
+		return nil, fmt.Errorf("unknown package family of the source OS %q", source.PrettyName)
+	}
+	to, ok := PackageFamilyOfImage(target)
+	if !ok {
+		return nil, fmt.Errorf("unknown package family of the target image %s (%s)", target.Id, target.OSDistribution)
+	}
+	return TranslatePackagesBetween(list, from, to, table)
+}
+
+// TranslatePackagesBetween translates the packages of the list between the given families (see TranslatePackages).
+func TranslatePackagesBetween(list *MigrationList, from, to SoftwarePackageType, table *PackageMappingTable) ([]UntranslatablePackage, error) {
+	untranslatable := []UntranslatablePackage{}
+	if from == to {
+		return untranslatable, nil
+	}
+	if table == nil {
+		table = DefaultPackageMappingTable()
+	}
+	reason := fmt.Sprintf("no %s equivalent", to)
+
+	translateAll := func(pkg string, names []string) []string {
+		translated := []string{}
+		seen := make(map[string]bool)
+		for _, name := range names {
+			result, ok := table.Translate(name, from, to)
+			if !ok {
+				untranslatable = append(untranslatable, UntranslatablePackage{Package: pkg, Name: name, Reason: reason})
+				continue
+			}
+			for _, r := range result {
+				if !seen[r] {
+					seen[r] = true
+					translated = append(translated, r)
+				}
+			}
+		}
+		return translated
+	}
+
+	packages := list.Packages[:0]
+	for _, p := range list.Packages {
+		original := p.Name
+		names, ok := table.Translate(p.Name, from, to)
+		if !ok {
+			untranslatable = append(untranslatable, UntranslatablePackage{Package: original, Name: original, Reason: reason})
+			continue
+		}
+		p.Name = names[0]
+		p.Version = ""
+		needed := translateAll(original, p.NeededPackages)
+		if len(names) > 1 {
+			needed = append(names[1:], needed...)
+		}
+		p.NeededPackages = SplitPackageList(strings.Join(needed, " "))
+		p.NeedToDeletePackages = translateAll(original, p.NeedToDeletePackages)
+
+		if p.RepoURL != "" {
+			untranslatable = append(untranslatable, UntranslatablePackage{
+				Package: original,
+				Name:    original,
+				Reason:  fmt.Sprintf("the custom repository %s is for %s packages", p.RepoURL, from),
+			})
+		}
+		packages = append(packages, p)
+	}
+	list.Packages = packages
+
+	if _, err := AssignMigrationOrder(list); err != nil {
+		compactMigrationOrder(list)
+		return untranslatable, err
+	}
+	return untranslatable, nil
+}
+
+// compactMigrationOrder renumbers the orders of the list from 1 without gaps, keeping the items of the same order together.
+func compactMigrationOrder(list *MigrationList) {
+	var orders []*int
+	for i := range list.Packages {
+		orders = append(orders, &list.Packages[i].Order)
+	}
+	for i := range list.Binaries {
+		orders = append(orders, &list.Binaries[i].Order)
+	}
+	for i := range list.Containers {
+		orders = append(orders, &list.Containers[i].Order)
+	}
+	for i := range list.Kubernetes {
+		orders = append(orders, &list.Kubernetes[i].Order)
+	}
+
+	distinct := []int{}
+	seen := make(map[int]bool)
+	for _, o := range orders {
+		if !seen[*o] {
+			seen[*o] = true
+			distinct = append(distinct, *o)
+		}
+	}
+	sort.Ints(distinct)
+	rank := make(map[int]int, len(distinct))
+	for i, o := range distinct {
+		rank[o] = i + 1
+	}
+	for _, o := range orders {
+		*o = rank[*o]
+	}
+}
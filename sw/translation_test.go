@@ -0,0 +1,136 @@
+package softwaremodel
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+
+	cloudmodel "github.com/cloud-barista/cm-model/infra/cloud-model"
+	onpremisemodel "github.com/cloud-barista/cm-model/infra/on-premise-model"
+)
+
+func TestTranslatePackages(t *testing.T) {
+	list := MigrationList{
+		Packages: []PackageMigrationInfo{
+			{Order: 1, Name: "apache2", Version: "2.4.52-1ubuntu4.7", NeededPackages: []string{"libssl3", "ufw"}},
+			{Order: 2, Name: "ufw", Version: "0.36.1-4ubuntu0.1"},
+			{Order: 3, Name: "build-essential", Version: "12.9ubuntu3", NeedToDeletePackages: []string{"libpcre3"}},
+			{Order: 4, Name: "libyaml-cpp-dev", Version: "0.7.0+dfsg-8build1"},
+			{Order: 5, Name: "docker-ce", Version: "5:27.3.1-1~ubuntu.22.04~jammy", RepoURL: "https://download.docker.com/linux/ubuntu"},
+		},
+	}
+	source := onpremisemodel.OsProperty{ID: "ubuntu", IDLike: "debian", PrettyName: "Ubuntu 22.04.4 LTS"}
+	target := cloudmodel.ImageInfo{Id: "rocky-9", OSDistribution: "Rocky Linux 9.3 (Blue Onyx)"}
+
+	untranslatable, err := TranslatePackages(&list, source, target, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := []PackageMigrationInfo{
+		{Order: 1, Name: "httpd", NeededPackages: []string{"openssl-libs"}, NeedToDeletePackages: []string{}},
+		{Order: 2, Name: "gcc", NeededPackages: []string{"gcc-c++", "make"}, NeedToDeletePackages: []string{"pcre"}},
+		{Order: 3, Name: "libyaml-cpp-devel", NeededPackages: []string{}, NeedToDeletePackages: []string{}},
+		{Order: 4, Name: "docker-ce", NeededPackages: []string{}, NeedToDeletePackages: []string{}, RepoURL: "https://download.docker.com/linux/ubuntu"},
+	}
+	if !reflect.DeepEqual(list.Packages, want) {
+		t.Errorf("packages = %+v, want %+v", list.Packages, want)
+	}
+
+	wantUntranslatable := []UntranslatablePackage{
+		{Package: "apache2", Name: "ufw", Reason: "no rpm equivalent"},
+		{Package: "ufw", Name: "ufw", Reason: "no rpm equivalent"},
+		{Package: "docker-ce", Name: "docker-ce", Reason: "the custom repository https://download.docker.com/linux/ubuntu is for deb packages"},
+	}
+	if !reflect.DeepEqual(untranslatable, wantUntranslatable) {
+		t.Errorf("untranslatable = %+v, want %+v", untranslatable, wantUntranslatable)
+	}
+}
+
+func TestTranslatePackagesSameFamily(t *testing.T) {
+	list := MigrationList{Packages: []PackageMigrationInfo{{Name: "ufw", Version: "0.36.1-4ubuntu0.1"}}}
+	untranslatable, err := TranslatePackagesBetween(&list, SoftwarePackageTypeDEB, SoftwarePackageTypeDEB, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(untranslatable) != 0 || len(list.Packages) != 1 || list.Packages[0].Version != "0.36.1-4ubuntu0.1" {
+		t.Errorf("packages = %+v, untranslatable = %+v, want them unchanged", list.Packages, untranslatable)
+	}
+}
+
+func TestTranslatePackagesBetweenOrder(t *testing.T) {
+	orders := func(list MigrationList) map[string]int {
+		m := make(map[string]int)
+		for _, p := range list.Packages {
+			m[p.Name] = p.Order
+		}
+		return m
+	}
+
+	// build-essential becomes gcc, which needs make: make is now installed first
+	list := MigrationList{
+		Packages: []PackageMigrationInfo{
+			{Order: 1, Name: "build-essential"},
+			{Order: 2, Name: "ufw"},
+			{Order: 3, Name: "make"},
+		},
+		Binaries: []BinaryMigrationInfo{{Order: 4, Name: "app"}},
+	}
+	if _, err := TranslatePackagesBetween(&list, SoftwarePackageTypeDEB, SoftwarePackageTypeRPM, nil); err != nil {
+		t.Fatal(err)
+	}
+	if got, want := orders(list), map[string]int{"make": 1, "gcc": 2}; !reflect.DeepEqual(got, want) {
+		t.Errorf("orders = %v, want %v", got, want)
+	}
+	if list.Binaries[0].Order != 3 {
+		t.Errorf("binary order %d, want 3", list.Binaries[0].Order)
+	}
+
+	// make needs build-essential, which becomes gcc needing make: the orders are only renumbered
+	list = MigrationList{
+		Packages: []PackageMigrationInfo{
+			{Order: 1, Name: "ufw"},
+			{Order: 2, Name: "build-essential"},
+			{Order: 3, Name: "make", NeededPackages: []string{"build-essential"}},
+		},
+		Binaries: []BinaryMigrationInfo{{Order: 4, Name: "app"}},
+	}
+	_, err := TranslatePackagesBetween(&list, SoftwarePackageTypeDEB, SoftwarePackageTypeRPM, nil)
+	var cycleErr *CycleError
+	if !errors.As(err, &cycleErr) {
+		t.Fatalf("error = %v, want a *CycleError", err)
+	}
+	if got, want := orders(list), map[string]int{"gcc": 1, "make": 2}; !reflect.DeepEqual(got, want) {
+		t.Errorf("orders = %v, want %v", got, want)
+	}
+	if list.Binaries[0].Order != 3 {
+		t.Errorf("binary order %d, want 3", list.Binaries[0].Order)
+	}
+}
+
+func TestPackageFamilyOfWords(t *testing.T) {
+	tests := []struct {
+		s      string
+		want   SoftwarePackageType
+		wantOk bool
+	}{
+		{"ubuntu", SoftwarePackageTypeDEB, true},
+		{"rhel centos fedora", SoftwarePackageTypeRPM, true},
+		{"ubuntu/images/hvm-ssd/ubuntu-jammy-22.04-amd64-server-20250516", SoftwarePackageTypeDEB, true},
+		{"Rocky Linux 9.3 (Blue Onyx)", SoftwarePackageTypeRPM, true},
+		{"rockylinux", SoftwarePackageTypeRPM, true},
+		{"Red Hat Enterprise Linux 9.4", SoftwarePackageTypeRPM, true},
+		{"Oracle Linux Server 8.10", SoftwarePackageTypeRPM, true},
+		{"rhel-9-with-debian-tools", SoftwarePackageTypeRPM, true}, // The first word, not the first keyword of the table
+		{"debian-12-with-rhel-tools", SoftwarePackageTypeDEB, true},
+		{"olive", "", false},
+		{"Windows Server 2022", "", false},
+	}
+
+	for _, tt := range tests {
+		got, ok := packageFamilyOfWords(tt.s)
+		if got != tt.want || ok != tt.wantOk {
+			t.Errorf("packageFamilyOfWords(%q) = %q, %v, want %q, %v", tt.s, got, ok, tt.want, tt.wantOk)
+		}
+	}
+}
@@ -0,0 +1,15 @@
+package softwaremodel
+
+import "github.com/cloud-barista/cm-model/validation"
+
+// Validate checks the `validate` tags of the whole source software model.
+// It returns validation.Errors listing every failure with its JSON path, or nil.
+func (m SourceSoftwareModel) Validate() error {
+	return validation.Check(m).Err()
+}
+
+// Validate checks the `validate` tags of the whole target software model.
+// It returns validation.Errors listing every failure with its JSON path, or nil.
+func (m TargetSoftwareModel) Validate() error {
+	return validation.Check(m).Err()
+}
@@ -0,0 +1,169 @@
+// Package validation enforces the `validate` struct tags declared on cm-model types.
+//
+// The models in this module are annotated with go-playground style tags (e.g., `validate:"required"`),
+// but the module itself must stay free of heavy dependencies. This package implements the subset of
+// rules used by the models and reports every violation with a JSON path
+// (e.g., onpremiseInfraModel.servers[3].cpu.cores), so consumers share one validation behavior.
+package validation
+
+import (
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+)
+
+// Supported validation rules.
+const (
+	RuleRequired  = "required"  // The field must not be a zero value (non-empty for slices and maps)
+	RuleOmitEmpty = "omitempty" // Skip validation of the field (and its children) if it is a zero value
+)
+
+// FieldError represents a single validation failure at a JSON path.
+type FieldError struct {
+	Path    string `json:"path"`    // JSON path of the field (e.g., onpremiseInfraModel.servers[3].cpu.cores)
+	Rule    string `json:"rule"`    // Violated rule (e.g., required, cidr, mac)
+	Message string `json:"message"` // Human-readable explanation
+}
+
+func (e *FieldError) Error() string {
+	return fmt.Sprintf("%s: %s", e.Path, e.Message)
+}
+
+// Errors is a multi-error report collecting every validation failure of a model.
+type Errors []*FieldError
+
+// Error returns all failures, one per line.
+func (errs Errors) Error() string {
+	msgs := make([]string, 0, len(errs))
+	for _, e := range errs {
+		msgs = append(msgs, e.Error())
+	}
+	return strings.Join(msgs, "\n")
+}
+
+// Add appends a failure to the report.
+func (errs *Errors) Add(path, rule, format string, args ...any) {
+	*errs = append(*errs, &FieldError{Path: path, Rule: rule, Message: fmt.Sprintf(format, args...)})
+}
+
+// Err returns nil if there is no failure, or the report itself otherwise.
+// Use it to avoid returning a non-nil error interface holding an empty report.
+func (errs Errors) Err() error {
+	if len(errs) == 0 {
+		return nil
+	}
+	return errs
+}
+
+// Check walks the given struct (or pointer to struct) including nested structs, slices and maps,
+// and returns every violation of the `validate` tags.
+// Paths are composed from the `json` tag names of the fields.
+func Check(v any) Errors {
+	var errs Errors
+	walk(reflect.ValueOf(v), "", &errs)
+	return errs
+}
+
+// JoinPath appends a field name to a JSON path.
+func JoinPath(path, name string) string {
+	if path == "" {
+		return name
+	}
+	return path + "." + name
+}
+
+// IndexPath appends a slice index to a JSON path.
+func IndexPath(path string, i int) string {
+	return fmt.Sprintf("%s[%d]", path, i)
+}
+
+func walk(v reflect.Value, path string, errs *Errors) {
+	switch v.Kind() {
+	case reflect.Pointer, reflect.Interface:
+		if !v.IsNil() {
+			walk(v.Elem(), path, errs)
+		}
+	case reflect.Struct:
+		walkStruct(v, path, errs)
+	case reflect.Slice, reflect.Array:
+		for i := 0; i < v.Len(); i++ {
+			walk(v.Index(i), IndexPath(path, i), errs)
+		}
+	case reflect.Map:
+		keys := v.MapKeys()
+		sort.Slice(keys, func(i, j int) bool {
+			return fmt.Sprint(keys[i].Interface()) < fmt.Sprint(keys[j].Interface())
+		})
+		for _, k := range keys {
+			walk(v.MapIndex(k), fmt.Sprintf("%s[%v]", path, k.Interface()), errs)
+		}
+	}
+}
+
+func walkStruct(v reflect.Value, path string, errs *Errors) {
+	t := v.Type()
+	for i := 0; i < t.NumField(); i++ {
+		sf := t.Field(i)
+		// Fields of an embedded struct of an unexported type are promoted as encoding/json does
+		if !sf.IsExported() && !(sf.Anonymous && sf.Type.Kind() == reflect.Struct) {
+			continue
+		}
+		fv := v.Field(i)
+
+		// Embedded structs (e.g., VmInfraInfo{MciInfo}) are flattened as encoding/json does
+		if sf.Anonymous && jsonName(sf) == "" {
+			walk(fv, path, errs)
+			continue
+		}
+
+		name := jsonName(sf)
+		if name == "-" {
+			continue
+		}
+		if name == "" {
+			name = sf.Name
+		}
+		fieldPath := JoinPath(path, name)
+
+		rules := strings.Split(sf.Tag.Get("validate"), ",")
+		if hasRule(rules, "-") {
+			continue
+		}
+		if isEmpty(fv) {
+			if hasRule(rules, RuleRequired) {
+				errs.Add(fieldPath, RuleRequired, "is required")
+				continue
+			}
+			if hasRule(rules, RuleOmitEmpty) {
+				continue
+			}
+		}
+		walk(fv, fieldPath, errs)
+	}
+}
+
+func jsonName(sf reflect.StructField) string {
+	name, _, _ := strings.Cut(sf.Tag.Get("json"), ",")
+	return name
+}
+
+func hasRule(rules []string, rule string) bool {
+	for _, r := range rules {
+		if strings.TrimSpace(r) == rule {
+			return true
+		}
+	}
+	return false
+}
+
+func isEmpty(v reflect.Value) bool {
+	switch v.Kind() {
+	case reflect.Slice, reflect.Map:
+		return v.Len() == 0
+	case reflect.Pointer, reflect.Interface:
+		return v.IsNil()
+	default:
+		return v.IsZero()
+	}
+}
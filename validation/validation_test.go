@@ -0,0 +1,177 @@
+package validation
+
+import (
+	"reflect"
+	"testing"
+)
+
+type testCpu struct {
+	Cores uint32 `json:"cores" validate:"required"`
+	Model string `json:"model"`
+}
+
+type testDisk struct {
+	Label string `json:"label" validate:"required"`
+}
+
+type testServer struct {
+	Hostname string              `json:"hostname" validate:"required"`
+	Cpu      testCpu             `json:"cpu" validate:"required"`
+	Disks    []testDisk          `json:"disks,omitempty"`
+	Volumes  map[string]testDisk `json:"volumes,omitempty"`
+	Gpu      *testCpu            `json:"gpu,omitempty"`
+	Spare    *testDisk           `json:"spare" validate:"omitempty"`
+	Cidr     string              `json:"cidr" validate:"cidr"` // Not a supported rule
+	Ignored  testDisk            `json:"ignored" validate:"-"`
+	Hidden   testDisk            `json:"-"`
+	NoTag    testDisk
+	internal testDisk
+}
+
+type testEmbedded struct {
+	Name string `json:"name" validate:"required"`
+}
+
+type testInfra struct {
+	testEmbedded
+	Servers [][]testServer `json:"servers" validate:"required"` // Nested to check the index paths
+	Tags    []string       `json:"tags" validate:"required"`
+}
+
+type testModel struct {
+	OnpremiseInfraModel testInfra `json:"onpremiseInfraModel" validate:"required"`
+}
+
+func validServer() testServer {
+	return testServer{Hostname: "web01", Cpu: testCpu{Cores: 4}, NoTag: testDisk{Label: "sda"}}
+}
+
+func TestCheck(t *testing.T) {
+	tests := []struct {
+		name  string
+		model func(m *testModel)
+		want  []string
+	}{
+		{
+			name:  "valid",
+			model: func(m *testModel) {},
+		},
+		{
+			name:  "required field of a nested slice",
+			model: func(m *testModel) { m.OnpremiseInfraModel.Servers[0][3].Cpu = testCpu{Model: "Xeon"} },
+			want:  []string{"onpremiseInfraModel.servers[0][3].cpu.cores"},
+		},
+		{
+			name: "required struct reports itself only",
+			model: func(m *testModel) {
+				m.OnpremiseInfraModel.Servers[0][1].Cpu = testCpu{}
+				m.OnpremiseInfraModel.Servers[0][1].Hostname = ""
+			},
+			want: []string{"onpremiseInfraModel.servers[0][1].hostname", "onpremiseInfraModel.servers[0][1].cpu"},
+		},
+		{
+			name: "slice and map elements",
+			model: func(m *testModel) {
+				m.OnpremiseInfraModel.Servers[0][0].Disks = []testDisk{{Label: "sda"}, {}}
+				m.OnpremiseInfraModel.Servers[0][0].Volumes = map[string]testDisk{"b": {}, "a": {}, "c": {Label: "data"}}
+			},
+			want: []string{
+				"onpremiseInfraModel.servers[0][0].disks[1].label",
+				"onpremiseInfraModel.servers[0][0].volumes[a].label",
+				"onpremiseInfraModel.servers[0][0].volumes[b].label",
+			},
+		},
+		{
+			name:  "non-nil pointer",
+			model: func(m *testModel) { m.OnpremiseInfraModel.Servers[0][2].Gpu = &testCpu{Model: "A100"} },
+			want:  []string{"onpremiseInfraModel.servers[0][2].gpu.cores"},
+		},
+		{
+			name:  "omitempty skips a nil pointer but not a set one",
+			model: func(m *testModel) { m.OnpremiseInfraModel.Servers[0][2].Spare = &testDisk{} },
+			want:  []string{"onpremiseInfraModel.servers[0][2].spare.label"},
+		},
+		{
+			name: "unknown rules, skipped and unexported fields",
+			model: func(m *testModel) {
+				s := &m.OnpremiseInfraModel.Servers[0][0]
+				s.Cidr = "not a cidr"
+				s.Ignored = testDisk{}
+				s.Hidden = testDisk{}
+				s.internal = testDisk{}
+			},
+		},
+		{
+			name:  "field without json tag",
+			model: func(m *testModel) { m.OnpremiseInfraModel.Servers[0][0].NoTag = testDisk{} },
+			want:  []string{"onpremiseInfraModel.servers[0][0].NoTag.label"},
+		},
+		{
+			name: "embedded struct and empty slices",
+			model: func(m *testModel) {
+				m.OnpremiseInfraModel.Name = ""
+				m.OnpremiseInfraModel.Servers = [][]testServer{}
+				m.OnpremiseInfraModel.Tags = nil
+			},
+			want: []string{"onpremiseInfraModel.name", "onpremiseInfraModel.servers", "onpremiseInfraModel.tags"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			m := testModel{OnpremiseInfraModel: testInfra{
+				testEmbedded: testEmbedded{Name: "infra"},
+				Servers:      [][]testServer{{validServer(), validServer(), validServer(), validServer()}},
+				Tags:         []string{"prod"},
+			}}
+			tt.model(&m)
+
+			// A struct and a pointer to it report the same failures
+			for _, v := range []any{m, &m} {
+				var got []string
+				for _, e := range Check(v) {
+					got = append(got, e.Path)
+					if e.Rule != RuleRequired || e.Message != "is required" {
+						t.Errorf("%s: rule %q, message %q", e.Path, e.Rule, e.Message)
+					}
+				}
+				if !reflect.DeepEqual(got, tt.want) {
+					t.Errorf("Check(%T) paths = %q, want %q", v, got, tt.want)
+				}
+			}
+		})
+	}
+}
+
+func TestCheckNil(t *testing.T) {
+	var m *testModel
+	for _, v := range []any{nil, m} {
+		if errs := Check(v); len(errs) != 0 {
+			t.Errorf("Check(%#v) = %v, want no failure", v, errs)
+		}
+	}
+}
+
+func TestErrors(t *testing.T) {
+	var errs Errors
+	if errs.Err() != nil {
+		t.Error("Err() of an empty report is not nil")
+	}
+
+	errs.Add("servers[0].cpu.cores", RuleRequired, "is required")
+	errs.Add("network.ipv4Networks.cidrBlocks[1]", "cidr", "%q is not a CIDR block", "10.0.0.0/33")
+	want := "servers[0].cpu.cores: is required\n" +
+		`network.ipv4Networks.cidrBlocks[1]: "10.0.0.0/33" is not a CIDR block`
+	if err := errs.Err(); err == nil || err.Error() != want {
+		t.Errorf("Err() = %v, want %s", err, want)
+	}
+}
+
+func TestPaths(t *testing.T) {
+	if got := JoinPath("", "servers"); got != "servers" {
+		t.Errorf("JoinPath() = %s, want servers", got)
+	}
+	if got := IndexPath(JoinPath("onpremiseInfraModel", "servers"), 3) + ".cpu"; got != "onpremiseInfraModel.servers[3].cpu" {
+		t.Errorf("path = %s, want onpremiseInfraModel.servers[3].cpu", got)
+	}
+}
@@ -0,0 +1,22 @@
+package versioning
+
+// Schema versions of the persisted models
+//
+// recommendedVmInfraModel
+//   - 1: CB-Tumblebug v0.12.5 structs (copied-tb-model.go synchronized on 2026-04-03).
+//     v0.12.5 added vNetTemplateId and sgTemplateId to MciDynamicReq and CreateSubGroupDynamicReq only,
+//     while the model embeds MciReq and CreateSubGroupReq, so documents written before the resync are version 1 too.
+//
+// onpremiseInfraModel, sourceSoftwareModel, targetSoftwareModel, migratedVmInfraModel
+//   - 1: current
+//
+// When a resync of copied-tb-model.go or a change of the models alters the JSON of a persisted model
+// (a renamed, removed or retyped field, or a new field older readers would drop), bump the current version here,
+// register a migration from the previous version and add its case to the tests of migrations_test.go.
+func init() {
+	RegisterKind(KindRecommendedVmInfraModel, 1, nil)
+	RegisterKind(KindOnpremiseInfraModel, 1, nil)
+	RegisterKind(KindSourceSoftwareModel, 1, nil)
+	RegisterKind(KindTargetSoftwareModel, 1, nil)
+	RegisterKind(KindMigratedVmInfraModel, 1, nil)
+}
@@ -0,0 +1,187 @@
+package versioning
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"testing"
+
+	cloudmodel "github.com/cloud-barista/cm-model/infra/cloud-model"
+	onpremisemodel "github.com/cloud-barista/cm-model/infra/on-premise-model"
+	softwaremodel "github.com/cloud-barista/cm-model/sw"
+)
+
+// upgradeCases are the cases of the registered migrations by kind and source version:
+// a model (the value of the kind key) in the source version and the model after the migration.
+var upgradeCases = map[string]map[int][]struct{ in, want string }{
+	testKind: {
+		1: {{in: `{"legacyName": "a", "size": "3"}`, want: `{"name": "a", "size": "3"}`}},
+		2: {
+			{in: `{"name": "a", "size": "3"}`, want: `{"name": "a", "size": 3}`},
+			{in: `{"name": "a"}`, want: `{"name": "a"}`},
+		},
+	},
+}
+
+// TestMigrations runs the cases of each registered migration, and then upgrades them to the current version.
+func TestMigrations(t *testing.T) {
+	for _, kind := range Kinds() {
+		info := kinds[kind]
+		for from := 1; from < info.current; from++ {
+			cases := upgradeCases[kind][from]
+			if len(cases) == 0 {
+				t.Errorf("%s: no test case of the migration from version %d", kind, from)
+				continue
+			}
+			for i, c := range cases {
+				t.Run(fmt.Sprintf("%s/v%d/%d", kind, from, i), func(t *testing.T) {
+					model := decode(t, c.in)
+					if err := info.migrations[from](model); err != nil {
+						t.Fatal(err)
+					}
+					assertJSON(t, model, decode(t, c.want))
+
+					doc := decode(t, fmt.Sprintf(`{"schemaVersion": %d, %q: %s}`, from, kind, c.in))
+					if _, _, err := UpgradeDocument(doc); err != nil {
+						t.Fatal(err)
+					}
+					if doc[SchemaVersionKey] != info.current {
+						t.Errorf("schemaVersion = %v, want %d", doc[SchemaVersionKey], info.current)
+					}
+				})
+			}
+		}
+	}
+}
+
+func TestCurrentVersions(t *testing.T) {
+	want := map[string]int{
+		KindRecommendedVmInfraModel: 1,
+		KindOnpremiseInfraModel:     1,
+		KindSourceSoftwareModel:     1,
+		KindTargetSoftwareModel:     1,
+		KindMigratedVmInfraModel:    1,
+		testKind:                    3,
+	}
+	for _, kind := range Kinds() {
+		if CurrentVersion(kind) != want[kind] {
+			t.Errorf("CurrentVersion(%s) = %d, want %d", kind, CurrentVersion(kind), want[kind])
+		}
+	}
+	if CurrentVersion("otherModel") != 0 {
+		t.Error("CurrentVersion() of an unknown kind is not 0")
+	}
+}
+
+// models are populated values of the persisted models.
+var models = map[string]any{
+	KindRecommendedVmInfraModel: &cloudmodel.RecommendedVmInfraModel{RecommendedVmInfraModel: cloudmodel.RecommendedVmInfra{
+		NameSeed:    "mig",
+		Status:      cloudmodel.StatusRecommended,
+		TargetCloud: cloudmodel.CloudProperty{Csp: "aws", Region: "ap-northeast-2"},
+		TargetVmInfra: cloudmodel.MciReq{
+			Name: "mig",
+			SubGroups: []cloudmodel.CreateSubGroupReq{{
+				Name: "mig-web01", SubGroupSize: 1, RootDiskSize: 50, SpecId: "aws+ap-northeast-2+t3.xlarge",
+				ImageId: "ami-01f71f215b23ba262", VNetId: "mig-vnet-01", SubnetId: "mig-subnet-01",
+				SecurityGroupIds: []string{"mig-sg-01"}, SshKeyId: "mig-sshkey-01",
+			}},
+		},
+		TargetVNet: cloudmodel.VNetReq{Name: "mig-vnet-01", ConnectionName: "aws-ap-northeast-2", CidrBlock: "10.0.0.0/16"},
+	}},
+	KindOnpremiseInfraModel: &onpremisemodel.OnpremiseInfraModel{OnpremiseInfraModel: onpremisemodel.OnpremInfra{
+		Network: onpremisemodel.NetworkProperty{IPv4Networks: onpremisemodel.NetworkDetail{CidrBlocks: []string{"10.0.0.0/16"}}},
+		Servers: []onpremisemodel.ServerProperty{{Hostname: "web01", MachineId: "m-1"}},
+	}},
+	KindSourceSoftwareModel: &softwaremodel.SourceSoftwareModel{SourceSoftwareModel: softwaremodel.SourceGroupSoftwareProperty{
+		ConnectionInfoList: []softwaremodel.SourceConnectionInfoSoftwareProperty{{ConnectionId: "c-1"}},
+	}},
+	KindTargetSoftwareModel: &softwaremodel.TargetSoftwareModel{TargetSoftwareModel: softwaremodel.TargetGroupSoftwareProperty{
+		Servers: []softwaremodel.MigrationServer{{SourceConnectionInfoID: "c-1", Errors: []string{}}},
+	}},
+	KindMigratedVmInfraModel: &cloudmodel.MigratedVmInfraModel{MigratedVmInfraModel: cloudmodel.MigratedVmInfra{
+		NameSeed: "mig", Status: cloudmodel.StatusMigrated,
+	}},
+}
+
+// TestMarshalModels checks that each persisted model is stored with its current version and loaded back unchanged.
+func TestMarshalModels(t *testing.T) {
+	for kind, model := range models {
+		t.Run(kind, func(t *testing.T) {
+			data, err := Marshal(model)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if gotKind, version, err := Detect(decode(t, string(data))); err != nil || gotKind != kind || version != CurrentVersion(kind) {
+				t.Fatalf("Detect() = %s %d %v, want %s %d", gotKind, version, err, kind, CurrentVersion(kind))
+			}
+
+			decoded := reflect.New(reflect.TypeOf(model).Elem()).Interface()
+			if err := Unmarshal(data, decoded); err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(decoded, model) {
+				t.Errorf("Unmarshal() = %+v, want %+v", decoded, model)
+			}
+
+			future := decode(t, string(data))
+			future[SchemaVersionKey] = CurrentVersion(kind) + 1
+			if _, _, err := Detect(future); err == nil {
+				t.Error("a document of a future version is accepted")
+			}
+		})
+	}
+}
+
+// TestUnversionedDocuments checks that documents written before the versioning (without schemaVersion)
+// are loaded without dropping any field.
+func TestUnversionedDocuments(t *testing.T) {
+	documents := map[string]string{
+		// Written with the structs before the CB-Tumblebug v0.12.5 resync, which did not change MciReq
+		KindRecommendedVmInfraModel: `{"recommendedVmInfraModel": {
+			"nameSeed": "mig", "status": "recommended", "description": "",
+			"targetCloud": {"csp": "aws", "region": "ap-northeast-2"},
+			"targetVmInfra": {"name": "mig", "installMonAgent": "no", "label": {}, "systemLabel": "", "description": "",
+				"subGroups": [{"name": "mig-web01", "subGroupSize": 1, "label": {}, "description": "",
+					"connectionName": "aws-ap-northeast-2", "specId": "aws+ap-northeast-2+t3.xlarge", "imageId": "ami-01f71f215b23ba262",
+					"vNetId": "mig-vnet-01", "subnetId": "mig-subnet-01", "securityGroupIds": ["mig-sg-01"], "sshKeyId": "mig-sshkey-01",
+					"vmUserName": "", "vmUserPassword": "", "dataDiskIds": []}],
+				"postCommand": {"userName": "", "command": null}},
+			"targetVNet": {"name": "mig-vnet-01", "connectionName": "aws-ap-northeast-2", "cidrBlock": "10.0.0.0/16",
+				"subnetInfoList": [{"name": "mig-subnet-01", "ipv4_CIDR": "10.0.1.0/24"}], "description": ""},
+			"targetSshKey": {"name": "mig-sshkey-01", "connectionName": "aws-ap-northeast-2", "description": ""},
+			"targetVmSpecList": null, "targetVmOsImageList": null, "targetSecurityGroupList": null}}`,
+		KindOnpremiseInfraModel: `{"onpremiseInfraModel": {"network": {"ipv4Networks": {"cidrBlocks": ["10.0.0.0/16"]}},
+			"servers": [{"hostname": "web01", "machineId": "m-1"}]}}`,
+	}
+	for kind, document := range documents {
+		t.Run(kind, func(t *testing.T) {
+			upgraded, err := Upgrade([]byte(document))
+			if err != nil {
+				t.Fatal(err)
+			}
+			decoded := reflect.New(reflect.TypeOf(models[kind]).Elem()).Interface()
+			var doc map[string]json.RawMessage
+			if err := json.Unmarshal(upgraded, &doc); err != nil {
+				t.Fatal(err)
+			}
+			delete(doc, SchemaVersionKey)
+			data, _ := json.Marshal(doc)
+			decoder := json.NewDecoder(bytes.NewReader(data))
+			decoder.DisallowUnknownFields()
+			if err := decoder.Decode(decoded); err != nil {
+				t.Errorf("a field of the document is dropped: %v", err)
+			}
+		})
+	}
+}
+
+func assertJSON(t *testing.T, got, want any) {
+	t.Helper()
+	g, _ := json.Marshal(got)
+	w, _ := json.Marshal(want)
+	if !bytes.Equal(g, w) {
+		t.Errorf("got %s, want %s", g, w)
+	}
+}
@@ -0,0 +1,213 @@
+// Package versioning provides the schema versions of the persisted models and
+// upgrades older documents step by step to the current shape.
+//
+// A persisted document is the JSON of a top-level model (e.g., RecommendedVmInfraModel)
+// with a `schemaVersion` field next to its single model key, which also identifies the kind:
+//
+//	{"schemaVersion": 2, "recommendedVmInfraModel": {...}}
+//
+// Documents without `schemaVersion` are dated by the detector of their kind.
+// Migrations work on the generic JSON form (map[string]any), so that they do not depend on
+// the current Go structs, which always represent the latest version.
+package versioning
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sort"
+)
+
+// SchemaVersionKey is the field name of the schema version in a persisted document.
+const SchemaVersionKey = "schemaVersion"
+
+// Kinds of the persisted models (the JSON key of each top-level model).
+const (
+	KindRecommendedVmInfraModel = "recommendedVmInfraModel"
+	KindOnpremiseInfraModel     = "onpremiseInfraModel"
+	KindSourceSoftwareModel     = "sourceSoftwareModel"
+	KindTargetSoftwareModel     = "targetSoftwareModel"
+	KindMigratedVmInfraModel    = "migratedVmInfraModel"
+)
+
+// Migration upgrades a model (the value of the kind key) by one version in place.
+type Migration func(model map[string]any) error
+
+// Detector returns the version of a model without `schemaVersion`.
+type Detector func(model map[string]any) int
+
+type kindInfo struct {
+	current    int
+	detect     Detector
+	migrations map[int]Migration // from version -> migration to version+1
+}
+
+var kinds = make(map[string]*kindInfo)
+
+// RegisterKind registers a kind with its current version and the detector of unversioned documents.
+// A nil detector dates unversioned documents as version 1.
+func RegisterKind(kind string, current int, detect Detector) {
+	if current < 1 {
+		panic(fmt.Sprintf("versioning: invalid current version %d of %s", current, kind))
+	}
+	if _, ok := kinds[kind]; ok {
+		panic("versioning: duplicate kind " + kind)
+	}
+	kinds[kind] = &kindInfo{current: current, detect: detect, migrations: make(map[int]Migration)}
+}
+
+// RegisterMigration registers the migration of a kind from a version to the next one.
+func RegisterMigration(kind string, from int, migrate Migration) {
+	info, ok := kinds[kind]
+	if !ok {
+		panic("versioning: unknown kind " + kind)
+	}
+	if from < 1 || from >= info.current {
+		panic(fmt.Sprintf("versioning: migration from %d is out of range of %s (current: %d)", from, kind, info.current))
+	}
+	if _, ok := info.migrations[from]; ok {
+		panic(fmt.Sprintf("versioning: duplicate migration of %s from %d", kind, from))
+	}
+	info.migrations[from] = migrate
+}
+
+// CurrentVersion returns the current version of a kind, or 0 if the kind is unknown.
+func CurrentVersion(kind string) int {
+	if info, ok := kinds[kind]; ok {
+		return info.current
+	}
+	return 0
+}
+
+// Kinds returns the registered kinds in alphabetical order.
+func Kinds() []string {
+	names := make([]string, 0, len(kinds))
+	for kind := range kinds {
+		names = append(names, kind)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// Detect returns the kind and version of a document.
+func Detect(doc map[string]any) (string, int, error) {
+	var kind string
+	for key := range doc {
+		if key == SchemaVersionKey {
+			continue
+		}
+		if _, ok := kinds[key]; !ok {
+			continue
+		}
+		if kind != "" {
+			return "", 0, fmt.Errorf("ambiguous document with %s and %s", kind, key)
+		}
+		kind = key
+	}
+	if kind == "" {
+		return "", 0, errors.New("unknown document kind")
+	}
+	info := kinds[kind]
+
+	if raw, ok := doc[SchemaVersionKey]; ok {
+		version, err := toVersion(raw)
+		if err != nil {
+			return "", 0, err
+		}
+		if version > info.current {
+			return "", 0, fmt.Errorf("%s version %d is newer than the supported version %d", kind, version, info.current)
+		}
+		return kind, version, nil
+	}
+
+	model, ok := doc[kind].(map[string]any)
+	if !ok || info.detect == nil {
+		return kind, 1, nil
+	}
+	return kind, info.detect(model), nil
+}
+
+// UpgradeDocument migrates a document in place to the current version of its kind and sets `schemaVersion`.
+// It returns the kind and the version the document was in.
+func UpgradeDocument(doc map[string]any) (string, int, error) {
+	kind, version, err := Detect(doc)
+	if err != nil {
+		return "", 0, err
+	}
+	info := kinds[kind]
+
+	model, _ := doc[kind].(map[string]any)
+	for v := version; v < info.current; v++ {
+		migrate, ok := info.migrations[v]
+		if !ok {
+			return kind, version, fmt.Errorf("no migration of %s from version %d", kind, v)
+		}
+		if model == nil {
+			continue
+		}
+		if err := migrate(model); err != nil {
+			return kind, version, fmt.Errorf("failed to migrate %s from version %d: %w", kind, v, err)
+		}
+	}
+	doc[SchemaVersionKey] = info.current
+	return kind, version, nil
+}
+
+// Upgrade migrates a JSON document to the current version of its kind.
+func Upgrade(data []byte) ([]byte, error) {
+	var doc map[string]any
+	if err := json.Unmarshal(data, &doc); err != nil {
+		return nil, fmt.Errorf("invalid document: %w", err)
+	}
+	if _, _, err := UpgradeDocument(doc); err != nil {
+		return nil, err
+	}
+	return json.Marshal(doc)
+}
+
+// Unmarshal upgrades a JSON document and decodes it into v (e.g., *cloudmodel.RecommendedVmInfraModel).
+func Unmarshal(data []byte, v any) error {
+	upgraded, err := Upgrade(data)
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(upgraded, v)
+}
+
+// Marshal encodes a top-level model (e.g., cloudmodel.RecommendedVmInfraModel) with the current `schemaVersion`.
+func Marshal(v any) ([]byte, error) {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return nil, err
+	}
+	var doc map[string]json.RawMessage
+	if err := json.Unmarshal(data, &doc); err != nil {
+		return nil, fmt.Errorf("not a top-level model: %w", err)
+	}
+
+	var kind string
+	for key := range doc {
+		if _, ok := kinds[key]; ok {
+			kind = key
+		}
+	}
+	if kind == "" || len(doc) != 1 {
+		return nil, errors.New("not a top-level model of a registered kind")
+	}
+	doc[SchemaVersionKey] = json.RawMessage(fmt.Sprint(kinds[kind].current))
+	return json.Marshal(doc)
+}
+
+func toVersion(raw any) (int, error) {
+	switch v := raw.(type) {
+	case float64:
+		if v >= 1 && v == float64(int(v)) {
+			return int(v), nil
+		}
+	case int:
+		if v >= 1 {
+			return v, nil
+		}
+	}
+	return 0, fmt.Errorf("invalid %s: %v", SchemaVersionKey, raw)
+}
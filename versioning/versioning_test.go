@@ -0,0 +1,160 @@
+package versioning
+
+import (
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+// testKind is a kind registered for the tests of the framework, with the versions
+//   - 1: {"legacyName": "a", "size": "3"}
+//   - 2: {"name": "a", "size": "3"}
+//   - 3: {"name": "a", "size": 3}
+const testKind = "testModel"
+
+func init() {
+	RegisterKind(testKind, 3, func(model map[string]any) int {
+		if _, ok := model["legacyName"]; ok {
+			return 1
+		}
+		if _, ok := model["size"].(string); ok {
+			return 2
+		}
+		return 3
+	})
+	RegisterMigration(testKind, 1, func(model map[string]any) error {
+		model["name"] = model["legacyName"]
+		delete(model, "legacyName")
+		return nil
+	})
+	RegisterMigration(testKind, 2, func(model map[string]any) error {
+		s, ok := model["size"].(string)
+		if !ok {
+			return nil
+		}
+		n, err := strconv.Atoi(s)
+		if err != nil {
+			return fmt.Errorf("size %q is not a number", s)
+		}
+		model["size"] = n
+		return nil
+	})
+}
+
+func decode(t *testing.T, s string) map[string]any {
+	t.Helper()
+	var doc map[string]any
+	if err := json.Unmarshal([]byte(s), &doc); err != nil {
+		t.Fatal(err)
+	}
+	return doc
+}
+
+func TestDetect(t *testing.T) {
+	tests := []struct {
+		name        string
+		doc         string
+		wantKind    string
+		wantVersion int
+		wantErr     string
+	}{
+		{name: "explicit version", doc: `{"schemaVersion": 2, "testModel": {"legacyName": "a"}}`, wantKind: testKind, wantVersion: 2},
+		{name: "detected version 1", doc: `{"testModel": {"legacyName": "a", "size": "3"}}`, wantKind: testKind, wantVersion: 1},
+		{name: "detected version 2", doc: `{"testModel": {"name": "a", "size": "3"}}`, wantKind: testKind, wantVersion: 2},
+		{name: "detected current version", doc: `{"testModel": {"name": "a", "size": 3}}`, wantKind: testKind, wantVersion: 3},
+		{name: "kind without detector", doc: `{"sourceSoftwareModel": {}}`, wantKind: KindSourceSoftwareModel, wantVersion: 1},
+		{name: "unknown kind", doc: `{"schemaVersion": 1, "otherModel": {}}`, wantErr: "unknown document kind"},
+		{name: "ambiguous", doc: `{"testModel": {}, "sourceSoftwareModel": {}}`, wantErr: "ambiguous"},
+		{name: "future version", doc: `{"schemaVersion": 4, "testModel": {}}`, wantErr: "newer than the supported version 3"},
+		{name: "zero version", doc: `{"schemaVersion": 0, "testModel": {}}`, wantErr: "invalid schemaVersion"},
+		{name: "fractional version", doc: `{"schemaVersion": 1.5, "testModel": {}}`, wantErr: "invalid schemaVersion"},
+		{name: "string version", doc: `{"schemaVersion": "1", "testModel": {}}`, wantErr: "invalid schemaVersion"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			kind, version, err := Detect(decode(t, tt.doc))
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("Detect() error = %v, want %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if kind != tt.wantKind || version != tt.wantVersion {
+				t.Errorf("Detect() = %s %d, want %s %d", kind, version, tt.wantKind, tt.wantVersion)
+			}
+		})
+	}
+}
+
+func TestUpgradeErrors(t *testing.T) {
+	tests := []struct {
+		name    string
+		doc     string
+		wantErr string
+	}{
+		{name: "future version", doc: `{"schemaVersion": 4, "testModel": {"name": "a", "size": 3}}`, wantErr: "newer than the supported version"},
+		{name: "failed migration", doc: `{"schemaVersion": 2, "testModel": {"name": "a", "size": "three"}}`, wantErr: "failed to migrate testModel from version 2"},
+		{name: "invalid JSON", doc: `{"testModel": `, wantErr: "invalid document"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := Upgrade([]byte(tt.doc)); err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("Upgrade() error = %v, want %q", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestUpgradeDocument(t *testing.T) {
+	doc := decode(t, `{"testModel": {"legacyName": "a", "size": "3"}}`)
+	kind, version, err := UpgradeDocument(doc)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if kind != testKind || version != 1 {
+		t.Errorf("UpgradeDocument() = %s %d, want %s 1", kind, version, testKind)
+	}
+	got, _ := json.Marshal(doc)
+	if want := `{"schemaVersion":3,"testModel":{"name":"a","size":3}}`; string(got) != want {
+		t.Errorf("upgraded document = %s, want %s", got, want)
+	}
+}
+
+func TestMarshal(t *testing.T) {
+	type model struct {
+		Name string `json:"name"`
+		Size int    `json:"size"`
+	}
+	type document struct {
+		TestModel model `json:"testModel"`
+	}
+
+	data, err := Marshal(document{TestModel: model{Name: "a", Size: 3}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := `{"schemaVersion":3,"testModel":{"name":"a","size":3}}`; string(data) != want {
+		t.Errorf("Marshal() = %s, want %s", data, want)
+	}
+	var decoded document
+	if err := Unmarshal(data, &decoded); err != nil {
+		t.Fatal(err)
+	}
+	if decoded.TestModel != (model{Name: "a", Size: 3}) {
+		t.Errorf("Unmarshal() = %+v", decoded)
+	}
+
+	if _, err := Marshal(struct {
+		Other model `json:"otherModel"`
+	}{}); err == nil {
+		t.Error("Marshal() of an unregistered kind succeeded")
+	}
+	if _, err := Marshal(model{}); err == nil {
+		t.Error("Marshal() of a model without kind key succeeded")
+	}
+}